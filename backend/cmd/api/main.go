@@ -5,11 +5,14 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/fitranmei/Mooove-/backend/config"
 	"github.com/fitranmei/Mooove-/backend/db"
 	"github.com/fitranmei/Mooove-/backend/handlers"
+	"github.com/fitranmei/Mooove-/backend/middlewares"
+	"github.com/fitranmei/Mooove-/backend/models"
 	"github.com/fitranmei/Mooove-/backend/repositories"
 	"github.com/fitranmei/Mooove-/backend/services"
 	"github.com/gofiber/fiber/v2"
@@ -29,13 +32,16 @@ func main() {
 
 	cfg := config.Load()
 
+	penyandiIdentitas, err := services.NewPenyandiIdentitasDariConfig(cfg.IdentitasKunci, cfg.IdentitasKunciIndeks, cfg.IdentitasKunciDev)
+	if err != nil {
+		log.Fatalf("gagal menyiapkan enkripsi identitas: %v", err)
+	}
+	models.SetPenyandiIdentitas(penyandiIdentitas)
+
 	database := db.ConnectMySQL(cfg)
 
 	db.RunMigrations(database)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	services.StartReservedCleanup(ctx, database, 1*time.Minute)
-
 	repoStasiun := repositories.NewStasiunRepo(database)
 	repoKereta := repositories.NewKeretaRepo(database)
 	repoJadwal := repositories.NewJadwalRepo(database)
@@ -43,17 +49,81 @@ func main() {
 	repoKursi := repositories.NewKursiRepo(database)
 
 	authRepo := repositories.NewAuthRepo(database)
+	userRepo := repositories.NewUserRepo(database)
+	refreshTokenRepo := repositories.NewRefreshTokenRepo(database)
+	kunciJWTRepo := repositories.NewKunciJWTRepo(database)
+	tokenAkunRepo := repositories.NewTokenAkunRepo(database)
+	percobaanLoginRepo := repositories.NewPercobaanLoginRepo(database)
+	penumpangTersimpanRepo := repositories.NewPenumpangTersimpanRepo(database)
+	kebijakanBookingRepo := repositories.NewKebijakanBookingRepo(database)
+	tataLetakRepo := repositories.NewTataLetakGerbongRepo(database)
 	bookingRepo := repositories.NewBookingRepo(database)
 	ketersediaanRepo := repositories.NewKetersediaanRepo(database)
 	paymentRepo := repositories.NewPaymentRepo(database)
 	tiketRepo := repositories.NewTiketRepo(database)
+	layananRepo := repositories.NewLayananRepo(database)
+	voucherRepo := repositories.NewVoucherRepo(database)
+	aturanHargaRepo := repositories.NewAturanHargaRepo(database)
+	templateJadwalRepo := repositories.NewTemplateJadwalRepo(database)
+
+	pengelolaKunci, err := services.NewPengelolaKunci(kunciJWTRepo, cfg.JwtSecret, cfg.JwtAlg, cfg.JwtKunciEnkripsi)
+	if err != nil {
+		log.Fatalf("gagal menyiapkan kunci JWT: %v", err)
+	}
+	middlewares.InitAuth(pengelolaKunci.Keyfunc)
+
+	pelindungLogin := services.NewPelindungLogin(percobaanLoginRepo, cfg.LoginMaksGagalAkun, cfg.LoginMaksGagalIP, cfg.LoginBlokirMenit)
+	authService := services.NewAuthServiceImpl(
+		authRepo,
+		refreshTokenRepo,
+		pengelolaKunci,
+		pelindungLogin,
+		time.Duration(cfg.AccessTokenMenit)*time.Minute,
+		time.Duration(cfg.RefreshTokenHari)*24*time.Hour,
+	)
+
+	pengirimEmail := services.NewPengirimEmail(cfg.MailDriver, cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.MailFrom, cfg.MailDir)
+	akunService := services.NewAkunService(userRepo, tokenAkunRepo, refreshTokenRepo, pengirimEmail, cfg.AppURL, cfg.ResetPasswordURL)
+
+	voucherService := services.NewVoucherService(database)
 
-	authService := services.NewAuthServiceImpl(authRepo, cfg.JwtSecret, 24*time.Hour)
+	hargaDinamisService := services.NewHargaDinamisService(aturanHargaRepo)
 
-	bookingService := services.NewBookingService(database, bookingRepo, ketersediaanRepo)
+	tarifService := services.NewTarifService(database, layananRepo, repoJadwal, voucherService, hargaDinamisService, cfg.TarifKelas, cfg.DiskonPenumpang)
+
+	bookingService := services.NewBookingService(database, bookingRepo, ketersediaanRepo, tarifService, voucherService, services.OpsiBooking{
+		WajibVerifikasiEmail: cfg.WajibVerifikasiEmail,
+		LamaHold:             cfg.HoldMenit,
+		PerpanjangHoldMenit:  cfg.PerpanjangHoldMenit,
+	})
 
 	paymentService := services.NewPaymentService(cfg, paymentRepo, bookingService)
 
+	perjalananService := services.NewPerjalananService(database, repoJadwal, tarifService, cfg.MinTransferMenit)
+
+	generatorJadwalService := services.NewGeneratorJadwalService(database, templateJadwalRepo, repoJadwal, cfg.GenerateJadwalHari)
+
+	gtfsService := services.NewGtfsService(database, repoJadwal, services.InfoAgensi{
+		Nama:     cfg.GtfsAgensi,
+		URL:      cfg.GtfsURL,
+		Timezone: cfg.GtfsTimezone,
+	})
+
+	imporJadwalService := services.NewImporJadwalService(database, repoJadwal)
+	dataPribadiService := services.NewDataPribadiService(database, userRepo, ketersediaanRepo)
+
+	if len(os.Args) > 1 {
+		if err := jalankanPerintah(os.Args[1], os.Args[2:], database, gtfsService, userRepo, penyandiIdentitas); err != nil {
+			log.Fatalf("%s gagal: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	services.StartReservedCleanup(ctx, database, 1*time.Minute)
+	services.StartGeneratorJadwal(ctx, generatorJadwalService, 1*time.Hour)
+	services.StartMuatUlangKunci(ctx, pengelolaKunci, 1*time.Minute)
+
 	handlers.InitHandlers(
 		repoStasiun,
 		repoKereta,
@@ -65,6 +135,7 @@ func main() {
 		tiketRepo,
 		authService,
 		paymentService,
+		bookingService,
 		database,
 	)
 
@@ -74,7 +145,23 @@ func main() {
 		database,
 	)
 
-	app := fiber.New()
+	handlers.InitPerjalananHandler(perjalananService)
+	handlers.InitTarifHandler(tarifService, layananRepo)
+	handlers.InitVoucherHandler(voucherRepo)
+	handlers.InitAturanHargaHandler(aturanHargaRepo, hargaDinamisService)
+	handlers.InitTemplateJadwalHandler(templateJadwalRepo, generatorJadwalService)
+	handlers.InitGtfsHandler(gtfsService)
+	handlers.InitImporHandler(imporJadwalService)
+	handlers.InitRoleHandler(userRepo)
+	handlers.InitKunciHandler(pengelolaKunci)
+	handlers.InitAkunHandler(akunService)
+	handlers.InitPercobaanLoginHandler(percobaanLoginRepo)
+	handlers.InitPenumpangTersimpanHandler(penumpangTersimpanRepo)
+	handlers.InitDataPribadiHandler(dataPribadiService)
+	handlers.InitKebijakanBookingHandler(kebijakanBookingRepo)
+	handlers.InitTataLetakGerbongHandler(tataLetakRepo)
+
+	app := fiber.New(fiberConfig(cfg))
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
@@ -98,3 +185,22 @@ func main() {
 	log.Printf("API server running on %s", addr)
 	log.Fatal(app.Listen(addr))
 }
+
+// fiberConfig hanya mempercayai header IP dari proxy yang terdaftar; tanpa
+// TRUSTED_PROXIES siapa pun bisa memalsukan header untuk lolos batas login
+// per IP.
+func fiberConfig(cfg *config.Config) fiber.Config {
+	var fc fiber.Config
+	if cfg.TrustedProxies == "" {
+		return fc
+	}
+	for _, p := range strings.Split(cfg.TrustedProxies, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			fc.TrustedProxies = append(fc.TrustedProxies, p)
+		}
+	}
+	fc.EnableTrustedProxyCheck = true
+	fc.ProxyHeader = cfg.ProxyHeader
+	fc.EnableIPValidation = true
+	return fc
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/fitranmei/Mooove-/backend/services"
+	"gorm.io/gorm"
+)
+
+// jalankanPerintah menjalankan subcommand CLI sebagai pengganti server API.
+func jalankanPerintah(nama string, args []string, db *gorm.DB, gtfs *services.GtfsService, users repositories.UserRepo, penyandi *services.PenyandiAmplop) error {
+	switch nama {
+	case "export-gtfs":
+		return eksporGtfs(args, gtfs)
+	case "grant-role":
+		return grantRole(args, users)
+	case "sandi-ulang-identitas":
+		return sandiUlangIdentitas(db, penyandi)
+	case "terapkan-tata-letak":
+		return terapkanTataLetak(db)
+	default:
+		return fmt.Errorf("perintah tidak dikenal: %s (tersedia: export-gtfs, grant-role, sandi-ulang-identitas, terapkan-tata-letak)", nama)
+	}
+}
+
+// eksporGtfs menjalankan subcommand:
+//
+//	main export-gtfs -o gtfs.zip -dari 2025-01-01 -sampai 2025-01-31
+func eksporGtfs(args []string, svc *services.GtfsService) error {
+	now := time.Now()
+	fs := flag.NewFlagSet("export-gtfs", flag.ContinueOnError)
+	out := fs.String("o", "gtfs.zip", "file zip tujuan")
+	dari := fs.String("dari", now.Format("2006-01-02"), "tanggal awal (YYYY-MM-DD)")
+	sampai := fs.String("sampai", now.AddDate(0, 0, 30).Format("2006-01-02"), "tanggal akhir (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	peringatan, err := svc.Ekspor(f, *dari, *sampai)
+	if err != nil {
+		f.Close()
+		os.Remove(*out)
+		return err
+	}
+	for _, p := range peringatan {
+		fmt.Fprintln(os.Stderr, "peringatan:", p)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("feed GTFS ditulis ke %s (%s s/d %s)\n", *out, *dari, *sampai)
+	return nil
+}
+
+// grantRole memberi role ke user lewat email, dipakai untuk membuat admin
+// pertama:
+//
+//	main grant-role -email admin@mooove.id -role admin
+func grantRole(args []string, users repositories.UserRepo) error {
+	fs := flag.NewFlagSet("grant-role", flag.ContinueOnError)
+	email := fs.String("email", "", "email user")
+	role := fs.String("role", models.RoleAdmin, "role yang diberikan")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !models.RoleValid(*role) {
+		return fmt.Errorf("role %s tidak dikenal", *role)
+	}
+
+	user, err := users.FindByEmail(*email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if err := users.GrantRole(user.ID, *role); err != nil {
+		return err
+	}
+	fmt.Printf("role %s diberikan ke %s\n", *role, user.Email)
+	return nil
+}
+
+// sandiUlangIdentitas mengenkripsi nomor identitas yang masih plaintext dan
+// membungkus ulang nilai lama dengan kunci aktif. Jalankan setelah deploy
+// pertama fitur enkripsi dan setiap kali IDENTITAS_KUNCI dirotasi:
+//
+//	main sandi-ulang-identitas
+func sandiUlangIdentitas(db *gorm.DB, penyandi *services.PenyandiAmplop) error {
+	for _, tabel := range []string{"penumpangs", "penumpang_tersimpans"} {
+		var lastID uint
+		jumlah := 0
+		for {
+			var rows []struct {
+				ID          uint
+				NoIdentitas string
+			}
+			if err := db.Table(tabel).Select("id, no_identitas").
+				Where("id > ?", lastID).Order("id asc").Limit(500).
+				Scan(&rows).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				break
+			}
+			for _, r := range rows {
+				lastID = r.ID
+				if !penyandi.PerluSandiUlang(r.NoIdentitas) {
+					continue
+				}
+				plain := r.NoIdentitas
+				if penyandi.Tersandi(plain) {
+					var err error
+					if plain, err = penyandi.Dekripsi(plain); err != nil {
+						return fmt.Errorf("%s id %d: %w", tabel, r.ID, err)
+					}
+				}
+				if err := db.Table(tabel).Where("id = ?", r.ID).Updates(map[string]interface{}{
+					"no_identitas":     models.Identitas(plain),
+					"no_identitas_idx": models.IndeksIdentitas(plain),
+				}).Error; err != nil {
+					return err
+				}
+				jumlah++
+			}
+		}
+		fmt.Printf("%s: %d nomor identitas dienkripsi ulang\n", tabel, jumlah)
+	}
+	return nil
+}
+
+// terapkanTataLetak mengisi ulang atribut kursi (jendela, lorong, arah,
+// prioritas) dari tata letak gerbongnya. Nomor kursi tidak diubah, jadi
+// aman untuk gerbong yang sudah punya booking. Gerbong lama tanpa tata letak
+// dilewati sampai admin memilihkan tata letak yang sesuai kursinya:
+//
+//	main terapkan-tata-letak
+func terapkanTataLetak(db *gorm.DB) error {
+	var gerbongs []models.Gerbong
+	if err := db.Preload("TataLetak").Preload("Kursis").Order("id asc").Find(&gerbongs).Error; err != nil {
+		return err
+	}
+	tataLetaks, err := repositories.AmbilTataLetakGerbongs(db, gerbongs)
+	if err != nil {
+		return err
+	}
+	jumlah, dilewati := 0, 0
+	for i := range gerbongs {
+		g := &gerbongs[i]
+		if g.TataLetakID == nil && g.KursiTanpaAtribut() {
+			dilewati++
+			continue
+		}
+		tataLetak := tataLetaks[i]
+		for _, k := range g.Kursis {
+			k.Baris, k.Kolom = k.Posisi()
+			if k.Baris == 0 {
+				continue
+			}
+			tataLetak.Terapkan(&k)
+			if err := db.Model(&models.Kursi{}).Where("id = ?", k.ID).Updates(map[string]interface{}{
+				"baris":      k.Baris,
+				"kolom":      k.Kolom,
+				"jendela":    k.Jendela,
+				"lorong":     k.Lorong,
+				"prioritas":  k.Prioritas,
+				"kursi_roda": k.KursiRoda,
+				"arah":       k.Arah,
+			}).Error; err != nil {
+				return err
+			}
+			jumlah++
+		}
+	}
+	fmt.Printf("atribut %d kursi diperbarui dari tata letak gerbong, %d gerbong lama dilewati\n", jumlah, dilewati)
+	return nil
+}
@@ -1,28 +1,124 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+)
 
 type Config struct {
 	DSN        string
 	Port       string
 	JwtSecret  string
+	JwtAlg     string
 	StorageDir string
 
+	// JwtKunciEnkripsi berformat "kid:base64,..." untuk mengenkripsi private
+	// key JWT di database; wajib bila JwtAlg asimetris.
+	JwtKunciEnkripsi string
+
+	AccessTokenMenit int
+	RefreshTokenHari int
+
 	MidtransServerKey string
 	MidtransClientKey string
 	MidtransEnv       string
+
+	MinTransferMenit int
+	TarifKelas       string
+	DiskonPenumpang  string
+
+	GenerateJadwalHari int
+
+	GtfsAgensi   string
+	GtfsURL      string
+	GtfsTimezone string
+
+	AppURL               string
+	ResetPasswordURL     string
+	MailDriver           string
+	MailFrom             string
+	MailDir              string
+	SMTPHost             string
+	SMTPPort             string
+	SMTPUser             string
+	SMTPPass             string
+	WajibVerifikasiEmail bool
+
+	// HoldMenit berformat "kanal:menit,..." (lihat services.OpsiBooking)
+	HoldMenit           string
+	PerpanjangHoldMenit int
+
+	LoginMaksGagalAkun int
+	LoginMaksGagalIP   int
+	LoginBlokirMenit   int
+
+	// TrustedProxies berisi IP/CIDR reverse proxy, dipisah koma. Bila diisi,
+	// IP klien (dipakai batas login per IP) dibaca dari ProxyHeader yang
+	// ditulis ulang oleh proxy tersebut; bila kosong dipakai IP koneksi.
+	TrustedProxies string
+	ProxyHeader    string
+
+	// IdentitasKunci berformat "kid:base64,..."; kunci pertama aktif.
+	// IdentitasKunciIndeks tidak boleh diganti karena blind index yang sudah
+	// tersimpan akan berhenti cocok.
+	IdentitasKunci       string
+	IdentitasKunciIndeks string
+	// IdentitasKunciDev mengizinkan kunci pengembangan bila dua kunci di
+	// atas kosong; jangan diaktifkan di production.
+	IdentitasKunciDev bool
 }
 
 func Load() *Config {
 	return &Config{
 		DSN:        getenv("DSN", "root:@tcp(127.0.0.1:3306)/mooove_db?parseTime=true&loc=Local"),
 		Port:       getenv("PORT", "8080"),
-		JwtSecret:  getenv("JWT_SECRET", "verymooove123"),
+		JwtSecret:  os.Getenv("JWT_SECRET"),
+		JwtAlg:     getenv("JWT_ALG", "HS256"),
 		StorageDir: getenv("STORAGE_DIR", "./storage"),
 
+		JwtKunciEnkripsi: os.Getenv("JWT_KUNCI_ENKRIPSI"),
+
+		AccessTokenMenit: getenvInt("ACCESS_TOKEN_MENIT", 15),
+		RefreshTokenHari: getenvInt("REFRESH_TOKEN_HARI", 30),
+
 		MidtransServerKey: getenv("MIDTRANS_SERVER_KEY", "SB-Mid-server-REPLACE_ME"),
 		MidtransClientKey: getenv("MIDTRANS_CLIENT_KEY", "SB-Mid-client-REPLACE_ME"),
 		MidtransEnv:       getenv("MIDTRANS_ENV", "sandbox"),
+
+		MinTransferMenit: getenvInt("MIN_TRANSFER_MENIT", 15),
+		TarifKelas:       getenv("TARIF_KELAS", "eksekutif:100,bisnis:100,ekonomi:100"),
+		DiskonPenumpang:  getenv("DISKON_PENUMPANG", "dewasa:0,anak:25,bayi:90,lansia:20"),
+
+		GenerateJadwalHari: getenvInt("GENERATE_JADWAL_HARI", 30),
+
+		GtfsAgensi:   getenv("GTFS_AGENSI", "Mooove"),
+		GtfsURL:      getenv("GTFS_URL", "https://mooove.id"),
+		GtfsTimezone: getenv("GTFS_TIMEZONE", "Asia/Jakarta"),
+
+		AppURL:               getenv("APP_URL", "http://localhost:8080"),
+		ResetPasswordURL:     os.Getenv("RESET_PASSWORD_URL"),
+		MailDriver:           getenv("MAIL_DRIVER", "file"),
+		MailFrom:             getenv("MAIL_FROM", "Mooove <no-reply@mooove.id>"),
+		MailDir:              getenv("MAIL_DIR", "./storage/mail"),
+		SMTPHost:             getenv("SMTP_HOST", "localhost"),
+		SMTPPort:             getenv("SMTP_PORT", "587"),
+		SMTPUser:             os.Getenv("SMTP_USER"),
+		SMTPPass:             os.Getenv("SMTP_PASS"),
+		WajibVerifikasiEmail: getenvBool("WAJIB_VERIFIKASI_EMAIL", false),
+
+		HoldMenit:           getenv("HOLD_MENIT", "default:15,web:15,app:15,loket:5"),
+		PerpanjangHoldMenit: getenvInt("PERPANJANG_HOLD_MENIT", 10),
+
+		LoginMaksGagalAkun: getenvInt("LOGIN_MAKS_GAGAL_AKUN", 10),
+		LoginMaksGagalIP:   getenvInt("LOGIN_MAKS_GAGAL_IP", 50),
+		LoginBlokirMenit:   getenvInt("LOGIN_BLOKIR_MENIT", 15),
+
+		TrustedProxies: os.Getenv("TRUSTED_PROXIES"),
+		ProxyHeader:    getenv("PROXY_HEADER", "X-Real-IP"),
+
+		IdentitasKunci:       os.Getenv("IDENTITAS_KUNCI"),
+		IdentitasKunciIndeks: os.Getenv("IDENTITAS_KUNCI_INDEKS"),
+		IdentitasKunciDev:    getenvBool("IDENTITAS_KUNCI_DEV", false),
 	}
 }
 
@@ -34,6 +130,22 @@ func getenv(key, fallback string) string {
 	return fallback
 }
 
+func getenvInt(key string, fallback int) int {
+	val, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
+func getenvBool(key string, fallback bool) bool {
+	val, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
 func (c *Config) IsSandbox() bool {
 	return c.MidtransEnv == "sandbox"
 }
@@ -4,27 +4,119 @@ import (
 	"log"
 
 	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
 	"gorm.io/gorm"
 )
 
 func RunMigrations(db *gorm.DB) {
 	if db.Migrator().HasTable("penumpangs") && db.Migrator().HasTable("kursis") {
-		db.Exec("DELETE FROM penumpangs WHERE seat_id NOT IN (SELECT id FROM kursis)")
+		// bayi tidak mendapat kursi; dulu disimpan sebagai seat_id 0 yang
+		// ditolak foreign key ke kursis, sekarang NULL
+		db.Exec("UPDATE penumpangs SET seat_id = NULL WHERE seat_id = 0")
+		db.Exec("DELETE FROM penumpangs WHERE seat_id IS NOT NULL AND seat_id NOT IN (SELECT id FROM kursis)")
 	}
 
-	if err := db.AutoMigrate(
+	if db.Migrator().HasIndex(&models.KetersediaanKursi{}, "idx_schedule_seat") {
+		if err := db.Migrator().DropIndex(&models.KetersediaanKursi{}, "idx_schedule_seat"); err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+	}
+
+	if err := db.AutoMigrate(SemuaModel()...); err != nil {
+		log.Fatalf("migration failed: %v", err)
+	}
+
+	if err := repositories.SeedTataLetakBawaan(db); err != nil {
+		log.Fatalf("seed tata letak gagal: %v", err)
+	}
+	if db.Migrator().HasTable(&models.Tiket{}) {
+		if err := db.Migrator().AlterColumn(&models.Tiket{}, "SeatID"); err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+		db.Exec("UPDATE tikets SET seat_id = NULL WHERE seat_id = 0")
+	}
+	backfillJadwalStops(db)
+	backfillBookingLegs(db)
+	log.Println("Migration complete (User)")
+}
+
+// backfillJadwalStops membuat stop asal/tujuan untuk jadwal lama yang dibuat
+// sebelum rute multi-stop ada.
+func backfillJadwalStops(db *gorm.DB) {
+	res := db.Exec(`
+		INSERT INTO jadwal_stops (jadwal_id, urutan, stasiun_id, waktu_berangkat, harga_kumulatif, created_at, updated_at)
+		SELECT j.id, 0, j.asal_id, j.waktu_berangkat, 0, NOW(), NOW()
+		FROM jadwals j
+		WHERE NOT EXISTS (SELECT 1 FROM jadwal_stops s WHERE s.jadwal_id = j.id)`)
+	if res.Error != nil {
+		log.Fatalf("backfill jadwal_stops gagal: %v", res.Error)
+	}
+
+	if err := db.Exec(`
+		INSERT INTO jadwal_stops (jadwal_id, urutan, stasiun_id, waktu_tiba, harga_kumulatif, created_at, updated_at)
+		SELECT j.id, 1, j.tujuan_id, j.waktu_tiba, j.harga, NOW(), NOW()
+		FROM jadwals j
+		WHERE (SELECT COUNT(*) FROM jadwal_stops s WHERE s.jadwal_id = j.id) = 1`).Error; err != nil {
+		log.Fatalf("backfill jadwal_stops gagal: %v", err)
+	}
+
+	if res.RowsAffected > 0 {
+		log.Printf("backfill %d jadwal ke rute 2 stop", res.RowsAffected)
+	}
+}
+
+// backfillBookingLegs membuat satu leg untuk booking lama yang hanya punya
+// satu jadwal, lalu mengaitkan penumpangnya ke leg tersebut.
+func backfillBookingLegs(db *gorm.DB) {
+	if err := db.Exec(`
+		INSERT INTO booking_legs (booking_id, urutan, train_schedule_id, naik_stasiun_id, turun_stasiun_id, created_at)
+		SELECT b.id, 0, b.train_schedule_id, b.naik_stasiun_id, b.turun_stasiun_id, b.created_at
+		FROM bookings b
+		WHERE NOT EXISTS (SELECT 1 FROM booking_legs l WHERE l.booking_id = b.id)`).Error; err != nil {
+		log.Fatalf("backfill booking_legs gagal: %v", err)
+	}
+
+	if err := db.Exec(`
+		UPDATE penumpangs p
+		JOIN booking_legs l ON l.booking_id = p.booking_id AND l.urutan = 0
+		SET p.booking_leg_id = l.id
+		WHERE p.booking_leg_id = 0`).Error; err != nil {
+		log.Fatalf("backfill booking_legs gagal: %v", err)
+	}
+}
+
+// SemuaModel berisi semua tabel yang dibuat AutoMigrate, berurutan sesuai
+// foreign key.
+func SemuaModel() []interface{} {
+	return []interface{}{
 		&models.User{},
+		&models.UserRole{},
+		&models.RefreshToken{},
+		&models.KunciJWT{},
+		&models.TokenAkun{},
+		&models.PercobaanLogin{},
+		&models.PenumpangTersimpan{},
+		&models.KebijakanBooking{},
 		&models.Stasiun{},
 		&models.Kereta{},
 		&models.Jadwal{},
+		&models.JadwalStop{},
+		&models.TataLetakGerbong{},
 		&models.Gerbong{},
 		&models.Kursi{},
 		&models.KetersediaanKursi{},
 		&models.Booking{},
+		&models.BookingLeg{},
+		&models.BookingItem{},
+		&models.LayananTambahan{},
+		&models.Voucher{},
+		&models.VoucherRedemption{},
+		&models.AturanHarga{},
+		&models.TemplateJadwal{},
+		&models.TemplateStop{},
+		&models.TemplateHargaKelas{},
+		&models.TemplateJadwalPengecualian{},
 		&models.Penumpang{},
 		&models.Payment{},
-	); err != nil {
-		log.Fatalf("migration failed: %v", err)
 	}
-	log.Println("Migration complete (User)")
 }
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fitranmei/Mooove-/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var akunSvcGlobal *services.AkunService
+
+func InitAkunHandler(svc *services.AkunService) {
+	akunSvcGlobal = svc
+}
+
+type HandlerAkun struct {
+	svc *services.AkunService
+}
+
+func NewHandlerAkun() *HandlerAkun {
+	return &HandlerAkun{svc: akunSvcGlobal}
+}
+
+type tokenAkunReq struct {
+	Token    string `json:"token"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (h *HandlerAkun) KirimVerifikasi(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if err := h.svc.KirimVerifikasi(c.Context(), uid); err != nil {
+		if errors.Is(err, services.ErrEmailSudahTerverifikasi) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "email verifikasi telah dikirim"})
+}
+
+// Verifikasi menerima token dari query (link di email) atau body JSON.
+func (h *HandlerAkun) Verifikasi(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		var req tokenAkunReq
+		_ = c.BodyParser(&req)
+		token = req.Token
+	}
+	if token == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "token wajib diisi"})
+	}
+	if err := h.svc.Verifikasi(c.Context(), token); err != nil {
+		if errors.Is(err, services.ErrTokenAkunTidakValid) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "email berhasil diverifikasi"})
+}
+
+func (h *HandlerAkun) LupaPassword(c *fiber.Ctx) error {
+	var req tokenAkunReq
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "email wajib diisi"})
+	}
+	if err := h.svc.LupaPassword(c.Context(), req.Email); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "jika email terdaftar, link reset password telah dikirim"})
+}
+
+func (h *HandlerAkun) ResetPassword(c *fiber.Ctx) error {
+	var req tokenAkunReq
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "token dan password wajib diisi"})
+	}
+	if err := h.svc.ResetPassword(c.Context(), req.Token, req.Password); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "password berhasil diganti, silakan login kembali"})
+}
+
+func (h *HandlerAkun) UpdateProfil(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	var req services.UpdateProfilInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+	}
+	user, err := h.svc.UpdateProfil(c.Context(), uid, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmailSudahDipakai):
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrPasswordSalah):
+			return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"fullname": user.Fullname,
+		"email":    user.Email,
+		"roles":    user.DaftarRole(),
+
+		"email_terverifikasi": user.EmailTerverifikasi(),
+	})
+}
+
+type gantiPasswordReq struct {
+	PasswordLama string `json:"password_lama"`
+	PasswordBaru string `json:"password_baru"`
+}
+
+func (h *HandlerAkun) GantiPassword(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	var req gantiPasswordReq
+	if err := c.BodyParser(&req); err != nil || req.PasswordLama == "" || req.PasswordBaru == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "password_lama dan password_baru wajib diisi"})
+	}
+	if err := h.svc.GantiPassword(c.Context(), uid, req.PasswordLama, req.PasswordBaru); err != nil {
+		if errors.Is(err, services.ErrPasswordLamaSalah) {
+			return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "password berhasil diganti, silakan login kembali"})
+}
+
+// halamanResetPassword adalah form minimal untuk link reset di email bila
+// RESET_PASSWORD_URL tidak mengarah ke aplikasi.
+const halamanResetPassword = `<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Reset password Mooove</title>
+</head>
+<body>
+<h1>Reset password</h1>
+<form id="form">
+<label>Password baru <input type="password" name="password" minlength="6" required></label>
+<button type="submit">Simpan</button>
+</form>
+<p id="pesan"></p>
+<script>
+document.getElementById("form").addEventListener("submit", async function (e) {
+  e.preventDefault();
+  var token = new URLSearchParams(location.search).get("token") || "";
+  var res = await fetch("/api/v1/auth/reset-password", {
+    method: "POST",
+    headers: {"Content-Type": "application/json"},
+    body: JSON.stringify({token: token, password: this.password.value})
+  });
+  var data = await res.json();
+  document.getElementById("pesan").textContent = data.message || data.error;
+  if (res.ok) this.remove();
+});
+</script>
+</body>
+</html>
+`
+
+func (h *HandlerAkun) HalamanResetPassword(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(halamanResetPassword)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/fitranmei/Mooove-/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	aturanHargaRepoGlobal repositories.AturanHargaRepo
+	hargaDinamisSvcGlobal *services.HargaDinamisService
+)
+
+func InitAturanHargaHandler(repo repositories.AturanHargaRepo, svc *services.HargaDinamisService) {
+	aturanHargaRepoGlobal = repo
+	hargaDinamisSvcGlobal = svc
+}
+
+type HandlerAturanHarga struct {
+	repo repositories.AturanHargaRepo
+}
+
+func NewHandlerAturanHarga() *HandlerAturanHarga {
+	return &HandlerAturanHarga{repo: aturanHargaRepoGlobal}
+}
+
+func validasiAturanHarga(a *models.AturanHarga) string {
+	if a.Jenis != "muatan" && a.Jenis != "hari" {
+		return "jenis harus muatan atau hari"
+	}
+	if a.Dari < 0 || a.Sampai <= a.Dari {
+		return "rentang tidak valid: dari harus >= 0 dan sampai > dari"
+	}
+	if a.Jenis == "muatan" && a.Sampai > 101 {
+		return "rentang muatan maksimal 0-101 (persen)"
+	}
+	if a.Faktor <= 0 {
+		return "faktor harus lebih dari 0"
+	}
+	return ""
+}
+
+func (h *HandlerAturanHarga) ListSemua(c *fiber.Ctx) error {
+	list, err := h.repo.ListSemua()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+func (h *HandlerAturanHarga) Buat(c *fiber.Ctx) error {
+	var req models.AturanHarga
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if msg := validasiAturanHarga(&req); msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	req.ID = 0
+	req.CreatedAt = time.Now()
+	req.UpdatedAt = time.Now()
+	if err := h.repo.Buat(&req); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(http.StatusCreated).JSON(req)
+}
+
+func (h *HandlerAturanHarga) Update(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	var req models.AturanHarga
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if msg := validasiAturanHarga(&req); msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	req.ID = uint(id)
+	req.UpdatedAt = time.Now()
+	if err := h.repo.Update(&req); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(req)
+}
+
+func (h *HandlerAturanHarga) Hapus(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := h.repo.Delete(uint(id)); err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "aturan harga dihapus"})
+}
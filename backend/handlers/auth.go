@@ -2,8 +2,13 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"log"
+	"math"
+	"strconv"
 
 	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/services"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -21,6 +26,10 @@ type registerReq struct {
 	Password string `json:"password"`
 }
 
+type refreshReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type loginReq struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -40,7 +49,18 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(fiber.Map{"token": token, "user": user})
+	if akunSvcGlobal != nil {
+		if err := akunSvcGlobal.KirimVerifikasi(context.Background(), user.ID); err != nil {
+			log.Printf("gagal mengirim email verifikasi ke user %d: %v", user.ID, err)
+		}
+	}
+	return c.JSON(fiber.Map{
+		"token":         token.AccessToken,
+		"refresh_token": token.RefreshToken,
+		"expires_in":    token.ExpiresIn,
+		"user":          user,
+		"roles":         user.DaftarRole(),
+	})
 }
 
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
@@ -49,11 +69,27 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid payload"})
 	}
 	creds := &models.AuthCredentials{Email: body.Email, Password: body.Password}
-	token, user, err := h.authSvc.Login(context.Background(), creds)
+	ctx := services.DenganInfoKlien(context.Background(), services.InfoKlien{IP: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)})
+	token, user, err := h.authSvc.Login(ctx, creds)
 	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+		var blokir *services.TerlaluBanyakPercobaanError
+		if errors.As(err, &blokir) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(blokir.CobaLagi.Seconds()))))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, services.ErrLoginGagal) {
+			return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("[auth] login gagal: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "gagal memproses login"})
 	}
-	return c.JSON(fiber.Map{"token": token, "user": user})
+	return c.JSON(fiber.Map{
+		"token":         token.AccessToken,
+		"refresh_token": token.RefreshToken,
+		"expires_in":    token.ExpiresIn,
+		"user":          user,
+		"roles":         user.DaftarRole(),
+	})
 }
 
 func (h *AuthHandler) Me(c *fiber.Ctx) error {
@@ -67,5 +103,47 @@ func (h *AuthHandler) Me(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"fullname": fullname,
 		"email":    email,
+		"roles":    c.Locals("user_roles"),
+
+		"email_terverifikasi": c.Locals("user_email_terverifikasi"),
 	})
 }
+
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var body refreshReq
+	if err := c.BodyParser(&body); err != nil || body.RefreshToken == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "refresh_token wajib diisi"})
+	}
+	token, err := h.authSvc.Refresh(context.Background(), body.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrRefreshTokenTidakValid) {
+			return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(token)
+}
+
+// Logout mencabut sesi dari refresh token yang dikirim. Access token yang
+// sudah terbit tetap berlaku sampai kedaluwarsa (berumur pendek).
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var body refreshReq
+	if err := c.BodyParser(&body); err != nil || body.RefreshToken == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "refresh_token wajib diisi"})
+	}
+	if err := h.authSvc.Logout(context.Background(), body.RefreshToken); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "logout berhasil"})
+}
+
+func (h *AuthHandler) LogoutSemua(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if err := h.authSvc.LogoutSemua(context.Background(), uid); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "semua sesi telah dicabut"})
+}
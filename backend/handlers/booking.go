@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"strconv"
 	"time"
 
@@ -9,13 +9,49 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/services"
 )
 
 type CreateBookingRequest struct {
-	ScheduleID uint               `json:"schedule_id"`
-	SeatIDs    []uint             `json:"seat_ids"`
-	Penumpangs []models.Penumpang `json:"penumpangs"`
-	TotalHarga int64              `json:"total_harga"`
+	ScheduleID     uint               `json:"schedule_id"`
+	NaikStasiunID  uint               `json:"naik_stasiun_id"`
+	TurunStasiunID uint               `json:"turun_stasiun_id"`
+	SeatIDs        []uint             `json:"seat_ids"`
+	Penumpangs     []models.Penumpang `json:"penumpangs"`
+	TotalHarga     int64              `json:"total_harga"`
+
+	// Legs dipakai untuk pulang-pergi/transit; jika kosong, booking satu
+	// jadwal dibentuk dari schedule_id dan seat_ids di atas.
+	Legs        []services.BookingLegInput `json:"legs"`
+	Layanan     []services.LayananInput    `json:"layanan"`
+	KodeVoucher string                     `json:"kode_voucher"`
+	Kanal       string                     `json:"kanal"`
+
+	// AutoKursi: kursi dipilihkan server untuk leg tanpa seat_ids
+	AutoKursi  bool                     `json:"auto_kursi"`
+	Preferensi services.PreferensiKursi `json:"preferensi_kursi"`
+}
+
+// permintaan menormalkan request lama (satu jadwal) ke bentuk multi-leg.
+func (req *CreateBookingRequest) permintaan() services.PermintaanBooking {
+	legs := req.Legs
+	if len(legs) == 0 {
+		legs = []services.BookingLegInput{{
+			ScheduleID:     req.ScheduleID,
+			NaikStasiunID:  req.NaikStasiunID,
+			TurunStasiunID: req.TurunStasiunID,
+			SeatIDs:        req.SeatIDs,
+		}}
+	}
+	return services.PermintaanBooking{
+		Legs:        legs,
+		Penumpangs:  req.Penumpangs,
+		Layanan:     req.Layanan,
+		KodeVoucher: req.KodeVoucher,
+		Kanal:       req.Kanal,
+		AutoKursi:   req.AutoKursi,
+		Preferensi:  req.Preferensi,
+	}
 }
 
 func NewHandlerBooking(repoBooking BookingRepoInterface, repoKetersediaan KetersediaanRepoInterface, db *gorm.DB) *BookingHandler {
@@ -30,8 +66,13 @@ func (h *BookingHandler) CreateBooking(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "body tidak valid", "detail": err.Error()})
 	}
 
-	if len(req.SeatIDs) == 0 || len(req.SeatIDs) != len(req.Penumpangs) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "jumlah seat dan penumpang harus sama dan >0"})
+	permintaan := req.permintaan()
+	if permintaan.Kanal == "" {
+		permintaan.Kanal = c.Get("X-Kanal")
+	}
+
+	if len(req.Penumpangs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "penumpang wajib diisi"})
 	}
 
 	var userID *uint = nil
@@ -41,57 +82,34 @@ func (h *BookingHandler) CreateBooking(c *fiber.Ctx) error {
 		}
 	}
 
-	var booking models.Booking
-
-	err := dbConn.Transaction(func(tx *gorm.DB) error {
-		now := time.Now()
-		booking = models.Booking{
-			UserID:          userID,
-			TrainScheduleID: req.ScheduleID,
-			Status:          "pending",
-			TotalPrice:      req.TotalHarga,
-			CreatedAt:       now,
-			UpdatedAt:       now,
+	booking, err := bookingSvc.CreateBookingWithReserve(c.Context(), userID, permintaan, req.TotalHarga)
+	if err != nil {
+		var hargaErr *services.HargaTidakSesuaiError
+		if errors.As(err, &hargaErr) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "quote": hargaErr.Quote})
 		}
-
-		if err := repoBooking.Create(tx, &booking); err != nil {
-			return err
+		if errors.Is(err, services.ErrEmailBelumTerverifikasi) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
 		}
-
-		inventories, err := repoKetersediaan.FindAndLockBySchedule(tx, req.ScheduleID, req.SeatIDs)
-		if err != nil {
-			return err
+		var batasErr *services.BatasBookingError
+		if errors.As(err, &batasErr) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error(), "kode": batasErr.Kode, "batas": batasErr.Batas})
 		}
-
-		for _, inv := range inventories {
-			if inv.Status != "available" {
-				return fmt.Errorf("kursi %d tidak tersedia", inv.SeatID)
-			}
+		var bentrokErr *services.IdentitasBentrokError
+		if errors.As(err, &bentrokErr) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "fields": bentrokErr.Kesalahan})
 		}
-
-		var invIDs []uint
-		for _, inv := range inventories {
-			invIDs = append(invIDs, inv.ID)
+		var dokErr *models.ValidasiDokumenError
+		if errors.As(err, &dokErr) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error(), "fields": dokErr.Kesalahan})
 		}
-		if err := repoKetersediaan.MarkReserved(tx, invIDs, booking.ID); err != nil {
-			return err
+		if errors.Is(err, services.ErrKursiTidakCukup) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
 		}
-
-		now2 := time.Now()
-		for i := range req.Penumpangs {
-			p := &req.Penumpangs[i]
-			p.BookingID = booking.ID
-			p.SeatID = req.SeatIDs[i]
-			p.CreatedAt = now2
-			if err := tx.Create(p).Error; err != nil {
-				return err
-			}
+		if errors.Is(err, services.ErrBookingBersamaan) {
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "retry": true})
 		}
-
-		return nil
-	})
-
-	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -107,7 +125,13 @@ func (h *BookingHandler) CreateBooking(c *fiber.Ctx) error {
 	}
 
 	seatReserved := make([]map[string]interface{}, 0, len(reservedRows))
+	seen := make(map[[2]uint]bool)
 	for _, r := range reservedRows {
+		key := [2]uint{r.TrainScheduleID, r.SeatID}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
 		var ru string
 		if r.ReservedUntil != nil {
 			ru = r.ReservedUntil.Format(time.RFC3339)
@@ -115,6 +139,7 @@ func (h *BookingHandler) CreateBooking(c *fiber.Ctx) error {
 			ru = ""
 		}
 		seatReserved = append(seatReserved, map[string]interface{}{
+			"schedule_id":    r.TrainScheduleID,
 			"seat_id":        r.SeatID,
 			"reserved_until": ru,
 		})
@@ -122,7 +147,12 @@ func (h *BookingHandler) CreateBooking(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"booking_id":     booking.ID,
+		"tipe":           booking.Tipe,
 		"status":         booking.Status,
+		"legs":           booking.Legs,
+		"items":          booking.Items,
+		"total_harga":    booking.TotalPrice,
+		"expires_at":     booking.ReservedUntil,
 		"reserved_seats": seatReserved,
 	})
 }
@@ -144,15 +174,31 @@ func (h *BookingHandler) GetBookingByID(c *fiber.Ctx) error {
 
 	if v := c.Locals("user_id"); v != nil {
 		if uid, ok := v.(uint); ok {
-			if b.UserID != nil && *b.UserID != uid {
+			roles, _ := c.Locals("user_roles").([]string)
+			if b.UserID != nil && *b.UserID != uid && !models.PunyaIzin(roles, models.PermLihatBooking) {
 				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "tidak berhak mengakses booking ini"})
 			}
 		}
 	}
 
+	samarkanIdentitas(c, b)
 	return c.JSON(b)
 }
 
+// samarkanIdentitas menyembunyikan nomor identitas penumpang kecuali untuk
+// staf yang berhak melihat booking (PermLihatBooking).
+func samarkanIdentitas(c *fiber.Ctx, bookings ...*models.Booking) {
+	roles, _ := c.Locals("user_roles").([]string)
+	if models.PunyaIzin(roles, models.PermLihatBooking) {
+		return
+	}
+	for _, b := range bookings {
+		for i := range b.Penumpangs {
+			b.Penumpangs[i].NoIdentitas = b.Penumpangs[i].NoIdentitas.Samarkan()
+		}
+	}
+}
+
 func (h *BookingHandler) ListBookingsForUser(c *fiber.Ctx) error {
 	v := c.Locals("user_id")
 	if v == nil {
@@ -171,10 +217,20 @@ func (h *BookingHandler) ListBookingsForUser(c *fiber.Ctx) error {
 		Preload("TrainSchedule.Kereta").
 		Preload("TrainSchedule.Asal").
 		Preload("TrainSchedule.Tujuan").
+		Preload("Legs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan asc")
+		}).
+		Preload("Legs.TrainSchedule").
+		Preload("Legs.TrainSchedule.Kereta").
+		Preload("Legs.TrainSchedule.Asal").
+		Preload("Legs.TrainSchedule.Tujuan").
 		Where("user_id = ?", uid).
 		Find(&bookings).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	for i := range bookings {
+		samarkanIdentitas(c, &bookings[i])
+	}
 	return c.JSON(bookings)
 }
 
@@ -240,6 +296,10 @@ func (h *BookingHandler) DeleteBooking(c *fiber.Ctx) error {
 			return err
 		}
 
+		if err := services.SetStatusRedemption(tx, bookingID, "released"); err != nil {
+			return err
+		}
+
 		booking.Status = "cancelled"
 		booking.UpdatedAt = time.Now()
 		if err := repoBooking.SimpanUpdate(tx, booking); err != nil {
@@ -285,7 +345,13 @@ func (h *BookingHandler) CreatePaymentForBooking(c *fiber.Ctx) error {
 		}
 	}
 
-	result, err := paymentSvc.CreatePayment(c.Context(), bookingID, booking.TotalPrice)
+	if booking.Status != "pending" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "booking tidak dalam status pending",
+		})
+	}
+
+	result, err := paymentSvc.CreatePayment(c.Context(), bookingID, booking.TotalPrice, booking.Items)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -329,7 +395,7 @@ func (h *BookingHandler) MarkBookingPaid(c *fiber.Ctx) error {
 			}).Error; err != nil {
 			return err
 		}
-		return nil
+		return services.SetStatusRedemption(tx, bookingID, "redeemed")
 	})
 
 	if err != nil {
@@ -338,3 +404,71 @@ func (h *BookingHandler) MarkBookingPaid(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"message": "booking paid successfully", "booking_id": bookingID})
 }
+
+type terapkanVoucherReq struct {
+	Kode string `json:"kode"`
+}
+
+func (h *BookingHandler) TerapkanVoucher(c *fiber.Ctx) error {
+	id64, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "id booking tidak valid"})
+	}
+
+	var req terapkanVoucherReq
+	if err := c.BodyParser(&req); err != nil || req.Kode == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "kode voucher wajib diisi"})
+	}
+
+	var userID *uint
+	if v := c.Locals("user_id"); v != nil {
+		if uid, ok := v.(uint); ok {
+			userID = &uid
+		}
+	}
+
+	booking, err := bookingSvc.TerapkanVoucher(c.Context(), uint(id64), userID, req.Kode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "booking tidak ditemukan"})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"booking_id":  booking.ID,
+		"items":       booking.Items,
+		"total_harga": booking.TotalPrice,
+	})
+}
+
+func (h *BookingHandler) PerpanjangHold(c *fiber.Ctx) error {
+	id64, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "id booking tidak valid"})
+	}
+
+	var userID *uint
+	if uid, ok := c.Locals("user_id").(uint); ok {
+		userID = &uid
+	}
+
+	booking, err := bookingSvc.PerpanjangHold(c.Context(), uint(id64), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "booking tidak ditemukan"})
+		case errors.Is(err, services.ErrHoldSudahDiperpanjang), errors.Is(err, services.ErrPembayaranBelumDimulai):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrHoldKedaluwarsa):
+			return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"booking_id":        booking.ID,
+		"expires_at":        booking.ReservedUntil,
+		"diperpanjang_pada": booking.DiperpanjangPada,
+	})
+}
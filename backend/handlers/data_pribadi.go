@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fitranmei/Mooove-/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var dataPribadiSvcGlobal *services.DataPribadiService
+
+func InitDataPribadiHandler(svc *services.DataPribadiService) {
+	dataPribadiSvcGlobal = svc
+}
+
+type HandlerDataPribadi struct {
+	svc *services.DataPribadiService
+}
+
+func NewHandlerDataPribadi() *HandlerDataPribadi {
+	return &HandlerDataPribadi{svc: dataPribadiSvcGlobal}
+}
+
+// Ekspor mengunduh arsip JSON berisi semua data pribadi milik user.
+func (h *HandlerDataPribadi) Ekspor(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	arsip, err := h.svc.Ekspor(c.Context(), uid)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="mooove-data-%d.json"`, uid))
+	return c.JSON(arsip)
+}
+
+type hapusAkunReq struct {
+	Password string `json:"password"`
+}
+
+func (h *HandlerDataPribadi) HapusAkun(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	var req hapusAkunReq
+	if err := c.BodyParser(&req); err != nil || req.Password == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "password wajib diisi untuk konfirmasi"})
+	}
+	if err := h.svc.HapusAkun(c.Context(), uid, req.Password); err != nil {
+		if errors.Is(err, services.ErrKonfirmasiPasswordSalah) {
+			return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "akun telah dihapus"})
+}
@@ -43,6 +43,7 @@ type buatGerbongReq struct {
 	NomorGerbong   int    `json:"nomor_gerbong"`
 	Kelas          string `json:"kelas"`
 	KapasitasKursi int    `json:"kapasitas_kursi"`
+	TataLetakID    *uint  `json:"tata_letak_id"`
 	GenerateKursi  bool   `json:"generate_kursi"`
 }
 
@@ -50,6 +51,7 @@ type updateGerbongReq struct {
 	NomorGerbong   *int    `json:"nomor_gerbong,omitempty"`
 	Kelas          *string `json:"kelas,omitempty"`
 	KapasitasKursi *int    `json:"kapasitas_kursi,omitempty"`
+	TataLetakID    *uint   `json:"tata_letak_id,omitempty"`
 }
 
 func (h *HandlerGerbong) ListSemuaGerbong(c *fiber.Ctx) error {
@@ -83,12 +85,18 @@ func (h *HandlerGerbong) BuatGerbong(c *fiber.Ctx) error {
 	if kap <= 0 {
 		kap = 64
 	}
+	if req.TataLetakID != nil {
+		if err := h.db.First(&models.TataLetakGerbong{}, *req.TataLetakID).Error; err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "tata letak tidak ditemukan"})
+		}
+	}
 
 	gerbong := &models.Gerbong{
 		KeretaID:       req.KeretaID,
 		NomorGerbong:   req.NomorGerbong,
 		Kelas:          req.Kelas,
 		KapasitasKursi: kap,
+		TataLetakID:    req.TataLetakID,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
@@ -98,16 +106,7 @@ func (h *HandlerGerbong) BuatGerbong(c *fiber.Ctx) error {
 			return err
 		}
 
-		kursiList := utils.GenerateListKursiMenggunakanKapasitas(gerbong.ID, gerbong.KapasitasKursi)
-		if len(kursiList) == 0 {
-			return nil
-		}
-
-		if err := tx.Create(&kursiList).Error; err != nil {
-			return err
-		}
-
-		return nil
+		return utils.GenerateKursiUntukGerbong(tx, gerbong)
 	})
 
 	if err != nil {
@@ -144,6 +143,14 @@ func (h *HandlerGerbong) UpdateGerbong(c *fiber.Ctx) error {
 	if req.KapasitasKursi != nil {
 		existing.KapasitasKursi = *req.KapasitasKursi
 	}
+	// kursi yang sudah ada tidak disusun ulang; atributnya diperbarui lewat
+	// perintah terapkan-tata-letak
+	if req.TataLetakID != nil {
+		if err := h.db.First(&models.TataLetakGerbong{}, *req.TataLetakID).Error; err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "tata letak tidak ditemukan"})
+		}
+		existing.TataLetakID = req.TataLetakID
+	}
 
 	existing.UpdatedAt = time.Now()
 
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var gtfsSvcGlobal *services.GtfsService
+
+func InitGtfsHandler(svc *services.GtfsService) {
+	gtfsSvcGlobal = svc
+}
+
+type HandlerGtfs struct {
+	svc *services.GtfsService
+}
+
+func NewHandlerGtfs() *HandlerGtfs {
+	return &HandlerGtfs{svc: gtfsSvcGlobal}
+}
+
+// Ekspor mengunduh feed GTFS static (zip). Default rentang hari ini sampai
+// 30 hari ke depan.
+func (h *HandlerGtfs) Ekspor(c *fiber.Ctx) error {
+	now := time.Now()
+	dari := c.Query("dari", now.Format("2006-01-02"))
+	sampai := c.Query("sampai", now.AddDate(0, 0, 30).Format("2006-01-02"))
+
+	var buf bytes.Buffer
+	peringatan, err := h.svc.Ekspor(&buf, dari, sampai)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(peringatan) > 0 {
+		c.Set("X-Gtfs-Peringatan", strings.Join(peringatan, "; "))
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="gtfs.zip"`)
+	return c.Send(buf.Bytes())
+}
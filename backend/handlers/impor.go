@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var imporJadwalSvcGlobal *services.ImporJadwalService
+
+func InitImporHandler(svc *services.ImporJadwalService) {
+	imporJadwalSvcGlobal = svc
+}
+
+type HandlerImpor struct {
+	svc *services.ImporJadwalService
+}
+
+func NewHandlerImpor() *HandlerImpor {
+	return &HandlerImpor{svc: imporJadwalSvcGlobal}
+}
+
+// ImporJadwal menerima multipart field "file" berisi zip GTFS atau CSV.
+// Form field: format (gtfs|csv, default dari ekstensi file), dry_run, dan
+// untuk GTFS: kelas, harga, dari, sampai.
+func (h *HandlerImpor) ImporJadwal(c *fiber.Ctx) error {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "file wajib diunggah"})
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	defer f.Close()
+
+	format := strings.ToLower(c.FormValue("format"))
+	if format == "" {
+		format = "csv"
+		if strings.HasSuffix(strings.ToLower(fh.Filename), ".zip") {
+			format = "gtfs"
+		}
+	}
+
+	now := time.Now()
+	harga, _ := strconv.ParseInt(c.FormValue("harga"), 10, 64)
+	dryRun, _ := strconv.ParseBool(c.FormValue("dry_run"))
+	opsi := services.OpsiImpor{
+		DryRun: dryRun,
+		Kelas:  c.FormValue("kelas"),
+		Harga:  harga,
+		Dari:   c.FormValue("dari", now.Format("2006-01-02")),
+		Sampai: c.FormValue("sampai", now.AddDate(0, 0, 30).Format("2006-01-02")),
+	}
+
+	var hasil *services.HasilImpor
+	switch format {
+	case "csv":
+		hasil, err = h.svc.ImporCsv(f, opsi)
+	case "gtfs":
+		var data []byte
+		if data, err = io.ReadAll(f); err == nil {
+			hasil, err = h.svc.ImporGtfs(data, opsi)
+		}
+	default:
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "format harus gtfs atau csv"})
+	}
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if len(hasil.Kesalahan) > 0 {
+		return c.Status(http.StatusUnprocessableEntity).JSON(hasil)
+	}
+	return c.JSON(hasil)
+}
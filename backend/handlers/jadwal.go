@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/fitranmei/Mooove-/backend/models"
 	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/fitranmei/Mooove-/backend/services"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -15,13 +17,15 @@ type HandlerJadwal struct {
 	repo             repositories.JadwalRepo
 	gerbongRepo      repositories.GerbongRepo
 	ketersediaanRepo repositories.KetersediaanRepo
+	tarif            *services.TarifService
 }
 
-func NewHandlerJadwal(repo repositories.JadwalRepo, gerbongRepo repositories.GerbongRepo, ketersediaanRepo repositories.KetersediaanRepo, db *gorm.DB) *HandlerJadwal {
+func NewHandlerJadwal(repo repositories.JadwalRepo, gerbongRepo repositories.GerbongRepo, ketersediaanRepo repositories.KetersediaanRepo, tarif *services.TarifService, db *gorm.DB) *HandlerJadwal {
 	return &HandlerJadwal{
 		repo:             repo,
 		gerbongRepo:      gerbongRepo,
 		ketersediaanRepo: ketersediaanRepo,
+		tarif:            tarif,
 	}
 }
 
@@ -34,6 +38,15 @@ type createJadwalPayload struct {
 	Tanggal        string `json:"tanggal"` // "YYYY-MM-DD"
 	Kelas          string `json:"kelas"`   // "eksekutif","bisnis","ekonomi"
 	HargaDasar     int64  `json:"harga_dasar"`
+
+	Stops []createStopPayload `json:"stops"` // opsional; jika kosong hanya asal dan tujuan
+}
+
+type createStopPayload struct {
+	StasiunID      uint   `json:"stasiun_id"`
+	WaktuTiba      string `json:"waktu_tiba"`
+	WaktuBerangkat string `json:"waktu_berangkat"`
+	HargaKumulatif int64  `json:"harga_kumulatif"`
 }
 
 func parseTimeFlexible(input string) (time.Time, error) {
@@ -74,6 +87,11 @@ func (h *HandlerJadwal) Buat(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "payload tidak valid"})
 	}
 
+	if len(payload.Stops) >= 2 {
+		payload.AsalID = payload.Stops[0].StasiunID
+		payload.TujuanID = payload.Stops[len(payload.Stops)-1].StasiunID
+	}
+
 	if payload.KeretaID == 0 || payload.AsalID == 0 || payload.TujuanID == 0 {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "kereta_id, asal_id, dan tujuan_id wajib diisi"})
 	}
@@ -109,6 +127,50 @@ func (h *HandlerJadwal) Buat(c *fiber.Ctx) error {
 		UpdatedAt:      time.Now(),
 	}
 
+	if len(payload.Stops) == 1 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "stops minimal berisi stasiun asal dan tujuan"})
+	}
+	for i, sp := range payload.Stops {
+		if sp.StasiunID == 0 {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("stops[%d].stasiun_id wajib diisi", i)})
+		}
+		stop := models.JadwalStop{
+			Urutan:         i,
+			StasiunID:      sp.StasiunID,
+			HargaKumulatif: sp.HargaKumulatif,
+		}
+		if sp.WaktuTiba != "" {
+			t, err := parseTimeFlexible(sp.WaktuTiba)
+			if err != nil {
+				return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("format stops[%d].waktu_tiba tidak valid", i)})
+			}
+			stop.WaktuTiba = &t
+		}
+		if sp.WaktuBerangkat != "" {
+			t, err := parseTimeFlexible(sp.WaktuBerangkat)
+			if err != nil {
+				return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("format stops[%d].waktu_berangkat tidak valid", i)})
+			}
+			stop.WaktuBerangkat = &t
+		}
+		jadwal.Stops = append(jadwal.Stops, stop)
+	}
+	// ujung rute boleh dikosongkan dan mengikuti jadwal
+	if n := len(jadwal.Stops); n > 0 {
+		if jadwal.Stops[0].WaktuBerangkat == nil {
+			jadwal.Stops[0].WaktuBerangkat = &wb
+		}
+		if jadwal.Stops[n-1].WaktuTiba == nil {
+			jadwal.Stops[n-1].WaktuTiba = &wt
+		}
+		if jadwal.Stops[n-1].HargaKumulatif == 0 {
+			jadwal.Stops[n-1].HargaKumulatif = payload.HargaDasar
+		}
+	}
+	if err := jadwal.ValidasiStops(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	created, err := h.repo.Buat(jadwal)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -145,6 +207,10 @@ func (h *HandlerJadwal) CariJadwal(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	if err := h.tarif.TerapkanHargaTampil(c.Context(), results); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	return c.JSON(fiber.Map{
 		"count": len(results),
 		"data":  results,
@@ -165,34 +231,60 @@ func (h *HandlerJadwal) GetKursiByJadwal(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "gagal mengambil data gerbong"})
 	}
 
-	ketersediaan, err := h.ketersediaanRepo.GetBySchedule(jadwalID)
+	naikID, _ := strconv.ParseUint(c.Query("naik"), 10, 64)
+	turunID, _ := strconv.ParseUint(c.Query("turun"), 10, 64)
+	naik, turun, err := jadwal.Segmen(uint(naikID), uint(turunID))
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "gagal mengambil data ketersediaan"})
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	statusMap := make(map[uint]string)
-	for _, k := range ketersediaan {
-		statusMap[k.SeatID] = k.Status
+	ketersediaan, err := h.ketersediaanRepo.GetBySegment(jadwalID, naik.Urutan, turun.Urutan)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "gagal mengambil data ketersediaan"})
 	}
 
+	statusMap := models.GabungStatusKursi(ketersediaan)
+
 	type SeatResp struct {
 		ID         uint   `json:"id"`
 		NomorKursi string `json:"nomor_kursi"`
 		Status     string `json:"status"` // available, booked, reserved
+		Baris      int    `json:"baris"`
+		Kolom      string `json:"kolom"`
+		Jendela    bool   `json:"jendela"`
+		Lorong     bool   `json:"lorong"`
+		Prioritas  bool   `json:"prioritas"`
+		KursiRoda  bool   `json:"kursi_roda"`
+		Arah       string `json:"arah"`
+	}
+
+	type TataLetakResp struct {
+		Kode  string `json:"kode"`
+		Nama  string `json:"nama"`
+		Kolom string `json:"kolom"` // "|" menandai lorong
 	}
 
 	type GerbongResp struct {
-		ID           uint       `json:"id"`
-		NomorGerbong int        `json:"nomor_gerbong"`
-		Kelas        string     `json:"kelas"`
-		Kursi        []SeatResp `json:"kursi"`
+		ID           uint          `json:"id"`
+		NomorGerbong int           `json:"nomor_gerbong"`
+		Kelas        string        `json:"kelas"`
+		TataLetak    TataLetakResp `json:"tata_letak"`
+		Kursi        []SeatResp    `json:"kursi"`
+	}
+
+	tataLetaks, err := h.gerbongRepo.TataLetakGerbongs(gerbongs)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "gagal mengambil tata letak gerbong"})
 	}
 
 	var gerbongResps []GerbongResp
 
-	for _, g := range gerbongs {
+	for i, g := range gerbongs {
+		tataLetak := tataLetaks[i]
+
 		var seats []SeatResp
 		for _, k := range g.Kursis {
+			k.LengkapiAtribut()
 			status := "available"
 			if s, ok := statusMap[k.ID]; ok {
 				status = s
@@ -201,6 +293,13 @@ func (h *HandlerJadwal) GetKursiByJadwal(c *fiber.Ctx) error {
 				ID:         k.ID,
 				NomorKursi: k.NomorKursi,
 				Status:     status,
+				Baris:      k.Baris,
+				Kolom:      k.Kolom,
+				Jendela:    k.Jendela,
+				Lorong:     k.Lorong,
+				Prioritas:  k.Prioritas,
+				KursiRoda:  k.KursiRoda,
+				Arah:       k.Arah,
 			})
 		}
 
@@ -208,6 +307,7 @@ func (h *HandlerJadwal) GetKursiByJadwal(c *fiber.Ctx) error {
 			ID:           g.ID,
 			NomorGerbong: g.NomorGerbong,
 			Kelas:        g.Kelas,
+			TataLetak:    TataLetakResp{Kode: tataLetak.Kode, Nama: tataLetak.Nama, Kolom: tataLetak.Kolom},
 			Kursi:        seats,
 		})
 	}
@@ -215,6 +315,8 @@ func (h *HandlerJadwal) GetKursiByJadwal(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"jadwal_id": jadwalID,
 		"kereta":    jadwal.Kereta,
+		"naik":      naik,
+		"turun":     turun,
 		"gerbongs":  gerbongResps,
 	})
 }
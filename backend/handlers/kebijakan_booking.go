@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+var kebijakanBookingRepoGlobal repositories.KebijakanBookingRepo
+
+func InitKebijakanBookingHandler(repo repositories.KebijakanBookingRepo) {
+	kebijakanBookingRepoGlobal = repo
+}
+
+type HandlerKebijakanBooking struct {
+	repo repositories.KebijakanBookingRepo
+}
+
+func NewHandlerKebijakanBooking() *HandlerKebijakanBooking {
+	return &HandlerKebijakanBooking{repo: kebijakanBookingRepoGlobal}
+}
+
+func validasiKebijakanBooking(k *models.KebijakanBooking) string {
+	if k.JedaIdentitasMenit < 0 || k.JedaIdentitasMenit > 24*60 {
+		return "jeda_identitas_menit harus 0-1440"
+	}
+	if k.MaksPenumpangPerBooking < 0 || k.MaksHoldAktifPerUser < 0 || k.MaksKursiPerJadwal < 0 {
+		return "batas tidak boleh negatif (0 = tanpa batas)"
+	}
+	return ""
+}
+
+func (h *HandlerKebijakanBooking) Get(c *fiber.Ctx) error {
+	k, err := h.repo.Get()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(k)
+}
+
+// Update menerima sebagian field; field yang tidak dikirim tetap memakai
+// nilai saat ini.
+func (h *HandlerKebijakanBooking) Update(c *fiber.Ctx) error {
+	k, err := h.repo.Get()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := c.BodyParser(k); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+	}
+	if msg := validasiKebijakanBooking(k); msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	if err := h.repo.Simpan(k); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(k)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fitranmei/Mooove-/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var pengelolaKunciGlobal *services.PengelolaKunci
+
+func InitKunciHandler(p *services.PengelolaKunci) {
+	pengelolaKunciGlobal = p
+}
+
+type HandlerKunci struct {
+	kunci *services.PengelolaKunci
+}
+
+func NewHandlerKunci() *HandlerKunci {
+	return &HandlerKunci{kunci: pengelolaKunciGlobal}
+}
+
+func (h *HandlerKunci) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.kunci.JWKS())
+}
+
+func (h *HandlerKunci) ListKunci(c *fiber.Ctx) error {
+	return c.JSON(h.kunci.Daftar())
+}
+
+type rotasiKunciReq struct {
+	Alg string `json:"alg"`
+}
+
+// Rotasi membuat kunci aktif baru. Token lama tetap valid sampai kuncinya
+// dipensiunkan.
+func (h *HandlerKunci) Rotasi(c *fiber.Ctx) error {
+	var req rotasiKunciReq
+	_ = c.BodyParser(&req)
+	if req.Alg == "" {
+		req.Alg = services.AlgEdDSA
+	}
+	kid, err := h.kunci.Rotasi(req.Alg)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"kid": kid, "alg": req.Alg})
+}
+
+func (h *HandlerKunci) Pensiunkan(c *fiber.Ctx) error {
+	if err := h.kunci.Pensiunkan(c.Params("kid")); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "kunci dipensiunkan"})
+}
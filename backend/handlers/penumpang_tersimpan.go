@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var penumpangTersimpanRepoGlobal repositories.PenumpangTersimpanRepo
+
+func InitPenumpangTersimpanHandler(repo repositories.PenumpangTersimpanRepo) {
+	penumpangTersimpanRepoGlobal = repo
+}
+
+type HandlerPenumpangTersimpan struct {
+	repo repositories.PenumpangTersimpanRepo
+}
+
+func NewHandlerPenumpangTersimpan() *HandlerPenumpangTersimpan {
+	return &HandlerPenumpangTersimpan{repo: penumpangTersimpanRepoGlobal}
+}
+
+func (h *HandlerPenumpangTersimpan) List(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	list, err := h.repo.ListByUser(uid)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+func (h *HandlerPenumpangTersimpan) Buat(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	var p models.PenumpangTersimpan
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+	}
+	p.ID = 0
+	p.UserID = uid
+	if err := p.Normalisasi(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.repo.Buat(&p); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(http.StatusCreated).JSON(p)
+}
+
+func (h *HandlerPenumpangTersimpan) Update(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	id, _ := strconv.Atoi(c.Params("id"))
+	lama, err := h.repo.GetMilikUser(uint(id), uid)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if lama == nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "penumpang tidak ditemukan"})
+	}
+
+	var p models.PenumpangTersimpan
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+	}
+	p.ID = lama.ID
+	p.UserID = uid
+	p.CreatedAt = lama.CreatedAt
+	if err := p.Normalisasi(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.repo.Update(&p); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(p)
+}
+
+func (h *HandlerPenumpangTersimpan) Hapus(c *fiber.Ctx) error {
+	uid, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := h.repo.Hapus(uint(id), uid); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "penumpang tidak ditemukan"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "penumpang dihapus"})
+}
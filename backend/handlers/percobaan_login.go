@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+var percobaanLoginRepoGlobal repositories.PercobaanLoginRepo
+
+func InitPercobaanLoginHandler(repo repositories.PercobaanLoginRepo) {
+	percobaanLoginRepoGlobal = repo
+}
+
+type HandlerPercobaanLogin struct {
+	repo repositories.PercobaanLoginRepo
+}
+
+func NewHandlerPercobaanLogin() *HandlerPercobaanLogin {
+	return &HandlerPercobaanLogin{repo: percobaanLoginRepoGlobal}
+}
+
+// List menampilkan riwayat percobaan login terbaru. Query opsional: email,
+// ip, user_id, berhasil (true/false), dari & sampai (YYYY-MM-DD), limit.
+func (h *HandlerPercobaanLogin) List(c *fiber.Ctx) error {
+	f := repositories.FilterPercobaanLogin{
+		Email: strings.ToLower(strings.TrimSpace(c.Query("email"))),
+		IP:    c.Query("ip"),
+		Limit: c.QueryInt("limit", 100),
+	}
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "user_id tidak valid"})
+		}
+		f.UserID = uint(id)
+	}
+	if v := c.Query("berhasil"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "berhasil harus true atau false"})
+		}
+		f.Berhasil = &b
+	}
+	if v := c.Query("dari"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "format dari harus YYYY-MM-DD"})
+		}
+		f.Dari = &t
+	}
+	if v := c.Query("sampai"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "format sampai harus YYYY-MM-DD"})
+		}
+		t = t.AddDate(0, 0, 1)
+		f.Sampai = &t
+	}
+
+	list, err := h.repo.Cari(f)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
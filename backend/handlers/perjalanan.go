@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var perjalananSvcGlobal *services.PerjalananService
+
+func InitPerjalananHandler(svc *services.PerjalananService) {
+	perjalananSvcGlobal = svc
+}
+
+type HandlerPerjalanan struct {
+	svc   *services.PerjalananService
+	tarif *services.TarifService
+}
+
+func NewHandlerPerjalanan() *HandlerPerjalanan {
+	return &HandlerPerjalanan{svc: perjalananSvcGlobal, tarif: tarifSvcGlobal}
+}
+
+func resolveStasiun(input string) (uint, bool) {
+	if id, err := strconv.ParseUint(input, 10, 64); err == nil {
+		return uint(id), true
+	}
+	s, err := repoStasiun.GetByKode(input)
+	if err != nil {
+		return 0, false
+	}
+	return s.ID, true
+}
+
+func (h *HandlerPerjalanan) Cari(c *fiber.Ctx) error {
+	tanggal := c.Query("tanggal")
+	if tanggal == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "parameter tanggal wajib diisi (YYYY-MM-DD)"})
+	}
+
+	asalID, ok := resolveStasiun(c.Query("asal"))
+	if !ok {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "stasiun asal tidak ditemukan"})
+	}
+	tujuanID, ok := resolveStasiun(c.Query("tujuan"))
+	if !ok {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "stasiun tujuan tidak ditemukan"})
+	}
+
+	maksTransit := c.QueryInt("maks_transit", 2)
+
+	results, err := h.svc.Cari(c.Context(), asalID, tujuanID, tanggal, c.Query("kelas"), maksTransit, c.Query("urut"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"count": len(results),
+		"data":  results,
+	})
+}
+
+func (h *HandlerPerjalanan) KalenderHarga(c *fiber.Ctx) error {
+	asalID, ok := resolveStasiun(c.Query("asal"))
+	if !ok {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "stasiun asal tidak ditemukan"})
+	}
+	tujuanID, ok := resolveStasiun(c.Query("tujuan"))
+	if !ok {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "stasiun tujuan tidak ditemukan"})
+	}
+
+	dari := time.Now()
+	if q := c.Query("dari"); q != "" {
+		t, err := time.ParseInLocation("2006-01-02", q, time.Local)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "format dari harus YYYY-MM-DD"})
+		}
+		dari = t
+	}
+
+	results, err := h.tarif.KalenderHarga(asalID, tujuanID, dari, c.QueryInt("hari", 30), c.Query("kelas"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"count": len(results),
+		"data":  results,
+	})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+var userRepoGlobal repositories.UserRepo
+
+func InitRoleHandler(repo repositories.UserRepo) {
+	userRepoGlobal = repo
+}
+
+type HandlerRole struct {
+	repo repositories.UserRepo
+}
+
+func NewHandlerRole() *HandlerRole {
+	return &HandlerRole{repo: userRepoGlobal}
+}
+
+type roleReq struct {
+	Role string `json:"role"`
+}
+
+func (h *HandlerRole) ListRole(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	user, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user tidak ditemukan"})
+	}
+	return c.JSON(fiber.Map{"user_id": user.ID, "email": user.Email, "roles": user.DaftarRole()})
+}
+
+func (h *HandlerRole) GrantRole(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	var req roleReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+	}
+	if !models.RoleValid(req.Role) || req.Role == models.RolePassenger {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "role harus operator, admin, atau station_staff"})
+	}
+	user, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user tidak ditemukan"})
+	}
+	if err := h.repo.GrantRole(user.ID, req.Role); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return h.ListRole(c)
+}
+
+func (h *HandlerRole) RevokeRole(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	role := c.Params("role")
+	if role == models.RolePassenger {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "role passenger tidak bisa dicabut"})
+	}
+	// admin tidak boleh mencabut role admin miliknya sendiri agar sistem tidak
+	// kehilangan admin terakhir secara tidak sengaja
+	if me, ok := c.Locals("user_id").(uint); ok && me == uint(id) && role == models.RoleAdmin {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "tidak bisa mencabut role admin milik sendiri"})
+	}
+	if err := h.repo.RevokeRole(uint(id), role); err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return h.ListRole(c)
+}
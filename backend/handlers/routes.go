@@ -1,6 +1,8 @@
 ﻿package handlers
 
 import (
+	"time"
+
 	"github.com/fitranmei/Mooove-/backend/middlewares"
 	"github.com/fitranmei/Mooove-/backend/models"
 	"github.com/fitranmei/Mooove-/backend/services"
@@ -19,6 +21,7 @@ var (
 
 	authServiceGlobal models.AuthService
 	paymentSvc        *services.PaymentService
+	bookingSvc        *services.BookingService
 
 	dbConn *gorm.DB
 )
@@ -44,15 +47,21 @@ type JadwalRepoInterface interface {
 	ListSemua() ([]models.Jadwal, error)
 	GetByID(id uint) (*models.Jadwal, error)
 	Buat(j *models.Jadwal) (*models.Jadwal, error)
+	BuatTx(tx *gorm.DB, j *models.Jadwal) error
 	Hapus(id uint) error
 	CariJadwal(asal, tujuan, tanggal, kelas string) ([]models.Jadwal, error)
+	ListByRentangTanggal(dari, sampai string, kelas string) ([]models.Jadwal, error)
+	ListByRentangTanggalTx(tx *gorm.DB, dari, sampai string, kelas string) ([]models.Jadwal, error)
+	RingkasanRute(asalID, tujuanID uint, dari, sampai string, kelas string) ([]models.RingkasanJadwal, error)
 }
 
 type KetersediaanRepoInterface interface {
-	FindAndLockBySchedule(tx *gorm.DB, scheduleID uint, seatIDs []uint) ([]models.KetersediaanKursi, error)
-	MarkReserved(tx *gorm.DB, inventoryIDs []uint, bookingID uint) error
+	FindAndLockBySegment(tx *gorm.DB, scheduleID uint, naikUrutan, turunUrutan int, seatIDs []uint) ([]models.KetersediaanKursi, error)
+	MarkReserved(tx *gorm.DB, inventoryIDs []uint, bookingID uint, reservedUntil time.Time) error
+	PerpanjangReserved(tx *gorm.DB, bookingID uint, reservedUntil time.Time) (int64, error)
 	ReleaseByBooking(tx *gorm.DB, bookingID uint) error
 	GetBySchedule(scheduleID uint) ([]models.KetersediaanKursi, error)
+	GetBySegment(scheduleID uint, naikUrutan, turunUrutan int) ([]models.KetersediaanKursi, error)
 }
 
 type GerbongRepoInterface interface {
@@ -63,6 +72,7 @@ type GerbongRepoInterface interface {
 	Update(g *models.Gerbong) error
 	Delete(id uint) error
 	ListByKeretaAndKelas(keretaID uint, kelas string) ([]models.Gerbong, error)
+	TataLetakGerbongs(gerbongs []models.Gerbong) ([]*models.TataLetakGerbong, error)
 }
 
 type BookingRepoInterface interface {
@@ -88,6 +98,7 @@ func InitHandlers(
 	tiketRepo TiketRepoInterface,
 	authSvc models.AuthService,
 	paySvc *services.PaymentService,
+	bookSvc *services.BookingService,
 	db *gorm.DB,
 ) {
 	repoStasiun = stasiunRepo
@@ -100,6 +111,7 @@ func InitHandlers(
 
 	authServiceGlobal = authSvc
 	paymentSvc = paySvc
+	bookingSvc = bookSvc
 
 	dbConn = db
 }
@@ -109,49 +121,141 @@ func RegisterRoutes(app *fiber.App) {
 		return c.SendString("API is running")
 	})
 
+	hKunci := NewHandlerKunci()
+	app.Get("/.well-known/jwks.json", hKunci.JWKS)
+	app.Get("/reset-password", NewHandlerAkun().HalamanResetPassword)
+
 	api := app.Group("/api/v1")
 
 	authHandler := NewAuthHandler(authServiceGlobal)
 	api.Post("/auth/register", authHandler.Register)
 	api.Post("/auth/login", authHandler.Login)
+	api.Post("/auth/refresh", authHandler.Refresh)
+	api.Post("/auth/logout", authHandler.Logout)
+	api.Post("/auth/logout-all", middlewares.AuthProtected(dbConn), authHandler.LogoutSemua)
+
+	hAkun := NewHandlerAkun()
+	api.Post("/auth/verifikasi-email/kirim", middlewares.AuthProtected(dbConn), hAkun.KirimVerifikasi)
+	api.Get("/auth/verifikasi-email", hAkun.Verifikasi)
+	api.Post("/auth/verifikasi-email", hAkun.Verifikasi)
+	api.Post("/auth/lupa-password", hAkun.LupaPassword)
+	api.Post("/auth/reset-password", hAkun.ResetPassword)
 
 	api.Get("/auth/me", middlewares.AuthProtected(dbConn), authHandler.Me)
+	api.Put("/auth/me", middlewares.AuthProtected(dbConn), hAkun.UpdateProfil)
+	api.Post("/auth/ganti-password", middlewares.AuthProtected(dbConn), hAkun.GantiPassword)
+
+	hDataPribadi := NewHandlerDataPribadi()
+	api.Get("/auth/me/ekspor", middlewares.AuthProtected(dbConn), hDataPribadi.Ekspor)
+	api.Delete("/auth/me", middlewares.AuthProtected(dbConn), hDataPribadi.HapusAkun)
+
+	hPercobaanLogin := NewHandlerPercobaanLogin()
+	api.Get("/auth/percobaan-login", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermLihatAudit), hPercobaanLogin.List)
+
+	api.Get("/auth/kunci", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaKunci), hKunci.ListKunci)
+	api.Post("/auth/kunci/rotasi", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaKunci), hKunci.Rotasi)
+	api.Delete("/auth/kunci/:kid", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaKunci), hKunci.Pensiunkan)
+
+	hRole := NewHandlerRole()
+	api.Get("/users/:id/roles", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaRole), hRole.ListRole)
+	api.Post("/users/:id/roles", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaRole), hRole.GrantRole)
+	api.Delete("/users/:id/roles/:role", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaRole), hRole.RevokeRole)
 
 	stasiunHandler := NewHandlerStasiun(repoStasiun)
 	api.Get("/stasiun", stasiunHandler.ListSemua)
 	api.Get("/stasiun/:id", stasiunHandler.GetByID)
-	api.Post("/stasiun", stasiunHandler.Buat)
-	api.Put("/stasiun/:id", stasiunHandler.Update)
-	api.Delete("/stasiun/:id", stasiunHandler.Hapus)
+	api.Post("/stasiun", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), stasiunHandler.Buat)
+	api.Put("/stasiun/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), stasiunHandler.Update)
+	api.Delete("/stasiun/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), stasiunHandler.Hapus)
 
 	keretaHandler := NewHandlerKereta(repoKereta)
 	api.Get("/kereta", keretaHandler.ListSemua)
 	api.Get("/kereta/:id", keretaHandler.GetByID)
-	api.Post("/kereta", keretaHandler.Buat)
-	api.Put("/kereta/:id", keretaHandler.Update)
-	api.Delete("/kereta/:id", keretaHandler.Hapus)
+	api.Post("/kereta", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), keretaHandler.Buat)
+	api.Put("/kereta/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), keretaHandler.Update)
+	api.Delete("/kereta/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), keretaHandler.Hapus)
 
-	jadwalHandler := NewHandlerJadwal(repoJadwal, repoGerbong, repoKetersediaan, dbConn)
+	jadwalHandler := NewHandlerJadwal(repoJadwal, repoGerbong, repoKetersediaan, tarifSvcGlobal, dbConn)
 	api.Get("/jadwal", jadwalHandler.ListSemua)
 	api.Get("/jadwal/cari", jadwalHandler.CariJadwal)
 	api.Get("/jadwal/:id", jadwalHandler.GetByID)
-	api.Post("/jadwal", jadwalHandler.Buat)
-	api.Delete("/jadwal/:id", jadwalHandler.Hapus)
+	api.Post("/jadwal", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), jadwalHandler.Buat)
+	api.Delete("/jadwal/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), jadwalHandler.Hapus)
 	api.Get("/jadwal/:id/kursi", jadwalHandler.GetKursiByJadwal)
 
+	hPerjalanan := NewHandlerPerjalanan()
+	api.Get("/perjalanan/cari", hPerjalanan.Cari)
+	api.Get("/perjalanan/kalender-harga", hPerjalanan.KalenderHarga)
+
 	hGerbong := NewHandlerGerbong()
 	api.Get("/gerbong", hGerbong.ListSemuaGerbong)
 	api.Get("/gerbong/:id", hGerbong.GetGerbongByID)
-	api.Post("/gerbong", hGerbong.BuatGerbong)
-	api.Put("/gerbong/:id", hGerbong.UpdateGerbong)
-	api.Delete("/gerbong/:id", hGerbong.HapusGerbong)
+	api.Post("/gerbong", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), hGerbong.BuatGerbong)
+	api.Put("/gerbong/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), hGerbong.UpdateGerbong)
+	api.Delete("/gerbong/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), hGerbong.HapusGerbong)
 	api.Get("/gerbong/:id/kursi", hGerbong.ListKursiByGerbong)
 
+	hTataLetak := NewHandlerTataLetakGerbong()
+	api.Get("/tata-letak-gerbong", hTataLetak.List)
+	api.Get("/tata-letak-gerbong/:id", hTataLetak.GetByID)
+	api.Post("/tata-letak-gerbong", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), hTataLetak.Buat)
+	api.Put("/tata-letak-gerbong/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), hTataLetak.Update)
+	api.Delete("/tata-letak-gerbong/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaMaster), hTataLetak.Hapus)
+
+	hTarif := NewHandlerTarif()
+	api.Post("/tarif/quote", middlewares.AuthOpsional(dbConn), hTarif.Quote)
+	api.Get("/layanan", hTarif.ListLayanan)
+	api.Post("/layanan", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hTarif.BuatLayanan)
+	api.Put("/layanan/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hTarif.UpdateLayanan)
+	api.Delete("/layanan/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hTarif.HapusLayanan)
+
+	hAturanHarga := NewHandlerAturanHarga()
+	api.Get("/aturan-harga", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hAturanHarga.ListSemua)
+	api.Post("/aturan-harga", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hAturanHarga.Buat)
+	api.Put("/aturan-harga/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hAturanHarga.Update)
+	api.Delete("/aturan-harga/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hAturanHarga.Hapus)
+
+	hTemplate := NewHandlerTemplateJadwal()
+	api.Get("/template-jadwal", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hTemplate.ListSemua)
+	api.Post("/template-jadwal", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hTemplate.Buat)
+	api.Post("/template-jadwal/generate", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hTemplate.Generate)
+	api.Get("/template-jadwal/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hTemplate.GetByID)
+	api.Put("/template-jadwal/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hTemplate.Update)
+	api.Delete("/template-jadwal/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hTemplate.Hapus)
+	api.Post("/template-jadwal/:id/generate", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hTemplate.Generate)
+	api.Post("/template-jadwal/:id/pengecualian", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hTemplate.TambahPengecualian)
+	api.Delete("/template-jadwal/:id/pengecualian/:tanggal", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hTemplate.HapusPengecualian)
+
+	hGtfs := NewHandlerGtfs()
+	api.Get("/gtfs/export", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hGtfs.Ekspor)
+
+	hImpor := NewHandlerImpor()
+	api.Post("/impor/jadwal", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaJadwal), hImpor.ImporJadwal)
+
+	hVoucher := NewHandlerVoucher()
+	api.Get("/voucher", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hVoucher.ListSemua)
+	api.Get("/voucher/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hVoucher.GetByID)
+	api.Post("/voucher", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hVoucher.Buat)
+	api.Put("/voucher/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hVoucher.Update)
+	api.Delete("/voucher/:id", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaTarif), hVoucher.Hapus)
+
+	hKebijakan := NewHandlerKebijakanBooking()
+	api.Get("/kebijakan-booking", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaKebijakan), hKebijakan.Get)
+	api.Put("/kebijakan-booking", middlewares.AuthProtected(dbConn), middlewares.RequirePermission(models.PermKelolaKebijakan), hKebijakan.Update)
+
 	hBooking := NewHandlerBooking(repoBooking, repoKetersediaan, dbConn)
 	api.Post("/bookings", middlewares.AuthProtected(dbConn), hBooking.CreateBooking)
 	api.Get("/bookings/:id", middlewares.AuthProtected(dbConn), hBooking.GetBookingByID)
 	api.Get("/user/bookings", middlewares.AuthProtected(dbConn), hBooking.ListBookingsForUser)
+
+	hPenumpang := NewHandlerPenumpangTersimpan()
+	api.Get("/user/penumpang", middlewares.AuthProtected(dbConn), hPenumpang.List)
+	api.Post("/user/penumpang", middlewares.AuthProtected(dbConn), hPenumpang.Buat)
+	api.Put("/user/penumpang/:id", middlewares.AuthProtected(dbConn), hPenumpang.Update)
+	api.Delete("/user/penumpang/:id", middlewares.AuthProtected(dbConn), hPenumpang.Hapus)
+	api.Post("/bookings/:id/voucher", middlewares.AuthProtected(dbConn), hBooking.TerapkanVoucher)
 	api.Post("/bookings/:id/pay", middlewares.AuthProtected(dbConn), hBooking.CreatePaymentForBooking)
+	api.Post("/bookings/:id/extend", middlewares.AuthProtected(dbConn), hBooking.PerpanjangHold)
 	api.Put("/bookings/:id/pay-success", middlewares.AuthProtected(dbConn), hBooking.MarkBookingPaid)
 	api.Delete("/bookings/:id", middlewares.AuthProtected(dbConn), hBooking.DeleteBooking)
 	api.Post("/payments/webhook", hBooking.PaymentWebhook)
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/fitranmei/Mooove-/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	tarifSvcGlobal    *services.TarifService
+	layananRepoGlobal repositories.LayananRepo
+)
+
+func InitTarifHandler(svc *services.TarifService, layananRepo repositories.LayananRepo) {
+	tarifSvcGlobal = svc
+	layananRepoGlobal = layananRepo
+}
+
+type HandlerTarif struct {
+	svc         *services.TarifService
+	layananRepo repositories.LayananRepo
+}
+
+func NewHandlerTarif() *HandlerTarif {
+	return &HandlerTarif{svc: tarifSvcGlobal, layananRepo: layananRepoGlobal}
+}
+
+// Quote menerima body yang sama dengan POST /bookings dan mengembalikan rincian
+// harga tanpa mengunci kursi.
+func (h *HandlerTarif) Quote(c *fiber.Ctx) error {
+	var req CreateBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "body tidak valid", "detail": err.Error()})
+	}
+
+	var userID *uint
+	if v := c.Locals("user_id"); v != nil {
+		if uid, ok := v.(uint); ok {
+			userID = &uid
+		}
+	}
+
+	quote, err := h.svc.Quote(c.Context(), userID, req.permintaan())
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(quote)
+}
+
+func (h *HandlerTarif) ListLayanan(c *fiber.Ctx) error {
+	list, err := h.layananRepo.ListSemua()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+func (h *HandlerTarif) BuatLayanan(c *fiber.Ctx) error {
+	var req models.LayananTambahan
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.Kode == "" || req.Harga < 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "kode wajib diisi dan harga tidak boleh negatif"})
+	}
+	req.ID = 0
+	req.CreatedAt = time.Now()
+	req.UpdatedAt = time.Now()
+	if err := h.layananRepo.Buat(&req); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(http.StatusCreated).JSON(req)
+}
+
+func (h *HandlerTarif) UpdateLayanan(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	var req models.LayananTambahan
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	req.ID = uint(id)
+	req.UpdatedAt = time.Now()
+	if err := h.layananRepo.Update(&req); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(req)
+}
+
+func (h *HandlerTarif) HapusLayanan(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := h.layananRepo.Delete(uint(id)); err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "layanan dihapus"})
+}
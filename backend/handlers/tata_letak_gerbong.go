@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var tataLetakRepoGlobal repositories.TataLetakGerbongRepo
+
+func InitTataLetakGerbongHandler(repo repositories.TataLetakGerbongRepo) {
+	tataLetakRepoGlobal = repo
+}
+
+type HandlerTataLetakGerbong struct {
+	repo repositories.TataLetakGerbongRepo
+}
+
+func NewHandlerTataLetakGerbong() *HandlerTataLetakGerbong {
+	return &HandlerTataLetakGerbong{repo: tataLetakRepoGlobal}
+}
+
+func (h *HandlerTataLetakGerbong) List(c *fiber.Ctx) error {
+	list, err := h.repo.ListSemua()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+func (h *HandlerTataLetakGerbong) GetByID(c *fiber.Ctx) error {
+	id64, _ := strconv.ParseUint(c.Params("id"), 10, 64)
+	t, err := h.repo.GetByID(uint(id64))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "tata letak tidak ditemukan"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(t)
+}
+
+func (h *HandlerTataLetakGerbong) Buat(c *fiber.Ctx) error {
+	var t models.TataLetakGerbong
+	if err := c.BodyParser(&t); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "payload tidak valid"})
+	}
+	t.ID = 0
+	if err := t.Validasi(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.repo.Buat(&t); err != nil {
+		if errors.Is(err, repositories.ErrKodeTataLetakAda) || errors.Is(err, repositories.ErrKelasTataLetakAda) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(http.StatusCreated).JSON(t)
+}
+
+// Update menerima sebagian field. Kursi yang sudah ada tidak ikut berubah;
+// jalankan perintah terapkan-tata-letak untuk memperbarui atributnya.
+func (h *HandlerTataLetakGerbong) Update(c *fiber.Ctx) error {
+	id64, _ := strconv.ParseUint(c.Params("id"), 10, 64)
+	t, err := h.repo.GetByID(uint(id64))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "tata letak tidak ditemukan"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := c.BodyParser(t); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "payload tidak valid"})
+	}
+	t.ID = uint(id64)
+	if err := t.Validasi(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.repo.Simpan(t); err != nil {
+		if errors.Is(err, repositories.ErrKodeTataLetakAda) || errors.Is(err, repositories.ErrKelasTataLetakAda) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(t)
+}
+
+func (h *HandlerTataLetakGerbong) Hapus(c *fiber.Ctx) error {
+	id64, _ := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err := h.repo.Delete(uint(id64)); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "tata letak tidak ditemukan"})
+		case errors.Is(err, repositories.ErrTataLetakDipakai):
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "tata letak dihapus"})
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/fitranmei/Mooove-/backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	templateJadwalRepoGlobal repositories.TemplateJadwalRepo
+	generatorJadwalSvcGlobal *services.GeneratorJadwalService
+)
+
+func InitTemplateJadwalHandler(repo repositories.TemplateJadwalRepo, svc *services.GeneratorJadwalService) {
+	templateJadwalRepoGlobal = repo
+	generatorJadwalSvcGlobal = svc
+}
+
+type HandlerTemplateJadwal struct {
+	repo repositories.TemplateJadwalRepo
+	svc  *services.GeneratorJadwalService
+}
+
+func NewHandlerTemplateJadwal() *HandlerTemplateJadwal {
+	return &HandlerTemplateJadwal{repo: templateJadwalRepoGlobal, svc: generatorJadwalSvcGlobal}
+}
+
+func (h *HandlerTemplateJadwal) ListSemua(c *fiber.Ctx) error {
+	list, err := h.repo.ListSemua()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+func (h *HandlerTemplateJadwal) GetByID(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	t, err := h.repo.GetByID(uint(id))
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "template jadwal tidak ditemukan"})
+	}
+	return c.JSON(t)
+}
+
+// templateJadwalReq memakai pointer supaya field yang tidak dikirim bisa
+// dibedakan dari nilai kosong: Update hanya mengganti field yang dikirim,
+// dan Buat tanpa "aktif" menghasilkan template aktif.
+type templateJadwalReq struct {
+	Nama          *string                      `json:"nama"`
+	KeretaID      *uint                        `json:"kereta_id"`
+	JamBerangkat  *string                      `json:"jam_berangkat"`
+	HariOperasi   *string                      `json:"hari_operasi"`
+	BerlakuMulai  *string                      `json:"berlaku_mulai"`
+	BerlakuSampai *string                      `json:"berlaku_sampai"`
+	Aktif         *bool                        `json:"aktif"`
+	Stops         *[]models.TemplateStop       `json:"stops"`
+	HargaKelas    *[]models.TemplateHargaKelas `json:"harga_kelas"`
+}
+
+func (r *templateJadwalReq) terapkan(t *models.TemplateJadwal) {
+	if r.Nama != nil {
+		t.Nama = *r.Nama
+	}
+	if r.KeretaID != nil {
+		t.KeretaID = *r.KeretaID
+	}
+	if r.JamBerangkat != nil {
+		t.JamBerangkat = *r.JamBerangkat
+	}
+	if r.HariOperasi != nil {
+		t.HariOperasi = *r.HariOperasi
+	}
+	if r.BerlakuMulai != nil {
+		t.BerlakuMulai = *r.BerlakuMulai
+	}
+	if r.BerlakuSampai != nil {
+		t.BerlakuSampai = *r.BerlakuSampai
+	}
+	if r.Aktif != nil {
+		t.Aktif = *r.Aktif
+	}
+	if r.Stops != nil {
+		t.Stops = *r.Stops
+	}
+	if r.HargaKelas != nil {
+		t.HargaKelas = *r.HargaKelas
+	}
+	for i := range t.Stops {
+		t.Stops[i].Urutan = i
+	}
+}
+
+func (h *HandlerTemplateJadwal) Buat(c *fiber.Ctx) error {
+	var req templateJadwalReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	baru := models.TemplateJadwal{Aktif: true}
+	req.terapkan(&baru)
+	if err := services.ValidasiTemplate(&baru); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	baru.CreatedAt = time.Now()
+	baru.UpdatedAt = time.Now()
+	if err := h.repo.Buat(&baru); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	t, err := h.repo.GetByID(baru.ID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(http.StatusCreated).JSON(t)
+}
+
+func (h *HandlerTemplateJadwal) Update(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	var req templateJadwalReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	ex, err := h.repo.GetByID(uint(id))
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "template jadwal tidak ditemukan"})
+	}
+	req.terapkan(ex)
+	if err := services.ValidasiTemplate(ex); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	ex.UpdatedAt = time.Now()
+	if err := h.repo.Update(ex); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	t, err := h.repo.GetByID(ex.ID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(t)
+}
+
+func (h *HandlerTemplateJadwal) Hapus(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := h.repo.Delete(uint(id)); err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "template jadwal dihapus"})
+}
+
+// TambahPengecualian menandai tanggal (misalnya hari libur) yang tidak boleh
+// digenerate. Jadwal yang sudah terbentuk pada tanggal itu tidak dihapus.
+func (h *HandlerTemplateJadwal) TambahPengecualian(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	var req models.TemplateJadwalPengecualian
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if _, err := time.Parse("2006-01-02", req.Tanggal); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "tanggal harus berformat YYYY-MM-DD"})
+	}
+	if _, err := h.repo.GetByID(uint(id)); err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "template jadwal tidak ditemukan"})
+	}
+	req.ID = 0
+	req.TemplateID = uint(id)
+	if err := h.repo.TambahPengecualian(&req); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"message": "tanggal pengecualian ditambahkan", "tanggal": req.Tanggal})
+}
+
+func (h *HandlerTemplateJadwal) HapusPengecualian(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := h.repo.HapusPengecualian(uint(id), c.Params("tanggal")); err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "tanggal pengecualian dihapus"})
+}
+
+// Generate menjalankan generator saat itu juga, untuk satu template atau
+// semua template aktif.
+func (h *HandlerTemplateJadwal) Generate(c *fiber.Ctx) error {
+	if idStr := c.Params("id"); idStr != "" {
+		id, _ := strconv.Atoi(idStr)
+		t, err := h.repo.GetByID(uint(id))
+		if err != nil {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "template jadwal tidak ditemukan"})
+		}
+		n, err := h.svc.GenerateTemplate(t, time.Now())
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{"jadwal_dibuat": n})
+	}
+
+	n, err := h.svc.Generate(c.Context())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error(), "jadwal_dibuat": n})
+	}
+	return c.JSON(fiber.Map{"jadwal_dibuat": n})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var voucherRepoGlobal repositories.VoucherRepo
+
+func InitVoucherHandler(repo repositories.VoucherRepo) {
+	voucherRepoGlobal = repo
+}
+
+type HandlerVoucher struct {
+	repo repositories.VoucherRepo
+}
+
+func NewHandlerVoucher() *HandlerVoucher {
+	return &HandlerVoucher{repo: voucherRepoGlobal}
+}
+
+func validasiVoucher(v *models.Voucher) string {
+	v.Kode = strings.ToUpper(strings.TrimSpace(v.Kode))
+	if v.Kode == "" {
+		return "kode voucher wajib diisi"
+	}
+	switch v.Jenis {
+	case "persen":
+		if v.Nilai <= 0 || v.Nilai > 100 {
+			return "nilai voucher persen harus 1-100"
+		}
+	case "nominal":
+		if v.Nilai <= 0 {
+			return "nilai voucher nominal harus lebih dari 0"
+		}
+	default:
+		return "jenis voucher harus persen atau nominal"
+	}
+	if v.BerlakuMulai != nil && v.BerlakuSampai != nil && v.BerlakuSampai.Before(*v.BerlakuMulai) {
+		return "berlaku_sampai tidak boleh sebelum berlaku_mulai"
+	}
+	if v.KuotaGlobal < 0 || v.KuotaPerUser < 0 {
+		return "kuota tidak boleh negatif"
+	}
+	return ""
+}
+
+func (h *HandlerVoucher) ListSemua(c *fiber.Ctx) error {
+	list, err := h.repo.ListSemua()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+func (h *HandlerVoucher) GetByID(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	v, err := h.repo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "voucher tidak ditemukan"})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(v)
+}
+
+func (h *HandlerVoucher) Buat(c *fiber.Ctx) error {
+	var req models.Voucher
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if msg := validasiVoucher(&req); msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	req.ID = 0
+	req.CreatedAt = time.Now()
+	req.UpdatedAt = time.Now()
+	if err := h.repo.Buat(&req); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(http.StatusCreated).JSON(req)
+}
+
+func (h *HandlerVoucher) Update(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	var req models.Voucher
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if msg := validasiVoucher(&req); msg != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+	req.ID = uint(id)
+	req.UpdatedAt = time.Now()
+	if err := h.repo.Update(&req); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(req)
+}
+
+func (h *HandlerVoucher) Hapus(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := h.repo.Delete(uint(id)); err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "voucher dihapus"})
+}
@@ -2,8 +2,6 @@ package middlewares
 
 import (
 	"errors"
-	"fmt"
-	"os"
 	"strings"
 
 	"github.com/fitranmei/Mooove-/backend/models"
@@ -13,6 +11,14 @@ import (
 	"gorm.io/gorm"
 )
 
+var keyfunc jwt.Keyfunc
+
+// InitAuth memasang fungsi pemilih kunci verifikasi token, dari
+// services.PengelolaKunci, sehingga secret JWT hanya dibaca di config.
+func InitAuth(kf jwt.Keyfunc) {
+	keyfunc = kf
+}
+
 func AuthProtected(db *gorm.DB) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		authHeader := ctx.Get("Authorization")
@@ -35,18 +41,7 @@ func AuthProtected(db *gorm.DB) fiber.Handler {
 
 		tokenStr := parts[1]
 
-		secretStr := os.Getenv("JWT_SECRET")
-		if secretStr == "" {
-			secretStr = "verymooove123"
-		}
-		secret := []byte(secretStr)
-
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return secret, nil
-		})
+		token, err := jwt.Parse(tokenStr, keyfunc)
 		if err != nil {
 			log.Warnf("invalid token: %v", err)
 			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -85,7 +80,7 @@ func AuthProtected(db *gorm.DB) fiber.Handler {
 		}
 
 		var user models.User
-		if err := db.First(&user, userId).Error; err != nil {
+		if err := db.Preload("Roles").First(&user, userId).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				log.Warnf("user not found in db: %v", userId)
 				return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -100,10 +95,48 @@ func AuthProtected(db *gorm.DB) fiber.Handler {
 			})
 		}
 
+		if user.DihapusPada != nil {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  "fail",
+				"message": "Unauthorized",
+			})
+		}
+
+		// token tanpa claim ver terbit sebelum versi token ada dan dianggap
+		// versi 0
+		var versi float64
+		if v, exists := claims["ver"]; exists {
+			versi, ok = v.(float64)
+		}
+		if !ok || versi != float64(user.VersiToken) {
+			log.Warnf("token revoked for user: %v", user.ID)
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  "fail",
+				"message": "Unauthorized",
+			})
+		}
+
 		ctx.Locals("user_id", user.ID)
 		ctx.Locals("user_email", user.Email)
 		ctx.Locals("user_fullname", user.Fullname)
+		ctx.Locals("user_email_terverifikasi", user.EmailTerverifikasi())
+		// role diambil dari database, bukan dari claim token, supaya revoke
+		// langsung berlaku tanpa menunggu token kedaluwarsa
+		ctx.Locals("user_roles", user.DaftarRole())
 
 		return ctx.Next()
 	}
 }
+
+// AuthOpsional menjalankan AuthProtected hanya bila header Authorization
+// dikirim, untuk endpoint publik yang hasilnya bergantung pada user (mis.
+// batas pemakaian voucher per user di quote tarif).
+func AuthOpsional(db *gorm.DB) fiber.Handler {
+	wajib := AuthProtected(db)
+	return func(ctx *fiber.Ctx) error {
+		if strings.TrimSpace(ctx.Get("Authorization")) == "" {
+			return ctx.Next()
+		}
+		return wajib(ctx)
+	}
+}
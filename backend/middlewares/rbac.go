@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// RequirePermission harus dipasang setelah AuthProtected.
+func RequirePermission(izin string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		roles, _ := ctx.Locals("user_roles").([]string)
+		if !models.PunyaIzin(roles, izin) {
+			log.Warnf("forbidden: user %v tanpa izin %s", ctx.Locals("user_id"), izin)
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "fail",
+				"message": "Forbidden",
+			})
+		}
+		return ctx.Next()
+	}
+}
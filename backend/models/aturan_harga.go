@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AturanHarga adalah satu bucket harga dinamis. Jenis "muatan" dicocokkan
+// dengan persentase kursi terisi (0-100), jenis "hari" dengan sisa hari
+// sampai keberangkatan. Rentang berlaku Dari <= nilai < Sampai.
+type AturanHarga struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Jenis     string    `gorm:"type:enum('muatan','hari')" json:"jenis"`
+	Dari      int       `json:"dari"`
+	Sampai    int       `json:"sampai"`
+	Faktor    int       `json:"faktor"`               // persen dari harga, 100 = tetap
+	Kelas     string    `gorm:"size:32" json:"kelas"` // kosong = semua kelas
+	Aktif     bool      `gorm:"default:true" json:"aktif"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (a *AturanHarga) Cocok(kelas string, nilai int) bool {
+	if a.Kelas != "" && a.Kelas != kelas {
+		return false
+	}
+	return nilai >= a.Dari && nilai < a.Sampai
+}
@@ -19,8 +19,11 @@ type AuthRepository interface {
 }
 
 type AuthService interface {
-	Login(ctx context.Context, loginData *AuthCredentials) (string, *User, error)
-	Register(ctx context.Context, registerData *AuthCredentials) (string, *User, error)
+	Login(ctx context.Context, loginData *AuthCredentials) (*TokenAuth, *User, error)
+	Register(ctx context.Context, registerData *AuthCredentials) (*TokenAuth, *User, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenAuth, error)
+	Logout(ctx context.Context, refreshToken string) error
+	LogoutSemua(ctx context.Context, userID uint) error
 }
 
 func MatchesHash(password, hash string) bool {
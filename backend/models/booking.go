@@ -7,10 +7,33 @@ type Booking struct {
 	UserID          *uint
 	TrainScheduleID uint
 	TrainSchedule   Jadwal `gorm:"foreignKey:TrainScheduleID"`
+	NaikStasiunID   uint
+	TurunStasiunID  uint
+	Tipe            string `gorm:"size:20;default:'sekali_jalan'"` // sekali_jalan, pulang_pergi, transit
 	Status          string `gorm:"type:enum('pending','paid','cancelled','expired');default:'pending'"`
 	TotalPrice      int64
-	Penumpangs      []Penumpang `gorm:"foreignKey:BookingID"`
-	ReservedUntil   *time.Time  `json:"reserved_until" gorm:"-"`
+	Legs            []BookingLeg  `gorm:"foreignKey:BookingID"`
+	Items           []BookingItem `gorm:"foreignKey:BookingID"`
+	Penumpangs      []Penumpang   `gorm:"foreignKey:BookingID"`
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
+
+	// ReservedUntil adalah batas waktu hold kursi (sama untuk semua kursi
+	// booking ini), dikosongkan setelah dibayar.
+	ReservedUntil    *time.Time `json:"expires_at"`
+	Kanal            string     `gorm:"size:20" json:"kanal"`
+	DiperpanjangPada *time.Time `json:"diperpanjang_pada"`
+}
+
+// BookingLeg adalah satu ruas perjalanan (satu jadwal) di dalam booking.
+// Booking pulang-pergi atau transit punya lebih dari satu leg.
+type BookingLeg struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	BookingID       uint      `gorm:"index" json:"booking_id"`
+	Urutan          int       `json:"urutan"`
+	TrainScheduleID uint      `gorm:"index" json:"train_schedule_id"`
+	TrainSchedule   Jadwal    `gorm:"foreignKey:TrainScheduleID" json:"train_schedule"`
+	NaikStasiunID   uint      `json:"naik_stasiun_id"`
+	TurunStasiunID  uint      `json:"turun_stasiun_id"`
+	CreatedAt       time.Time `json:"created_at"`
 }
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KesalahanField menunjuk field request yang tidak valid, misalnya
+// "penumpangs[1].no_identitas".
+type KesalahanField struct {
+	Field string `json:"field"`
+	Pesan string `json:"pesan"`
+}
+
+// ValidasiDokumenError berisi semua kesalahan dokumen penumpang sekaligus
+// supaya client bisa menandai setiap field.
+type ValidasiDokumenError struct {
+	Kesalahan []KesalahanField
+}
+
+func (e *ValidasiDokumenError) Error() string {
+	pesan := make([]string, len(e.Kesalahan))
+	for i, k := range e.Kesalahan {
+		pesan[i] = k.Field + ": " + k.Pesan
+	}
+	return "data identitas penumpang tidak valid: " + strings.Join(pesan, "; ")
+}
+
+const (
+	UsiaMinSIM = 17
+
+	panjangNIK = 16
+)
+
+// kodeProvinsiNIK adalah dua digit awal NIK sesuai kode wilayah Kemendagri.
+var kodeProvinsiNIK = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+	"21": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "36": true,
+	"51": true, "52": true, "53": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true,
+	"71": true, "72": true, "73": true, "74": true, "75": true, "76": true,
+	"81": true, "82": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true, "97": true,
+}
+
+func semuaDigit(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// TanggalLahirNIK membaca tanggal lahir dari digit 7-12 NIK (DDMMYY, tanggal
+// ditambah 40 untuk perempuan). Abad dipilih agar tanggal tidak melewati
+// acuan.
+func TanggalLahirNIK(nik string, acuan time.Time) (time.Time, error) {
+	if len(nik) != panjangNIK || !semuaDigit(nik) {
+		return time.Time{}, fmt.Errorf("NIK harus %d digit angka", panjangNIK)
+	}
+	if !kodeProvinsiNIK[nik[0:2]] {
+		return time.Time{}, fmt.Errorf("kode provinsi %s pada NIK tidak dikenal", nik[0:2])
+	}
+	if nik[2:4] == "00" || nik[4:6] == "00" {
+		return time.Time{}, fmt.Errorf("kode kabupaten/kecamatan pada NIK tidak valid")
+	}
+	if nik[12:16] == "0000" {
+		return time.Time{}, fmt.Errorf("nomor urut NIK tidak valid")
+	}
+
+	hari, _ := strconv.Atoi(nik[6:8])
+	bulan, _ := strconv.Atoi(nik[8:10])
+	tahun, _ := strconv.Atoi(nik[10:12])
+	if hari > 40 {
+		hari -= 40
+	}
+
+	abad := 2000
+	if abad+tahun > acuan.Year() {
+		abad = 1900
+	}
+	lahir := time.Date(abad+tahun, time.Month(bulan), hari, 0, 0, 0, 0, time.UTC)
+	if hari < 1 || bulan < 1 || bulan > 12 || lahir.Day() != hari {
+		return time.Time{}, fmt.Errorf("tanggal lahir pada NIK tidak valid")
+	}
+	return lahir, nil
+}
+
+func validasiSIM(no string) string {
+	if !semuaDigit(no) || len(no) < 12 || len(no) > 14 {
+		return "nomor SIM harus 12-14 digit angka"
+	}
+	return ""
+}
+
+func validasiPaspor(no string) string {
+	if len(no) < 7 || len(no) > 9 {
+		return "nomor paspor harus 7-9 karakter"
+	}
+	huruf := false
+	for _, r := range no {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			huruf = true
+		case r >= '0' && r <= '9':
+		default:
+			return "nomor paspor hanya boleh huruf dan angka"
+		}
+	}
+	if !huruf || (no[0] < 'A' || no[0] > 'Z') {
+		return "nomor paspor harus diawali huruf"
+	}
+	return ""
+}
+
+// ValidasiDokumenPenumpang memeriksa jenis dan nomor identitas setiap
+// penumpang terhadap tanggal keberangkatan. Dijalankan sebelum
+// NormalisasiPenumpang: tanggal lahir yang kosong diisi dari NIK sehingga
+// tipe penumpang ikut ditentukan dari NIK. Client lama hanya mengirim nama
+// dan nomor identitas, jadi bila jenis kosong nomor tidak diperiksa lebih
+// jauh seperti sebelumnya. Bayi boleh tanpa identitas.
+func ValidasiDokumenPenumpang(list []Penumpang, berangkat time.Time) error {
+	var salah []KesalahanField
+	tambah := func(i int, field, pesan string) {
+		salah = append(salah, KesalahanField{Field: fmt.Sprintf("penumpangs[%d].%s", i, field), Pesan: pesan})
+	}
+
+	for i := range list {
+		p := &list[i]
+		p.JenisIdentitas = strings.ToLower(strings.TrimSpace(p.JenisIdentitas))
+		no := NormalisasiIdentitas(string(p.NoIdentitas))
+		p.NoIdentitas = Identitas(no)
+
+		var lahir *time.Time
+		if p.TanggalLahir != "" {
+			if t, err := time.Parse("2006-01-02", p.TanggalLahir); err == nil {
+				lahir = &t
+			}
+		}
+		tipe := strings.ToLower(strings.TrimSpace(p.Tipe))
+		if lahir != nil {
+			tipe = tipeDariUsia(usiaPada(*lahir, berangkat))
+		}
+
+		if no == "" {
+			if p.JenisIdentitas != "" || tipe != TipeBayi {
+				tambah(i, "no_identitas", "nomor identitas wajib diisi")
+			}
+			continue
+		}
+		if p.JenisIdentitas == "" {
+			continue
+		}
+
+		switch p.JenisIdentitas {
+		case IdentitasKTP:
+			lahirNIK, err := TanggalLahirNIK(no, berangkat)
+			if err != nil {
+				tambah(i, "no_identitas", err.Error())
+				continue
+			}
+			if lahir == nil {
+				if p.TanggalLahir != "" {
+					// format salah, biarkan NormalisasiPenumpang yang melaporkan
+					continue
+				}
+				p.TanggalLahir = lahirNIK.Format("2006-01-02")
+			} else if !lahir.Equal(lahirNIK) {
+				tambah(i, "tanggal_lahir", "tanggal lahir tidak sesuai dengan NIK")
+			}
+
+		case IdentitasSIM:
+			if pesan := validasiSIM(no); pesan != "" {
+				tambah(i, "no_identitas", pesan)
+				continue
+			}
+			if lahir != nil && usiaPada(*lahir, berangkat) < UsiaMinSIM {
+				tambah(i, "jenis_identitas", fmt.Sprintf("SIM hanya untuk penumpang berusia %d tahun ke atas", UsiaMinSIM))
+			} else if tipe == TipeAnak || tipe == TipeBayi {
+				tambah(i, "jenis_identitas", "SIM tidak bisa dipakai untuk penumpang "+tipe)
+			}
+
+		case IdentitasPaspor:
+			if pesan := validasiPaspor(no); pesan != "" {
+				tambah(i, "no_identitas", pesan)
+				continue
+			}
+			if p.PasporBerlakuSampai == "" {
+				tambah(i, "paspor_berlaku_sampai", "masa berlaku paspor wajib diisi")
+				continue
+			}
+			berlaku, err := time.Parse("2006-01-02", p.PasporBerlakuSampai)
+			if err != nil {
+				tambah(i, "paspor_berlaku_sampai", "format harus YYYY-MM-DD")
+			} else if berlaku.Format("2006-01-02") < berangkat.Format("2006-01-02") {
+				tambah(i, "paspor_berlaku_sampai", "paspor sudah tidak berlaku pada tanggal keberangkatan")
+			}
+
+		default:
+			tambah(i, "jenis_identitas", "jenis identitas harus ktp, sim, atau paspor")
+		}
+	}
+
+	if len(salah) > 0 {
+		return &ValidasiDokumenError{Kesalahan: salah}
+	}
+	return nil
+}
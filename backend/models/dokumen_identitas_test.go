@@ -0,0 +1,111 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTanggalLahirNIK(t *testing.T) {
+	acuan := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		nik     string
+		want    string
+		wantErr bool
+	}{
+		{nik: "3171010101900001", want: "1990-01-01"},
+		{nik: "3171014101900002", want: "1990-01-01"}, // perempuan, tanggal + 40
+		{nik: "3273022912000003", want: "2000-12-29"},
+		{nik: "3171010101200001", want: "2020-01-01"},
+		{nik: "3171010101300001", want: "1930-01-01"}, // 2030 melewati acuan
+		{nik: "317101010190000", wantErr: true},       // 15 digit
+		{nik: "31710101019000A1", wantErr: true},
+		{nik: "9971010101900001", wantErr: true}, // provinsi tidak dikenal
+		{nik: "3100010101900001", wantErr: true}, // kabupaten 00
+		{nik: "3171000101900001", wantErr: true}, // kecamatan 00
+		{nik: "3171013102900001", wantErr: true}, // 31 Februari
+		{nik: "3171010113900001", wantErr: true}, // bulan 13
+		{nik: "3171010101900000", wantErr: true}, // nomor urut 0000
+	}
+	for _, tt := range tests {
+		t.Run(tt.nik, func(t *testing.T) {
+			got, err := TanggalLahirNIK(tt.nik, acuan)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("TanggalLahirNIK(%q) = %v, want error", tt.nik, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := got.Format("2006-01-02"); s != tt.want {
+				t.Errorf("TanggalLahirNIK(%q) = %s, want %s", tt.nik, s, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidasiDokumenPenumpang(t *testing.T) {
+	berangkat := time.Date(2026, 6, 15, 8, 0, 0, 0, time.UTC)
+	ktp := func(no, lahir string) Penumpang {
+		return Penumpang{Nama: "P", JenisIdentitas: IdentitasKTP, NoIdentitas: Identitas(no), TanggalLahir: lahir}
+	}
+	paspor := func(no, berlaku string) Penumpang {
+		return Penumpang{Nama: "P", JenisIdentitas: IdentitasPaspor, NoIdentitas: Identitas(no), PasporBerlakuSampai: berlaku}
+	}
+
+	tests := []struct {
+		nama       string
+		penumpangs []Penumpang
+		wantField  []string
+		wantLahir  string // tanggal lahir penumpang pertama setelah validasi
+	}{
+		{nama: "KTP mengisi tanggal lahir", penumpangs: []Penumpang{ktp("3171010101900001", "")}, wantLahir: "1990-01-01"},
+		{nama: "KTP dengan spasi dan tanda hubung", penumpangs: []Penumpang{ktp("3171 0141-0190 0002", "")}, wantLahir: "1990-01-01"},
+		{nama: "KTP sesuai tanggal lahir", penumpangs: []Penumpang{ktp("3171010101900001", "1990-01-01")}, wantLahir: "1990-01-01"},
+		{nama: "KTP beda tanggal lahir", penumpangs: []Penumpang{ktp("3171010101900001", "1991-01-01")}, wantField: []string{"penumpangs[0].tanggal_lahir"}, wantLahir: "1991-01-01"},
+		{nama: "KTP provinsi tidak dikenal", penumpangs: []Penumpang{ktp("9971010101900001", "")}, wantField: []string{"penumpangs[0].no_identitas"}},
+		{nama: "KTP tanggal tidak ada", penumpangs: []Penumpang{ktp("3171013102900001", "")}, wantField: []string{"penumpangs[0].no_identitas"}},
+		{nama: "SIM dewasa", penumpangs: []Penumpang{{Nama: "P", JenisIdentitas: IdentitasSIM, NoIdentitas: "123456789012", TanggalLahir: "1990-01-01"}}, wantLahir: "1990-01-01"},
+		{nama: "SIM di bawah 17 tahun", penumpangs: []Penumpang{{Nama: "P", JenisIdentitas: IdentitasSIM, NoIdentitas: "123456789012", TanggalLahir: "2010-01-01"}}, wantField: []string{"penumpangs[0].jenis_identitas"}, wantLahir: "2010-01-01"},
+		{nama: "SIM terlalu pendek", penumpangs: []Penumpang{{Nama: "P", JenisIdentitas: IdentitasSIM, NoIdentitas: "12345"}}, wantField: []string{"penumpangs[0].no_identitas"}},
+		{nama: "paspor berlaku", penumpangs: []Penumpang{paspor("A1234567", "2027-01-01")}},
+		{nama: "paspor berlaku sampai hari keberangkatan", penumpangs: []Penumpang{paspor("A1234567", "2026-06-15")}},
+		{nama: "paspor kedaluwarsa", penumpangs: []Penumpang{paspor("A1234567", "2026-06-14")}, wantField: []string{"penumpangs[0].paspor_berlaku_sampai"}},
+		{nama: "paspor tanpa masa berlaku", penumpangs: []Penumpang{paspor("A1234567", "")}, wantField: []string{"penumpangs[0].paspor_berlaku_sampai"}},
+		{nama: "paspor diawali angka", penumpangs: []Penumpang{paspor("12345678", "2027-01-01")}, wantField: []string{"penumpangs[0].no_identitas"}},
+		{nama: "jenis tidak dikenal", penumpangs: []Penumpang{{Nama: "P", JenisIdentitas: "kitas", NoIdentitas: "X123"}}, wantField: []string{"penumpangs[0].jenis_identitas"}},
+		{nama: "bayi tanpa identitas", penumpangs: []Penumpang{{Nama: "P", TanggalLahir: "2025-06-01"}}, wantLahir: "2025-06-01"},
+		{nama: "dewasa tanpa identitas", penumpangs: []Penumpang{{Nama: "P"}}, wantField: []string{"penumpangs[0].no_identitas"}},
+		{nama: "client lama tanpa jenis identitas", penumpangs: []Penumpang{{Nama: "P", NoIdentitas: "apa saja"}}},
+		{
+			nama:       "semua kesalahan dilaporkan",
+			penumpangs: []Penumpang{ktp("3171010101900001", ""), paspor("A1234567", "2026-01-01"), {Nama: "P"}},
+			wantField:  []string{"penumpangs[1].paspor_berlaku_sampai", "penumpangs[2].no_identitas"},
+			wantLahir:  "1990-01-01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			err := ValidasiDokumenPenumpang(tt.penumpangs, berangkat)
+			var got []string
+			if err != nil {
+				var ve *ValidasiDokumenError
+				if !errors.As(err, &ve) {
+					t.Fatalf("error %T, want *ValidasiDokumenError", err)
+				}
+				for _, k := range ve.Kesalahan {
+					got = append(got, k.Field)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantField) {
+				t.Errorf("field salah = %v, want %v (err: %v)", got, tt.wantField, err)
+			}
+			if l := tt.penumpangs[0].TanggalLahir; l != tt.wantLahir {
+				t.Errorf("tanggal_lahir = %q, want %q", l, tt.wantLahir)
+			}
+		})
+	}
+}
@@ -3,13 +3,29 @@ package models
 import "time"
 
 type Gerbong struct {
-	ID             uint      `gorm:"primaryKey" json:"id"`
-	KeretaID       uint      `json:"kereta_id"`
-	NomorGerbong   int       `json:"nomor_gerbong"`
-	Kelas          string    `json:"kelas"` // eksekutif, bisnis, ekonomi
-	KapasitasKursi int       `gorm:"default:64" json:"kapasitas_kursi"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	KeretaID       uint   `json:"kereta_id"`
+	NomorGerbong   int    `json:"nomor_gerbong"`
+	Kelas          string `json:"kelas"` // eksekutif, bisnis, ekonomi
+	KapasitasKursi int    `gorm:"default:64" json:"kapasitas_kursi"`
+
+	// TataLetakID kosong berarti memakai tata letak bawaan kelasnya
+	TataLetakID *uint             `json:"tata_letak_id"`
+	TataLetak   *TataLetakGerbong `gorm:"foreignKey:TataLetakID" json:"tata_letak,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 
 	Kursis []Kursi `gorm:"foreignKey:GerbongID" json:"kursis,omitempty"`
 }
+
+// KursiTanpaAtribut bernilai true bila ada kursi yang dibuat sebelum tata
+// letak gerbong tersedia.
+func (g *Gerbong) KursiTanpaAtribut() bool {
+	for i := range g.Kursis {
+		if g.Kursis[i].Baris == 0 {
+			return true
+		}
+	}
+	return false
+}
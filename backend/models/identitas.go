@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PenyandiIdentitas mengenkripsi nomor identitas sebelum disimpan dan
+// membuat blind index untuk pencarian tanpa mendekripsi.
+type PenyandiIdentitas interface {
+	Enkripsi(plain string) (string, error)
+	Dekripsi(sandi string) (string, error)
+	Tersandi(nilai string) bool
+	Indeks(plain string) string
+}
+
+var penyandiIdentitas PenyandiIdentitas
+
+// SetPenyandiIdentitas dipanggil sekali saat startup sebelum ada query ke
+// tabel yang menyimpan NoIdentitas.
+func SetPenyandiIdentitas(p PenyandiIdentitas) {
+	penyandiIdentitas = p
+}
+
+// Identitas adalah nomor identitas (NIK, SIM, paspor) yang otomatis
+// dienkripsi saat ditulis ke database dan didekripsi saat dibaca. Nilai
+// lama yang masih plaintext tetap terbaca apa adanya.
+type Identitas string
+
+func (i Identitas) Value() (driver.Value, error) {
+	if i == "" {
+		return "", nil
+	}
+	if penyandiIdentitas == nil {
+		return nil, errors.New("penyandi identitas belum diatur")
+	}
+	return penyandiIdentitas.Enkripsi(string(i))
+}
+
+func (i *Identitas) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*i = ""
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("tipe %T tidak bisa dibaca sebagai identitas", src)
+	}
+
+	if penyandiIdentitas == nil || !penyandiIdentitas.Tersandi(s) {
+		*i = Identitas(s)
+		return nil
+	}
+	plain, err := penyandiIdentitas.Dekripsi(s)
+	if err != nil {
+		return err
+	}
+	*i = Identitas(plain)
+	return nil
+}
+
+// NormalisasiIdentitas menyeragamkan penulisan nomor identitas supaya blind
+// index sama untuk "3171 0101-9000 0001" dan "3171010190000001".
+func NormalisasiIdentitas(no string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(no) {
+		if r == ' ' || r == '-' || r == '.' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// IndeksIdentitas menghasilkan blind index, atau string kosong bila nomor
+// kosong atau penyandi belum diatur.
+func IndeksIdentitas(no string) string {
+	no = NormalisasiIdentitas(no)
+	if no == "" || penyandiIdentitas == nil {
+		return ""
+	}
+	return penyandiIdentitas.Indeks(no)
+}
+
+// Samarkan hanya menampilkan empat karakter terakhir.
+func (i Identitas) Samarkan() Identitas {
+	r := []rune(string(i))
+	if len(r) <= 4 {
+		return Identitas(strings.Repeat("*", len(r)))
+	}
+	return Identitas(strings.Repeat("*", len(r)-4) + string(r[len(r)-4:]))
+}
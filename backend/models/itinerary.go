@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type ItineraryLeg struct {
+	JadwalID       uint      `json:"jadwal_id"`
+	KeretaID       uint      `json:"kereta_id"`
+	NamaKereta     string    `json:"nama_kereta"`
+	Kelas          string    `json:"kelas"`
+	NaikStasiunID  uint      `json:"naik_stasiun_id"`
+	Naik           Stasiun   `json:"naik"`
+	TurunStasiunID uint      `json:"turun_stasiun_id"`
+	Turun          Stasiun   `json:"turun"`
+	WaktuBerangkat time.Time `json:"waktu_berangkat"`
+	WaktuTiba      time.Time `json:"waktu_tiba"`
+	Harga          int64     `json:"harga"`
+}
+
+type Itinerary struct {
+	Legs           []ItineraryLeg `json:"legs"`
+	JumlahTransit  int            `json:"jumlah_transit"`
+	WaktuBerangkat time.Time      `json:"waktu_berangkat"`
+	WaktuTiba      time.Time      `json:"waktu_tiba"`
+	DurasiMenit    int            `json:"durasi_menit"`
+	TotalHarga     int64          `json:"total_harga"`
+}
@@ -1,20 +1,169 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 type Jadwal struct {
-	ID             uint      `gorm:"primaryKey" json:"id"`
-	KeretaID       uint      `json:"kereta_id"`
-	Kereta         Kereta    `gorm:"foreignKey:KeretaID" json:"kereta"`
-	AsalID         uint      `json:"asal_id"`
-	Asal           Stasiun   `gorm:"foreignKey:AsalID" json:"asal"`
-	TujuanID       uint      `json:"tujuan_id"`
-	Tujuan         Stasiun   `gorm:"foreignKey:TujuanID" json:"tujuan"`
-	WaktuBerangkat time.Time `json:"waktu_berangkat"`
-	WaktuTiba      time.Time `json:"waktu_tiba"`
-	Tanggal        string    `json:"tanggal"`
-	Kelas          string    `gorm:"size:32" json:"kelas"`
-	Harga          int64     `json:"harga_dasar"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	KeretaID       uint         `json:"kereta_id"`
+	Kereta         Kereta       `gorm:"foreignKey:KeretaID" json:"kereta"`
+	AsalID         uint         `json:"asal_id"`
+	Asal           Stasiun      `gorm:"foreignKey:AsalID" json:"asal"`
+	TujuanID       uint         `json:"tujuan_id"`
+	Tujuan         Stasiun      `gorm:"foreignKey:TujuanID" json:"tujuan"`
+	WaktuBerangkat time.Time    `json:"waktu_berangkat"`
+	WaktuTiba      time.Time    `json:"waktu_tiba"`
+	Tanggal        string       `json:"tanggal"`
+	Kelas          string       `gorm:"size:32" json:"kelas"`
+	Harga          int64        `json:"harga_dasar"`
+	Stops          []JadwalStop `gorm:"foreignKey:JadwalID" json:"stops,omitempty"`
+	TemplateID     *uint        `gorm:"index" json:"template_id,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+
+	// diisi saat pencarian per pasangan stasiun naik/turun
+	Naik        *JadwalStop `gorm:"-" json:"naik,omitempty"`
+	Turun       *JadwalStop `gorm:"-" json:"turun,omitempty"`
+	HargaSegmen int64       `gorm:"-" json:"harga_segmen,omitempty"`
+
+	// harga setelah harga dinamis, diisi oleh handler pencarian
+	HargaSaatIni int64 `gorm:"-" json:"harga_saat_ini,omitempty"`
+}
+
+var ErrSegmenTidakValid = errors.New("stasiun naik/turun tidak ada pada rute jadwal ini")
+
+// StopDi mengembalikan stop pertama pada rute yang berhenti di stasiun tersebut.
+func (j *Jadwal) StopDi(stasiunID uint) *JadwalStop {
+	for i := range j.Stops {
+		if j.Stops[i].StasiunID == stasiunID {
+			return &j.Stops[i]
+		}
+	}
+	return nil
+}
+
+// Segmen mengubah pasangan stasiun naik/turun menjadi urutan stop. ID 0 berarti
+// stasiun awal (untuk naik) atau stasiun akhir (untuk turun) dari rute.
+func (j *Jadwal) Segmen(naikID, turunID uint) (*JadwalStop, *JadwalStop, error) {
+	if len(j.Stops) < 2 {
+		return nil, nil, ErrSegmenTidakValid
+	}
+
+	naik := &j.Stops[0]
+	if naikID != 0 {
+		naik = j.StopDi(naikID)
+	}
+	if naik == nil {
+		return nil, nil, ErrSegmenTidakValid
+	}
+
+	var turun *JadwalStop
+	if turunID == 0 {
+		turun = &j.Stops[len(j.Stops)-1]
+	} else {
+		for i := range j.Stops {
+			if j.Stops[i].StasiunID == turunID && j.Stops[i].Urutan > naik.Urutan {
+				turun = &j.Stops[i]
+				break
+			}
+		}
+	}
+	if turun == nil || turun.Urutan <= naik.Urutan {
+		return nil, nil, ErrSegmenTidakValid
+	}
+	return naik, turun, nil
+}
+
+// HargaAntara menghitung harga perjalanan antara dua stop. Rute baru sudah
+// lolos ValidasiStops sehingga selisihnya selalu positif; harga dasar jadwal
+// hanya dipakai untuk data lama yang harga kumulatif per stopnya belum diisi.
+func (j *Jadwal) HargaAntara(naik, turun *JadwalStop) int64 {
+	if naik == nil || turun == nil {
+		return j.Harga
+	}
+	if h := turun.HargaKumulatif - naik.HargaKumulatif; h > 0 {
+		return h
+	}
+	return j.Harga
+}
+
+// ValidasiStops memeriksa rute sebelum disimpan: harga kumulatif dimulai dari
+// 0 dan naik di setiap stop, stop antara punya waktu, waktu tidak pernah
+// mundur, dan ujung rute sama dengan WaktuBerangkat/WaktuTiba jadwal.
+func (j *Jadwal) ValidasiStops() error {
+	if len(j.Stops) == 0 {
+		if !j.WaktuTiba.After(j.WaktuBerangkat) {
+			return errors.New("waktu_tiba harus setelah waktu_berangkat")
+		}
+		return nil
+	}
+	if len(j.Stops) < 2 {
+		return errors.New("stops minimal berisi stasiun asal dan tujuan")
+	}
+
+	akhir := len(j.Stops) - 1
+	awal := &j.Stops[0]
+	if awal.HargaKumulatif != 0 {
+		return errors.New("stops[0].harga_kumulatif harus 0")
+	}
+	if awal.WaktuBerangkat == nil || !awal.WaktuBerangkat.Equal(j.WaktuBerangkat) {
+		return errors.New("stops[0].waktu_berangkat harus sama dengan waktu_berangkat jadwal")
+	}
+	if j.Stops[akhir].WaktuTiba == nil || !j.Stops[akhir].WaktuTiba.Equal(j.WaktuTiba) {
+		return fmt.Errorf("stops[%d].waktu_tiba harus sama dengan waktu_tiba jadwal", akhir)
+	}
+
+	sebelum := j.WaktuBerangkat
+	for i := 1; i <= akhir; i++ {
+		s := &j.Stops[i]
+		if s.HargaKumulatif <= j.Stops[i-1].HargaKumulatif {
+			return fmt.Errorf("stops[%d].harga_kumulatif harus lebih besar dari stop sebelumnya", i)
+		}
+		if s.WaktuTiba == nil && s.WaktuBerangkat == nil {
+			return fmt.Errorf("stops[%d] wajib punya waktu_tiba atau waktu_berangkat", i)
+		}
+		tiba := j.TibaDi(s)
+		if tiba.Before(sebelum) {
+			return fmt.Errorf("stops[%d] tiba sebelum stop sebelumnya berangkat", i)
+		}
+		sebelum = j.BerangkatDari(s)
+		if sebelum.Before(tiba) {
+			return fmt.Errorf("stops[%d] berangkat sebelum tiba", i)
+		}
+	}
+	return nil
+}
+
+// JumlahSegmen adalah banyaknya ruas antar stop berurutan pada rute.
+func (j *Jadwal) JumlahSegmen() int {
+	if len(j.Stops) < 2 {
+		return 1
+	}
+	return len(j.Stops) - 1
+}
+
+// BerangkatDari mengembalikan waktu kereta meninggalkan stop. Stop tanpa waktu
+// berangkat (mis. data lama) memakai waktu tiba atau waktu berangkat jadwal.
+func (j *Jadwal) BerangkatDari(s *JadwalStop) time.Time {
+	if s.WaktuBerangkat != nil {
+		return *s.WaktuBerangkat
+	}
+	if s.WaktuTiba != nil {
+		return *s.WaktuTiba
+	}
+	return j.WaktuBerangkat
+}
+
+// TibaDi mengembalikan waktu kereta sampai di stop.
+func (j *Jadwal) TibaDi(s *JadwalStop) time.Time {
+	if s.WaktuTiba != nil {
+		return *s.WaktuTiba
+	}
+	if s.WaktuBerangkat != nil {
+		return *s.WaktuBerangkat
+	}
+	return j.WaktuTiba
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type JadwalStop struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	JadwalID       uint       `gorm:"index:idx_jadwal_urutan,unique" json:"jadwal_id"`
+	Urutan         int        `gorm:"index:idx_jadwal_urutan,unique" json:"urutan"`
+	StasiunID      uint       `gorm:"index" json:"stasiun_id"`
+	Stasiun        Stasiun    `gorm:"foreignKey:StasiunID" json:"stasiun"`
+	WaktuTiba      *time.Time `json:"waktu_tiba"`
+	WaktuBerangkat *time.Time `json:"waktu_berangkat"`
+	HargaKumulatif int64      `json:"harga_kumulatif"` // harga dari stasiun awal sampai stop ini
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidasiStops(t *testing.T) {
+	berangkat := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	jam := func(h float64) *time.Time {
+		w := berangkat.Add(time.Duration(h * float64(time.Hour)))
+		return &w
+	}
+	tests := []struct {
+		nama    string
+		stops   []JadwalStop
+		wantErr string
+	}{
+		{
+			nama: "rute valid",
+			stops: []JadwalStop{
+				{WaktuBerangkat: jam(0)},
+				{WaktuTiba: jam(2), WaktuBerangkat: jam(2.1), HargaKumulatif: 80000},
+				{WaktuTiba: jam(4), HargaKumulatif: 150000},
+			},
+		},
+		{
+			nama: "harga stop pertama bukan 0",
+			stops: []JadwalStop{
+				{WaktuBerangkat: jam(0), HargaKumulatif: 10000},
+				{WaktuTiba: jam(4), HargaKumulatif: 150000},
+			},
+			wantErr: "stops[0].harga_kumulatif",
+		},
+		{
+			nama: "harga stop antara 0",
+			stops: []JadwalStop{
+				{WaktuBerangkat: jam(0)},
+				{WaktuTiba: jam(2), WaktuBerangkat: jam(2.1)},
+				{WaktuTiba: jam(4), HargaKumulatif: 150000},
+			},
+			wantErr: "stops[1].harga_kumulatif",
+		},
+		{
+			nama: "harga turun",
+			stops: []JadwalStop{
+				{WaktuBerangkat: jam(0)},
+				{WaktuTiba: jam(2), WaktuBerangkat: jam(2.1), HargaKumulatif: 160000},
+				{WaktuTiba: jam(4), HargaKumulatif: 150000},
+			},
+			wantErr: "stops[2].harga_kumulatif",
+		},
+		{
+			nama: "stop pertama tidak sama dengan jadwal",
+			stops: []JadwalStop{
+				{WaktuBerangkat: jam(0.5)},
+				{WaktuTiba: jam(4), HargaKumulatif: 150000},
+			},
+			wantErr: "stops[0].waktu_berangkat",
+		},
+		{
+			nama: "stop terakhir tidak sama dengan jadwal",
+			stops: []JadwalStop{
+				{WaktuBerangkat: jam(0)},
+				{WaktuTiba: jam(5), HargaKumulatif: 150000},
+			},
+			wantErr: "stops[1].waktu_tiba",
+		},
+		{
+			nama: "stop antara tanpa waktu",
+			stops: []JadwalStop{
+				{WaktuBerangkat: jam(0)},
+				{HargaKumulatif: 80000},
+				{WaktuTiba: jam(4), HargaKumulatif: 150000},
+			},
+			wantErr: "stops[1] wajib punya",
+		},
+		{
+			nama: "stop antara di luar rentang jadwal",
+			stops: []JadwalStop{
+				{WaktuBerangkat: jam(0)},
+				{WaktuTiba: jam(5), WaktuBerangkat: jam(5.1), HargaKumulatif: 80000},
+				{WaktuTiba: jam(4), HargaKumulatif: 150000},
+			},
+			wantErr: "stops[2] tiba sebelum",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			j := &Jadwal{WaktuBerangkat: berangkat, WaktuTiba: *jam(4), Stops: tt.stops}
+			err := j.ValidasiStops()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidasiStops = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidasiStops = %v, want error berisi %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// RingkasanJadwal adalah hasil agregasi satu jadwal untuk pasangan stasiun
+// naik/turun, dipakai untuk kalender harga.
+type RingkasanJadwal struct {
+	JadwalID       uint
+	Tanggal        string
+	Kelas          string
+	WaktuBerangkat time.Time
+	Harga          int64
+	HargaNaik      int64
+	HargaTurun     int64
+	TotalBaris     int64 // semua baris ketersediaan jadwal (semua segmen)
+	BarisTerisi    int64
+	KursiSegmen    int64 // kursi yang punya baris di segmen perjalanan
+	KursiTerpakai  int64 // kursi yang minimal satu segmennya terisi
+}
+
+type HargaHarian struct {
+	Tanggal       string `json:"tanggal"`
+	Kelas         string `json:"kelas"`
+	HargaTerendah int64  `json:"harga_terendah"`
+	SisaKursi     int64  `json:"sisa_kursi"`
+	JumlahJadwal  int    `json:"jumlah_jadwal"`
+}
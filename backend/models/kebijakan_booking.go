@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// KebijakanBooking adalah aturan booking yang bisa diubah admin saat
+// runtime. Hanya ada satu baris (ID 1); bila belum ada, nilai bawaan
+// dipakai.
+type KebijakanBooking struct {
+	ID uint `gorm:"primaryKey" json:"-"`
+
+	// CegahIdentitasGanda menolak penumpang yang nomor identitasnya sudah
+	// ada di booking pending/paid lain dengan waktu perjalanan bertabrakan.
+	// JedaIdentitasMenit memperlebar jendela waktu di kedua sisi.
+	CegahIdentitasGanda bool `json:"cegah_identitas_ganda"`
+	JedaIdentitasMenit  int  `json:"jeda_identitas_menit"`
+
+	// Batas booking per user; 0 berarti tanpa batas. Hold aktif adalah
+	// booking pending milik user.
+	MaksPenumpangPerBooking int `json:"maks_penumpang_per_booking"`
+	MaksHoldAktifPerUser    int `json:"maks_hold_aktif_per_user"`
+	MaksKursiPerJadwal      int `json:"maks_kursi_per_jadwal"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const IDKebijakanBooking = 1
+
+func KebijakanBookingBawaan() KebijakanBooking {
+	return KebijakanBooking{
+		ID:                  IDKebijakanBooking,
+		CegahIdentitasGanda: true,
+
+		MaksPenumpangPerBooking: 4,
+		MaksHoldAktifPerUser:    3,
+		MaksKursiPerJadwal:      8,
+	}
+}
@@ -2,12 +2,29 @@ package models
 
 import "time"
 
+// KetersediaanKursi menyimpan status satu kursi pada satu segmen rute jadwal.
+// Segmen n adalah ruas dari stop dengan urutan n ke stop urutan n+1.
 type KetersediaanKursi struct {
 	ID                uint   `gorm:"primaryKey"`
-	TrainScheduleID   uint   `gorm:"index:idx_schedule_seat,unique"`
-	SeatID            uint   `gorm:"index:idx_schedule_seat,unique"`
+	TrainScheduleID   uint   `gorm:"index:idx_schedule_seat_segmen,unique"`
+	SeatID            uint   `gorm:"index:idx_schedule_seat_segmen,unique"`
+	Segmen            int    `gorm:"index:idx_schedule_seat_segmen,unique;default:0"`
 	Status            string `gorm:"type:enum('available','reserved','booked');default:'available'"`
 	ReservedByBooking uint   `gorm:"default:0"`
 	ReservedUntil     *time.Time
 	UpdatedAt         time.Time
 }
+
+// GabungStatusKursi meringkas status per kursi dari beberapa segmen. Kursi
+// dianggap terisi jika salah satu segmennya sudah booked/reserved.
+func GabungStatusKursi(rows []KetersediaanKursi) map[uint]string {
+	prioritas := map[string]int{"available": 0, "reserved": 1, "booked": 2}
+	out := make(map[uint]string)
+	for _, r := range rows {
+		cur, ok := out[r.SeatID]
+		if !ok || prioritas[r.Status] > prioritas[cur] {
+			out[r.SeatID] = r.Status
+		}
+	}
+	return out
+}
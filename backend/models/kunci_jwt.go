@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// KunciJWT adalah kunci asimetris untuk menandatangani access token. Hanya
+// satu kunci yang aktif untuk menandatangani; kunci lain yang belum pensiun
+// tetap dipakai untuk verifikasi sehingga rotasi tidak membuat user logout.
+type KunciJWT struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Kid         string     `gorm:"size:32;uniqueIndex" json:"kid"`
+	Alg         string     `gorm:"size:10" json:"alg"` // RS256 atau EdDSA
+	PrivatePEM  string     `gorm:"type:text" json:"-"` // terenkripsi, lihat services.PengelolaKunci
+	Aktif       bool       `json:"aktif"`
+	PensiunPada *time.Time `json:"pensiun_pada"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
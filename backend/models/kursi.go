@@ -1,12 +1,47 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Kursi struct {
-	ID         uint      `gorm:"primaryKey" json:"id"`
-	GerbongID  uint      `json:"gerbong_id"`
-	Gerbong    Gerbong   `gorm:"foreignKey:GerbongID" json:"gerbong"`
-	NomorKursi string    `gorm:"size:10;index" json:"nomor_kursi"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	GerbongID  uint    `json:"gerbong_id"`
+	Gerbong    Gerbong `gorm:"foreignKey:GerbongID" json:"gerbong"`
+	NomorKursi string  `gorm:"size:10;index" json:"nomor_kursi"`
+
+	// Atribut dari tata letak gerbong saat kursi dibuat
+	Baris     int    `json:"baris"`
+	Kolom     string `gorm:"size:2" json:"kolom"`
+	Jendela   bool   `json:"jendela"`
+	Lorong    bool   `json:"lorong"`
+	Prioritas bool   `json:"prioritas"`
+	KursiRoda bool   `json:"kursi_roda"`
+	Arah      string `gorm:"size:10" json:"arah"` // maju, mundur
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Posisi memisahkan nomor kursi menjadi baris dan kolom, misalnya "12C"
+// menjadi 12 dan "C". Baris 0 berarti nomor tidak mengikuti format tersebut.
+func (k *Kursi) Posisi() (int, string) {
+	i := strings.IndexFunc(k.NomorKursi, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return 0, k.NomorKursi
+	}
+	baris, _ := strconv.Atoi(k.NomorKursi[:i])
+	return baris, k.NomorKursi[i:]
+}
+
+// LengkapiAtribut mengisi atribut kursi lama yang dibuat sebelum ada tata
+// letak gerbong, dengan anggapan susunan standar 2-2.
+func (k *Kursi) LengkapiAtribut() {
+	if k.Baris > 0 {
+		return
+	}
+	k.Baris, k.Kolom = k.Posisi()
+	TataLetakStandar.Terapkan(k)
 }
@@ -1,15 +1,130 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TipeDewasa = "dewasa"
+	TipeAnak   = "anak"
+	TipeBayi   = "bayi"
+	TipeLansia = "lansia"
+
+	UsiaMaksBayi  = 3  // di bawah 3 tahun, tidak mendapat kursi
+	UsiaMaksAnak  = 12 // 3 s.d. 11 tahun
+	UsiaMinLansia = 60
+)
 
 type Penumpang struct {
-	ID          uint   `gorm:"primaryKey"`
-	BookingID   uint   `gorm:"index"`
-	Nama        string `json:"nama"`
-	NoIdentitas string `json:"no_identitas"` // KTP, SIM, Passport
-	SeatID      uint   `json:"seat_id"`
-	Kursi       Kursi  `gorm:"foreignKey:SeatID" json:"kursi"`
-	NoTiket     string `json:"no_tiket"`
-	QRPath      string `json:"qr_path"`
-	CreatedAt   time.Time
+	ID           uint      `gorm:"primaryKey"`
+	BookingID    uint      `gorm:"index"`
+	BookingLegID uint      `gorm:"index" json:"booking_leg_id"`
+	Nama         string    `json:"nama"`
+	NoIdentitas  Identitas `json:"no_identitas"` // KTP, SIM, Passport; terenkripsi
+	Tipe         string    `gorm:"size:10;default:'dewasa'" json:"tipe"`
+	TanggalLahir string    `gorm:"size:10" json:"tanggal_lahir"` // YYYY-MM-DD
+	SeatID       *uint     `json:"seat_id"`                      // NULL untuk bayi yang dipangku
+	Kursi        Kursi     `gorm:"foreignKey:SeatID" json:"kursi"`
+	NoTiket      string    `json:"no_tiket"`
+	QRPath       string    `json:"qr_path"`
+	CreatedAt    time.Time
+
+	JenisIdentitas      string `gorm:"size:10" json:"jenis_identitas"`
+	PasporBerlakuSampai string `gorm:"size:10" json:"paspor_berlaku_sampai,omitempty"` // YYYY-MM-DD
+
+	// PenumpangTersimpanID diisi client untuk memakai data dari buku
+	// penumpang; nama, identitas, dan tanggal lahir disalin saat booking.
+	PenumpangTersimpanID *uint `gorm:"index" json:"penumpang_tersimpan_id,omitempty"`
+
+	// NoIdentitasIdx adalah blind index NoIdentitas untuk pencarian
+	NoIdentitasIdx string `gorm:"size:64;index" json:"-"`
+}
+
+func (p *Penumpang) BeforeSave(tx *gorm.DB) error {
+	p.NoIdentitasIdx = IndeksIdentitas(string(p.NoIdentitas))
+	return nil
+}
+
+// Berkursi bernilai false untuk bayi yang dipangku pendamping.
+func (p *Penumpang) Berkursi() bool {
+	return p.Tipe != TipeBayi
+}
+
+func usiaPada(lahir, pada time.Time) int {
+	usia := pada.Year() - lahir.Year()
+	if pada.Month() < lahir.Month() || (pada.Month() == lahir.Month() && pada.Day() < lahir.Day()) {
+		usia--
+	}
+	return usia
+}
+
+func tipeDariUsia(usia int) string {
+	switch {
+	case usia < UsiaMaksBayi:
+		return TipeBayi
+	case usia < UsiaMaksAnak:
+		return TipeAnak
+	case usia >= UsiaMinLansia:
+		return TipeLansia
+	}
+	return TipeDewasa
+}
+
+// NormalisasiPenumpang menentukan tipe setiap penumpang dari tanggal lahir
+// pada tanggal keberangkatan dan memeriksa aturan komposisi: selain dewasa
+// wajib mengisi tanggal lahir, dan setiap bayi harus didampingi satu
+// penumpang dewasa/lansia. Slice diubah di tempat.
+func NormalisasiPenumpang(list []Penumpang, berangkat time.Time) error {
+	var bayi, pendamping int
+	for i := range list {
+		p := &list[i]
+		p.Tipe = strings.ToLower(strings.TrimSpace(p.Tipe))
+
+		if p.TanggalLahir == "" {
+			if p.Tipe != "" && p.Tipe != TipeDewasa {
+				return fmt.Errorf("penumpang %d: tanggal_lahir wajib diisi untuk tipe %s", i+1, p.Tipe)
+			}
+			p.Tipe = TipeDewasa
+		} else {
+			lahir, err := time.Parse("2006-01-02", p.TanggalLahir)
+			if err != nil {
+				return fmt.Errorf("penumpang %d: format tanggal_lahir harus YYYY-MM-DD", i+1)
+			}
+			if lahir.After(berangkat) {
+				return fmt.Errorf("penumpang %d: tanggal_lahir setelah tanggal keberangkatan", i+1)
+			}
+			tipe := tipeDariUsia(usiaPada(lahir, berangkat))
+			if p.Tipe != "" && p.Tipe != tipe {
+				return fmt.Errorf("penumpang %d: tipe %s tidak sesuai dengan usia (%s)", i+1, p.Tipe, tipe)
+			}
+			p.Tipe = tipe
+		}
+
+		switch p.Tipe {
+		case TipeBayi:
+			bayi++
+		case TipeDewasa, TipeLansia:
+			pendamping++
+		}
+	}
+
+	if bayi > pendamping {
+		return fmt.Errorf("setiap bayi harus didampingi satu penumpang dewasa")
+	}
+	return nil
+}
+
+// JumlahBerkursi menghitung penumpang yang membutuhkan kursi.
+func JumlahBerkursi(list []Penumpang) int {
+	n := 0
+	for i := range list {
+		if list[i].Berkursi() {
+			n++
+		}
+	}
+	return n
 }
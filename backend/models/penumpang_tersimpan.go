@@ -0,0 +1,102 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	IdentitasKTP    = "ktp"
+	IdentitasSIM    = "sim"
+	IdentitasPaspor = "paspor"
+)
+
+func JenisIdentitasValid(jenis string) bool {
+	switch jenis {
+	case IdentitasKTP, IdentitasSIM, IdentitasPaspor:
+		return true
+	}
+	return false
+}
+
+// PenumpangTersimpan adalah buku alamat penumpang milik user. Saat booking,
+// data ini disalin ke Penumpang sehingga perubahan berikutnya tidak
+// memengaruhi booking lama.
+type PenumpangTersimpan struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	UserID         uint      `gorm:"index" json:"user_id"`
+	Nama           string    `gorm:"size:100" json:"nama"`
+	JenisIdentitas string    `gorm:"size:10" json:"jenis_identitas"`
+	NoIdentitas    Identitas `gorm:"size:255" json:"no_identitas"`
+	NoIdentitasIdx string    `gorm:"size:64;index" json:"-"`
+	TanggalLahir   string    `gorm:"size:10" json:"tanggal_lahir"` // YYYY-MM-DD
+
+	PasporBerlakuSampai string    `gorm:"size:10" json:"paspor_berlaku_sampai,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// Normalisasi merapikan isian lalu memeriksa kelengkapannya.
+func (p *PenumpangTersimpan) Normalisasi() error {
+	p.Nama = strings.TrimSpace(p.Nama)
+	p.JenisIdentitas = strings.ToLower(strings.TrimSpace(p.JenisIdentitas))
+	p.NoIdentitas = Identitas(NormalisasiIdentitas(string(p.NoIdentitas)))
+	p.TanggalLahir = strings.TrimSpace(p.TanggalLahir)
+
+	if p.Nama == "" {
+		return errors.New("nama wajib diisi")
+	}
+	if !JenisIdentitasValid(p.JenisIdentitas) {
+		return errors.New("jenis_identitas harus ktp, sim, atau paspor")
+	}
+	if p.NoIdentitas == "" {
+		return errors.New("no_identitas wajib diisi")
+	}
+	if p.TanggalLahir != "" {
+		lahir, err := time.Parse("2006-01-02", p.TanggalLahir)
+		if err != nil {
+			return errors.New("format tanggal_lahir harus YYYY-MM-DD")
+		}
+		if lahir.After(time.Now()) {
+			return errors.New("tanggal_lahir tidak boleh di masa depan")
+		}
+	}
+
+	// masa berlaku paspor baru diperiksa saat booking terhadap tanggal
+	// keberangkatan
+	no := string(p.NoIdentitas)
+	switch p.JenisIdentitas {
+	case IdentitasKTP:
+		lahir, err := TanggalLahirNIK(no, time.Now())
+		if err != nil {
+			return err
+		}
+		if p.TanggalLahir == "" {
+			p.TanggalLahir = lahir.Format("2006-01-02")
+		} else if p.TanggalLahir != lahir.Format("2006-01-02") {
+			return errors.New("tanggal_lahir tidak sesuai dengan NIK")
+		}
+	case IdentitasSIM:
+		if pesan := validasiSIM(no); pesan != "" {
+			return errors.New(pesan)
+		}
+	case IdentitasPaspor:
+		if pesan := validasiPaspor(no); pesan != "" {
+			return errors.New(pesan)
+		}
+		if p.PasporBerlakuSampai != "" {
+			if _, err := time.Parse("2006-01-02", p.PasporBerlakuSampai); err != nil {
+				return errors.New("format paspor_berlaku_sampai harus YYYY-MM-DD")
+			}
+		}
+	}
+	return nil
+}
+
+func (p *PenumpangTersimpan) BeforeSave(tx *gorm.DB) error {
+	p.NoIdentitasIdx = IndeksIdentitas(string(p.NoIdentitas))
+	return nil
+}
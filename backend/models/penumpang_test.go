@@ -0,0 +1,65 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalisasiPenumpang(t *testing.T) {
+	berangkat := time.Date(2026, 6, 15, 8, 0, 0, 0, time.UTC)
+	dewasa := Penumpang{Nama: "Budi"}
+	lahir := func(tgl string) Penumpang { return Penumpang{Nama: "P", TanggalLahir: tgl} }
+
+	tests := []struct {
+		nama       string
+		penumpangs []Penumpang
+		want       []string
+		wantErr    bool
+	}{
+		{nama: "tanpa tanggal lahir dianggap dewasa", penumpangs: []Penumpang{dewasa}, want: []string{TipeDewasa}},
+		{nama: "tipe ditulis bebas", penumpangs: []Penumpang{{Nama: "Budi", Tipe: " Dewasa "}}, want: []string{TipeDewasa}},
+		{nama: "bayi dengan pendamping", penumpangs: []Penumpang{dewasa, lahir("2025-06-16")}, want: []string{TipeDewasa, TipeBayi}},
+		{nama: "sehari sebelum ulang tahun ke-3 masih bayi", penumpangs: []Penumpang{dewasa, lahir("2023-06-16")}, want: []string{TipeDewasa, TipeBayi}},
+		{nama: "tepat 3 tahun menjadi anak", penumpangs: []Penumpang{lahir("2023-06-15")}, want: []string{TipeAnak}},
+		{nama: "11 tahun masih anak", penumpangs: []Penumpang{lahir("2014-06-16")}, want: []string{TipeAnak}},
+		{nama: "tepat 12 tahun menjadi dewasa", penumpangs: []Penumpang{lahir("2014-06-15")}, want: []string{TipeDewasa}},
+		{nama: "tepat 60 tahun menjadi lansia", penumpangs: []Penumpang{lahir("1966-06-15")}, want: []string{TipeLansia}},
+		{nama: "lansia boleh mendampingi bayi", penumpangs: []Penumpang{lahir("1950-01-01"), lahir("2026-01-01")}, want: []string{TipeLansia, TipeBayi}},
+		{nama: "dua bayi satu dewasa", penumpangs: []Penumpang{dewasa, lahir("2025-01-01"), lahir("2025-02-01")}, wantErr: true},
+		{nama: "bayi hanya didampingi anak", penumpangs: []Penumpang{lahir("2018-01-01"), lahir("2025-01-01")}, wantErr: true},
+		{nama: "bayi sendirian", penumpangs: []Penumpang{lahir("2025-01-01")}, wantErr: true},
+		{nama: "tipe tidak sesuai usia", penumpangs: []Penumpang{{Nama: "P", Tipe: TipeAnak, TanggalLahir: "1990-01-01"}}, wantErr: true},
+		{nama: "anak tanpa tanggal lahir", penumpangs: []Penumpang{{Nama: "P", Tipe: TipeAnak}}, wantErr: true},
+		{nama: "lahir setelah keberangkatan", penumpangs: []Penumpang{dewasa, lahir("2026-07-01")}, wantErr: true},
+		{nama: "format tanggal lahir salah", penumpangs: []Penumpang{lahir("15-06-1990")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			err := NormalisasiPenumpang(tt.penumpangs, berangkat)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NormalisasiPenumpang tidak mengembalikan error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range tt.penumpangs {
+				got = append(got, p.Tipe)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tipe = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJumlahBerkursi(t *testing.T) {
+	list := []Penumpang{{Tipe: TipeDewasa}, {Tipe: TipeBayi}, {Tipe: TipeAnak}, {Tipe: TipeLansia}}
+	if n := JumlahBerkursi(list); n != 3 {
+		t.Errorf("JumlahBerkursi = %d, want 3 (bayi dipangku)", n)
+	}
+}
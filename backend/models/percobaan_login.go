@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	AlasanLoginBerhasil      = "berhasil"
+	AlasanLoginPasswordSalah = "password_salah"
+	AlasanLoginEmailTakAda   = "email_tidak_dikenal"
+	AlasanLoginDiblokir      = "diblokir"
+)
+
+// PercobaanLogin adalah audit setiap percobaan login, sekaligus sumber
+// hitungan gagal untuk pembatasan per akun dan per IP.
+type PercobaanLogin struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Email     string    `gorm:"size:150;index" json:"email"`
+	UserID    *uint     `gorm:"index" json:"user_id"`
+	IP        string    `gorm:"size:45;index" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	Berhasil  bool      `json:"berhasil"`
+	Alasan    string    `gorm:"size:30" json:"alasan"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// RefreshToken disimpan dalam bentuk hash. Setiap kali dipakai token dirotasi:
+// baris lama ditandai terpakai dan token baru dibuat dengan FamilyID yang sama.
+// Token terpakai yang dikirim ulang dianggap bocor sehingga seluruh family
+// dicabut.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index" json:"user_id"`
+	FamilyID  string     `gorm:"size:36;index" json:"family_id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TokenAuth adalah pasangan token yang dikembalikan saat login/refresh.
+type TokenAuth struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
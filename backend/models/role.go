@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	RolePassenger    = "passenger"
+	RoleOperator     = "operator"
+	RoleAdmin        = "admin"
+	RoleStaffStasiun = "station_staff"
+)
+
+// Izin dipakai per route oleh middlewares.RequirePermission.
+const (
+	PermKelolaMaster = "master:write" // stasiun, kereta, gerbong
+	PermKelolaJadwal = "jadwal:write" // jadwal, template, impor/ekspor
+	PermKelolaTarif  = "tarif:write"  // layanan, voucher, aturan harga
+	PermKelolaRole   = "role:write"   // grant/revoke role user
+	PermLihatBooking = "booking:read" // melihat booking milik user lain
+	PermKelolaKunci  = "kunci:write"  // rotasi kunci JWT
+	PermLihatAudit   = "audit:read"   // riwayat percobaan login
+
+	PermKelolaKebijakan = "kebijakan:write" // aturan booking
+)
+
+var izinRole = map[string][]string{
+	RolePassenger:    {},
+	RoleStaffStasiun: {PermLihatBooking},
+	RoleOperator:     {PermKelolaMaster, PermKelolaJadwal, PermLihatBooking},
+	RoleAdmin:        {PermKelolaMaster, PermKelolaJadwal, PermKelolaTarif, PermKelolaRole, PermLihatBooking, PermKelolaKunci, PermLihatAudit, PermKelolaKebijakan},
+}
+
+// UserRole menyimpan role tambahan milik user. Setiap user selalu dianggap
+// punya role passenger tanpa perlu baris di tabel ini.
+type UserRole struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_user_role" json:"user_id"`
+	Role      string    `gorm:"size:20;uniqueIndex:idx_user_role" json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func RoleValid(role string) bool {
+	_, ok := izinRole[role]
+	return ok
+}
+
+// PunyaIzin mengecek apakah salah satu role memberikan izin tersebut.
+func PunyaIzin(roles []string, izin string) bool {
+	for _, r := range roles {
+		for _, p := range izinRole[r] {
+			if p == izin {
+				return true
+			}
+		}
+	}
+	return false
+}
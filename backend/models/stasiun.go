@@ -3,10 +3,13 @@ package models
 import "time"
 
 type Stasiun struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Kode      string    `gorm:"uniqueIndex;size:10" json:"kode"`
-	Nama      string    `json:"nama"`
-	Kota      string    `json:"kota"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	Kode             string    `gorm:"uniqueIndex;size:10" json:"kode"`
+	Nama             string    `json:"nama"`
+	Kota             string    `json:"kota"`
+	Latitude         *float64  `json:"latitude"`
+	Longitude        *float64  `json:"longitude"`
+	MinTransferMenit int       `gorm:"default:0" json:"min_transfer_menit"` // 0 = pakai default dari config
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// BookingItem adalah satu baris rincian harga. Baris yang sama dipakai untuk
+// quote dan untuk item_details Midtrans saat pembayaran.
+type BookingItem struct {
+	ID          uint      `gorm:"primaryKey" json:"id,omitempty"`
+	BookingID   uint      `gorm:"index" json:"booking_id,omitempty"`
+	Kode        string    `gorm:"size:50" json:"kode"`
+	Nama        string    `gorm:"size:100" json:"nama"`
+	Jumlah      int       `json:"jumlah"`
+	HargaSatuan int64     `json:"harga_satuan"`
+	Subtotal    int64     `json:"subtotal"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+type Quote struct {
+	Items []BookingItem `json:"items"`
+	Total int64         `json:"total"`
+}
+
+func (q *Quote) Tambah(item BookingItem) {
+	item.Subtotal = item.HargaSatuan * int64(item.Jumlah)
+	q.Items = append(q.Items, item)
+	q.Total += item.Subtotal
+}
+
+type LayananTambahan struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Kode         string    `gorm:"uniqueIndex;size:30" json:"kode"`
+	Nama         string    `gorm:"size:100" json:"nama"`
+	Harga        int64     `json:"harga"`
+	PerPenumpang bool      `json:"per_penumpang"` // jumlah default = jumlah penumpang
+	Aktif        bool      `gorm:"default:true" json:"aktif"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,248 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ArahMaju   = "maju"
+	ArahMundur = "mundur"
+)
+
+// TataLetakGerbong adalah template susunan kursi satu jenis gerbong. Kursi
+// diberi nomor baris mulai 1 dan huruf kolom sesuai Kolom; baris yang
+// dilewati tidak dipakai sama sekali (misalnya baris 13).
+//
+// Daftar baris ditulis "1,3,5-8", atau "ganjil"/"genap". Daftar kursi
+// boleh berisi nomor kursi ("1A") maupun baris ("1" berarti semua kursi di
+// baris 1).
+type TataLetakGerbong struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Kode string `gorm:"size:32;uniqueIndex" json:"kode"`
+	Nama string `gorm:"size:100" json:"nama"`
+
+	// Kelas menjadikan template ini bawaan untuk gerbong kelas tersebut
+	// yang tidak memilih tata letak sendiri.
+	Kelas string `gorm:"size:32;index" json:"kelas"`
+
+	// Kolom dari kiri ke kanan dengan "|" sebagai lorong, misalnya "AB|CD"
+	// (2-2) atau "AB|CDE" (2-3).
+	Kolom         string `gorm:"size:20" json:"kolom"`
+	BarisDilewati string `gorm:"size:100" json:"baris_dilewati"`
+
+	// BarisMundur berisi baris yang menghadap berlawanan arah perjalanan,
+	// dipakai untuk kursi hadap-hadapan.
+	BarisMundur    string `gorm:"size:100" json:"baris_mundur"`
+	KursiPrioritas string `gorm:"size:255" json:"kursi_prioritas"`
+	KursiRoda      string `gorm:"size:255" json:"kursi_roda"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TataLetakStandar adalah susunan 2-2 yang dipakai sebelum ada template;
+// kursi lama tanpa atribut dianggap mengikuti susunan ini.
+var TataLetakStandar = TataLetakGerbong{Kode: "standar-2-2", Nama: "Standar 2-2", Kolom: "AB|CD"}
+
+var tataLetakBawaan = map[string]TataLetakGerbong{
+	"eksekutif": {Kode: "eksekutif-2-2", Nama: "Eksekutif 2-2", Kelas: "eksekutif", Kolom: "AB|CD", KursiPrioritas: "1"},
+	"bisnis":    {Kode: "bisnis-2-2", Nama: "Bisnis 2-2", Kelas: "bisnis", Kolom: "AB|CD", KursiPrioritas: "1"},
+	"ekonomi":   {Kode: "ekonomi-2-3", Nama: "Ekonomi 2-3 hadap-hadapan", Kelas: "ekonomi", Kolom: "AB|CDE", BarisMundur: "ganjil", KursiPrioritas: "1"},
+}
+
+// TataLetakBawaan mengembalikan template bawaan untuk kelas gerbong bila
+// admin belum menyimpan template untuk kelas tersebut.
+func TataLetakBawaan(kelas string) TataLetakGerbong {
+	if t, ok := tataLetakBawaan[strings.ToLower(strings.TrimSpace(kelas))]; ok {
+		return t
+	}
+	return TataLetakStandar
+}
+
+// DaftarTataLetakBawaan berisi semua template bawaan, disimpan ke database
+// saat migrasi supaya gerbong bisa menunjuk ke barisnya.
+func DaftarTataLetakBawaan() []TataLetakGerbong {
+	out := []TataLetakGerbong{TataLetakStandar}
+	for _, kelas := range []string{"eksekutif", "bisnis", "ekonomi"} {
+		out = append(out, tataLetakBawaan[kelas])
+	}
+	return out
+}
+
+// kelompokKolom memecah Kolom menjadi kelompok kursi yang dipisahkan lorong.
+func (t *TataLetakGerbong) kelompokKolom() [][]string {
+	var out [][]string
+	for _, bagian := range strings.Split(strings.ToUpper(strings.ReplaceAll(t.Kolom, " ", "")), "|") {
+		var kelompok []string
+		for _, r := range bagian {
+			kelompok = append(kelompok, string(r))
+		}
+		out = append(out, kelompok)
+	}
+	return out
+}
+
+func (t *TataLetakGerbong) Validasi() error {
+	t.Kode = strings.ToLower(strings.TrimSpace(t.Kode))
+	t.Kelas = strings.ToLower(strings.TrimSpace(t.Kelas))
+	t.Kolom = strings.ToUpper(strings.ReplaceAll(t.Kolom, " ", ""))
+	if t.Kode == "" {
+		return errors.New("kode tata letak wajib diisi")
+	}
+
+	kelompok := t.kelompokKolom()
+	if len(kelompok) > 2 {
+		return errors.New("kolom hanya boleh memiliki satu lorong")
+	}
+	var sebelumnya rune
+	for _, k := range kelompok {
+		if len(k) == 0 {
+			return errors.New("kolom tidak valid, contoh: AB|CD")
+		}
+		for _, huruf := range k {
+			r := rune(huruf[0])
+			if r < 'A' || r > 'Z' || r <= sebelumnya {
+				return errors.New("kolom harus huruf A-Z berurutan, contoh: AB|CD")
+			}
+			sebelumnya = r
+		}
+	}
+
+	for nama, daftar := range map[string]string{"baris_dilewati": t.BarisDilewati, "baris_mundur": t.BarisMundur} {
+		if _, err := parseDaftarBaris(daftar); err != nil {
+			return fmt.Errorf("%s: %w", nama, err)
+		}
+	}
+	for nama, daftar := range map[string]string{"kursi_prioritas": t.KursiPrioritas, "kursi_roda": t.KursiRoda} {
+		for _, item := range pecahDaftar(daftar) {
+			k := Kursi{NomorKursi: item}
+			if baris, _ := k.Posisi(); baris <= 0 {
+				if _, err := parseDaftarBaris(item); err != nil {
+					return fmt.Errorf("%s: %q bukan nomor kursi atau baris", nama, item)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Susun membuat daftar kursi sebanyak kapasitas beserta atributnya.
+func (t *TataLetakGerbong) Susun(gerbongID uint, kapasitas int) []Kursi {
+	if kapasitas <= 0 {
+		kapasitas = 64
+	}
+	var kolom []string
+	for _, k := range t.kelompokKolom() {
+		kolom = append(kolom, k...)
+	}
+	if len(kolom) == 0 {
+		return nil
+	}
+	dilewati, _ := parseDaftarBaris(t.BarisDilewati)
+
+	kursiList := make([]Kursi, 0, kapasitas)
+	for baris := 1; len(kursiList) < kapasitas; baris++ {
+		if dilewati(baris) {
+			continue
+		}
+		for _, huruf := range kolom {
+			if len(kursiList) == kapasitas {
+				break
+			}
+			k := Kursi{
+				GerbongID:  gerbongID,
+				NomorKursi: fmt.Sprintf("%d%s", baris, huruf),
+				Baris:      baris,
+				Kolom:      huruf,
+			}
+			t.Terapkan(&k)
+			kursiList = append(kursiList, k)
+		}
+	}
+	return kursiList
+}
+
+// Terapkan mengisi atribut kursi (jendela, lorong, arah, prioritas) dari
+// Baris dan Kolom-nya.
+func (t *TataLetakGerbong) Terapkan(k *Kursi) {
+	kelompok := t.kelompokKolom()
+	k.Jendela, k.Lorong = false, false
+	for i, kol := range kelompok {
+		for j, huruf := range kol {
+			if huruf != k.Kolom {
+				continue
+			}
+			k.Jendela = (i == 0 && j == 0) || (i == len(kelompok)-1 && j == len(kol)-1)
+			k.Lorong = len(kelompok) > 1 && ((i == 0 && j == len(kol)-1) || (i > 0 && j == 0))
+		}
+	}
+
+	k.Arah = ArahMaju
+	if mundur, _ := parseDaftarBaris(t.BarisMundur); mundur(k.Baris) {
+		k.Arah = ArahMundur
+	}
+	k.Prioritas = cocokDaftarKursi(t.KursiPrioritas, k)
+	k.KursiRoda = cocokDaftarKursi(t.KursiRoda, k)
+}
+
+func pecahDaftar(daftar string) []string {
+	var out []string
+	for _, item := range strings.Split(daftar, ",") {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// parseDaftarBaris mengubah "1,3,5-8", "ganjil" atau "genap" menjadi
+// fungsi pengecek baris.
+func parseDaftarBaris(daftar string) (func(int) bool, error) {
+	var cek []func(int) bool
+	for _, item := range pecahDaftar(daftar) {
+		switch item {
+		case "GANJIL":
+			cek = append(cek, func(b int) bool { return b%2 == 1 })
+			continue
+		case "GENAP":
+			cek = append(cek, func(b int) bool { return b%2 == 0 })
+			continue
+		}
+		awalStr, akhirStr, rentang := strings.Cut(item, "-")
+		awal, err := strconv.Atoi(awalStr)
+		if err != nil {
+			return nil, fmt.Errorf("baris %q tidak valid", item)
+		}
+		akhir := awal
+		if rentang {
+			if akhir, err = strconv.Atoi(akhirStr); err != nil || akhir < awal {
+				return nil, fmt.Errorf("rentang baris %q tidak valid", item)
+			}
+		}
+		cek = append(cek, func(b int) bool { return b >= awal && b <= akhir })
+	}
+	return func(b int) bool {
+		for _, c := range cek {
+			if c(b) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func cocokDaftarKursi(daftar string, k *Kursi) bool {
+	for _, item := range pecahDaftar(daftar) {
+		if item == strings.ToUpper(k.NomorKursi) {
+			return true
+		}
+		if baris, err := parseDaftarBaris(item); err == nil && baris(k.Baris) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseDaftarBaris(t *testing.T) {
+	tests := []struct {
+		daftar  string
+		cocok   []int
+		tidak   []int
+		wantErr bool
+	}{
+		{daftar: "", tidak: []int{1, 2, 13}},
+		{daftar: "1,3,5-8", cocok: []int{1, 3, 5, 6, 8}, tidak: []int{2, 4, 9}},
+		{daftar: "ganjil", cocok: []int{1, 3, 15}, tidak: []int{2, 4, 16}},
+		{daftar: "genap", cocok: []int{2, 4, 16}, tidak: []int{1, 3, 15}},
+		{daftar: " Genap , 13 ", cocok: []int{2, 13}, tidak: []int{1, 11}},
+		{daftar: "x", wantErr: true},
+		{daftar: "5-3", wantErr: true},
+		{daftar: "3-", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.daftar, func(t *testing.T) {
+			cek, err := parseDaftarBaris(tt.daftar)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseDaftarBaris(%q) tidak mengembalikan error", tt.daftar)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, b := range tt.cocok {
+				if !cek(b) {
+					t.Errorf("baris %d seharusnya cocok dengan %q", b, tt.daftar)
+				}
+			}
+			for _, b := range tt.tidak {
+				if cek(b) {
+					t.Errorf("baris %d seharusnya tidak cocok dengan %q", b, tt.daftar)
+				}
+			}
+		})
+	}
+}
+
+func TestSusun(t *testing.T) {
+	tests := []struct {
+		nama      string
+		tataLetak TataLetakGerbong
+		kapasitas int
+		want      []string
+	}{
+		{
+			nama:      "2-2 melewati baris 2",
+			tataLetak: TataLetakGerbong{Kolom: "AB|CD", BarisDilewati: "2"},
+			kapasitas: 10,
+			want:      []string{"1A", "1B", "1C", "1D", "3A", "3B", "3C", "3D", "4A", "4B"},
+		},
+		{
+			nama:      "2-3 melewati baris genap",
+			tataLetak: TataLetakGerbong{Kolom: "AB|CDE", BarisDilewati: "genap"},
+			kapasitas: 7,
+			want:      []string{"1A", "1B", "1C", "1D", "1E", "3A", "3B"},
+		},
+		{
+			nama:      "tanpa lorong",
+			tataLetak: TataLetakGerbong{Kolom: "ABC"},
+			kapasitas: 4,
+			want:      []string{"1A", "1B", "1C", "2A"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			kursi := tt.tataLetak.Susun(9, tt.kapasitas)
+			var got []string
+			for _, k := range kursi {
+				if k.GerbongID != 9 {
+					t.Errorf("kursi %s GerbongID = %d, want 9", k.NomorKursi, k.GerbongID)
+				}
+				got = append(got, k.NomorKursi)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Susun = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if n := len(TataLetakStandar.Susun(1, 0)); n != 64 {
+		t.Errorf("kapasitas 0 menghasilkan %d kursi, want 64", n)
+	}
+}
+
+func TestTerapkan23(t *testing.T) {
+	tataLetak := TataLetakGerbong{
+		Kolom:          "AB|CDE",
+		BarisMundur:    "ganjil",
+		KursiPrioritas: "1",
+		KursiRoda:      "2E",
+	}
+	tests := []struct {
+		baris     int
+		kolom     string
+		jendela   bool
+		lorong    bool
+		arah      string
+		prioritas bool
+		kursiRoda bool
+	}{
+		{baris: 1, kolom: "A", jendela: true, arah: ArahMundur, prioritas: true},
+		{baris: 1, kolom: "B", lorong: true, arah: ArahMundur, prioritas: true},
+		{baris: 2, kolom: "C", lorong: true, arah: ArahMaju},
+		{baris: 2, kolom: "D", arah: ArahMaju},
+		{baris: 2, kolom: "E", jendela: true, arah: ArahMaju, kursiRoda: true},
+		{baris: 3, kolom: "E", jendela: true, arah: ArahMundur},
+	}
+	for _, tt := range tests {
+		k := Kursi{NomorKursi: fmt.Sprintf("%d%s", tt.baris, tt.kolom), Baris: tt.baris, Kolom: tt.kolom}
+		t.Run(k.NomorKursi, func(t *testing.T) {
+			tataLetak.Terapkan(&k)
+			if k.Jendela != tt.jendela || k.Lorong != tt.lorong {
+				t.Errorf("jendela/lorong = %v/%v, want %v/%v", k.Jendela, k.Lorong, tt.jendela, tt.lorong)
+			}
+			if k.Arah != tt.arah {
+				t.Errorf("arah = %q, want %q", k.Arah, tt.arah)
+			}
+			if k.Prioritas != tt.prioritas || k.KursiRoda != tt.kursiRoda {
+				t.Errorf("prioritas/kursi roda = %v/%v, want %v/%v", k.Prioritas, k.KursiRoda, tt.prioritas, tt.kursiRoda)
+			}
+		})
+	}
+}
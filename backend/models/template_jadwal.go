@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// TemplateJadwal adalah jadwal berulang. Generator membuat satu Jadwal per
+// kelas untuk setiap tanggal operasi dalam masa berlaku template.
+type TemplateJadwal struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	Nama          string `gorm:"size:100" json:"nama"`
+	KeretaID      uint   `json:"kereta_id"`
+	Kereta        Kereta `gorm:"foreignKey:KeretaID" json:"kereta"`
+	JamBerangkat  string `gorm:"size:5" json:"jam_berangkat"`   // HH:MM waktu lokal
+	HariOperasi   string `gorm:"size:20" json:"hari_operasi"`   // 1=Senin ... 7=Minggu, contoh "1,2,3,4,5"
+	BerlakuMulai  string `gorm:"size:10" json:"berlaku_mulai"`  // YYYY-MM-DD
+	BerlakuSampai string `gorm:"size:10" json:"berlaku_sampai"` // YYYY-MM-DD, kosong = tanpa batas
+	Aktif         bool   `json:"aktif"`
+
+	Stops        []TemplateStop               `gorm:"foreignKey:TemplateID" json:"stops"`
+	HargaKelas   []TemplateHargaKelas         `gorm:"foreignKey:TemplateID" json:"harga_kelas"`
+	Pengecualian []TemplateJadwalPengecualian `gorm:"foreignKey:TemplateID" json:"pengecualian"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TemplateStop menyimpan waktu stop sebagai menit sejak keberangkatan dari
+// stasiun awal, dan harga kumulatif sebagai persen dari harga kelas.
+type TemplateStop struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	TemplateID     uint    `gorm:"index" json:"template_id"`
+	Urutan         int     `json:"urutan"`
+	StasiunID      uint    `json:"stasiun_id"`
+	Stasiun        Stasiun `gorm:"foreignKey:StasiunID" json:"stasiun"`
+	MenitTiba      *int    `json:"menit_tiba"`
+	MenitBerangkat *int    `json:"menit_berangkat"`
+	PersenHarga    int     `json:"persen_harga"`
+}
+
+type TemplateHargaKelas struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	TemplateID uint   `gorm:"index" json:"template_id"`
+	Kelas      string `gorm:"size:32" json:"kelas"`
+	Harga      int64  `json:"harga"`
+}
+
+type TemplateJadwalPengecualian struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	TemplateID uint   `gorm:"index" json:"template_id"`
+	Tanggal    string `gorm:"size:10" json:"tanggal"`
+	Keterangan string `gorm:"size:100" json:"keterangan"`
+}
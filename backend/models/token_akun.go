@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const (
+	TokenVerifikasiEmail = "verifikasi_email"
+	TokenResetPassword   = "reset_password"
+)
+
+// TokenAkun adalah token sekali pakai yang dikirim lewat email. Hanya hash
+// SHA-256 yang disimpan.
+type TokenAkun struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index" json:"user_id"`
+	Jenis       string     `gorm:"size:20" json:"jenis"`
+	TokenHash   string     `gorm:"size:64;uniqueIndex" json:"-"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	DipakaiPada *time.Time `json:"dipakai_pada"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
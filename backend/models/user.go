@@ -5,10 +5,36 @@ import (
 )
 
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Email     string    `json:"email" gorm:"type:varchar(150);uniqueIndex;not null"`
-	Fullname  string    `json:"fullname" gorm:"type:varchar(100);not null"`
-	Password  string    `json:"-"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Email     string     `json:"email" gorm:"type:varchar(150);uniqueIndex;not null"`
+	Fullname  string     `json:"fullname" gorm:"type:varchar(100);not null"`
+	Password  string     `json:"-"`
+	Roles     []UserRole `json:"-" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// naik setiap logout semua perangkat; access token yang membawa versi
+	// lain ditolak
+	VersiToken       uint       `json:"-" gorm:"not null;default:0"`
+	TokenDicabutPada *time.Time `json:"-"`
+
+	EmailTerverifikasiPada *time.Time `json:"email_terverifikasi_pada"`
+
+	// diisi saat user menghapus akun; data pribadi sudah dianonimkan
+	DihapusPada *time.Time `json:"-"`
+}
+
+// DaftarRole mengembalikan nama role user, selalu termasuk passenger.
+func (u *User) DaftarRole() []string {
+	out := []string{RolePassenger}
+	for _, r := range u.Roles {
+		if r.Role != RolePassenger {
+			out = append(out, r.Role)
+		}
+	}
+	return out
+}
+
+func (u *User) EmailTerverifikasi() bool {
+	return u.EmailTerverifikasiPada != nil
 }
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+type Voucher struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Kode          string     `gorm:"uniqueIndex;size:30" json:"kode"`
+	Nama          string     `gorm:"size:100" json:"nama"`
+	Jenis         string     `gorm:"type:enum('persen','nominal');default:'nominal'" json:"jenis"`
+	Nilai         int64      `json:"nilai"`         // persen (1-100) atau rupiah
+	MaksPotongan  int64      `json:"maks_potongan"` // batas potongan untuk jenis persen, 0 = tanpa batas
+	MinBelanja    int64      `json:"min_belanja"`
+	BerlakuMulai  *time.Time `json:"berlaku_mulai"`
+	BerlakuSampai *time.Time `json:"berlaku_sampai"`
+	KuotaGlobal   int        `json:"kuota_global"`   // 0 = tanpa batas
+	KuotaPerUser  int        `json:"kuota_per_user"` // 0 = tanpa batas
+	AsalID        *uint      `json:"asal_id"`
+	TujuanID      *uint      `json:"tujuan_id"`
+	Kelas         string     `gorm:"size:32" json:"kelas"`
+	Aktif         bool       `gorm:"default:true" json:"aktif"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Potongan menghitung besar diskon untuk subtotal tertentu, tidak pernah
+// melebihi subtotal itu sendiri.
+func (v *Voucher) Potongan(subtotal int64) int64 {
+	var p int64
+	if v.Jenis == "persen" {
+		p = subtotal * v.Nilai / 100
+		if v.MaksPotongan > 0 && p > v.MaksPotongan {
+			p = v.MaksPotongan
+		}
+	} else {
+		p = v.Nilai
+	}
+	if p > subtotal {
+		p = subtotal
+	}
+	return p
+}
+
+// VoucherRedemption mencatat pemakaian voucher oleh satu booking. Status
+// reserved ikut ditahan bersama kursi, redeemed setelah booking dibayar, dan
+// released jika booking batal/kedaluwarsa.
+type VoucherRedemption struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	VoucherID uint      `gorm:"index" json:"voucher_id"`
+	UserID    *uint     `gorm:"index" json:"user_id"`
+	BookingID uint      `gorm:"uniqueIndex" json:"booking_id"`
+	Potongan  int64     `json:"potongan"`
+	Status    string    `gorm:"type:enum('reserved','redeemed','released');default:'reserved'" json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+type AturanHargaRepo interface {
+	Buat(a *models.AturanHarga) error
+	ListSemua() ([]models.AturanHarga, error)
+	ListAktif() ([]models.AturanHarga, error)
+	ListAktifTx(tx *gorm.DB) ([]models.AturanHarga, error)
+	Update(a *models.AturanHarga) error
+	Delete(id uint) error
+}
+
+type aturanHargaRepo struct {
+	db *gorm.DB
+}
+
+func NewAturanHargaRepo(db *gorm.DB) AturanHargaRepo {
+	return &aturanHargaRepo{db: db}
+}
+
+func (r *aturanHargaRepo) Buat(a *models.AturanHarga) error {
+	return r.db.Create(a).Error
+}
+
+func (r *aturanHargaRepo) ListSemua() ([]models.AturanHarga, error) {
+	var list []models.AturanHarga
+	if err := r.db.Order("jenis asc, dari asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListAktif mengurutkan aturan per kelas di depan aturan umum agar yang
+// lebih spesifik menang saat dicocokkan.
+func (r *aturanHargaRepo) ListAktif() ([]models.AturanHarga, error) {
+	return r.ListAktifTx(r.db)
+}
+
+// ListAktifTx sama dengan ListAktif tetapi memakai handle milik pemanggil.
+func (r *aturanHargaRepo) ListAktifTx(tx *gorm.DB) ([]models.AturanHarga, error) {
+	var list []models.AturanHarga
+	if err := tx.Where("aktif = ?", true).
+		Order("kelas = '' asc, dari asc").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *aturanHargaRepo) Update(a *models.AturanHarga) error {
+	var ex models.AturanHarga
+	if err := r.db.First(&ex, a.ID).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&ex).Select("jenis", "dari", "sampai", "faktor", "kelas", "aktif", "updated_at").Updates(a).Error
+}
+
+func (r *aturanHargaRepo) Delete(id uint) error {
+	res := r.db.Delete(&models.AturanHarga{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("aturan harga tidak ditemukan")
+	}
+	return nil
+}
@@ -60,7 +60,7 @@ func (r *authRepo) RegisterUser(ctx context.Context, registerData *models.AuthCr
 
 func (r *authRepo) GetUser(ctx context.Context, query interface{}, args ...interface{}) (*models.User, error) {
 	var u models.User
-	err := r.db.WithContext(ctx).Where(query, args...).First(&u).Error
+	err := r.db.WithContext(ctx).Preload("Roles").Where(query, args...).First(&u).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -26,7 +26,12 @@ func (r *bookingRepo) Create(tx *gorm.DB, b *models.Booking) error {
 
 func (r *bookingRepo) GetByID(id uint) (*models.Booking, error) {
 	var b models.Booking
-	if err := r.db.Preload("Penumpangs").First(&b, id).Error; err != nil {
+	if err := r.db.Preload("Penumpangs").
+		Preload("Items").
+		Preload("Legs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan asc")
+		}).
+		First(&b, id).Error; err != nil {
 		return nil, err
 	}
 	return &b, nil
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	errMySQLDuplikat = 1062
+	errMySQLDeadlock = 1213
+)
+
+// IsDuplikat melaporkan apakah err berasal dari pelanggaran unique index.
+func IsDuplikat(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == errMySQLDuplikat
+}
+
+// IsDeadlock melaporkan apakah InnoDB membatalkan transaksi karena deadlock;
+// transaksi seperti ini aman diulang dari awal.
+func IsDeadlock(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == errMySQLDeadlock
+}
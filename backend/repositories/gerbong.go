@@ -15,6 +15,7 @@ type GerbongRepo interface {
 	ListSemua() ([]models.Gerbong, error)
 	Update(g *models.Gerbong) error
 	Delete(id uint) error
+	TataLetakGerbongs(gerbongs []models.Gerbong) ([]*models.TataLetakGerbong, error)
 }
 
 type gerbongRepo struct {
@@ -51,6 +52,7 @@ func (r *gerbongRepo) ListByKeretaAndKelas(keretaID uint, kelas string) ([]model
 	var list []models.Gerbong
 	if err := r.db.Where("kereta_id = ? AND kelas = ?", keretaID, kelas).
 		Preload("Kursis").
+		Preload("TataLetak").
 		Order("nomor_gerbong asc").
 		Find(&list).Error; err != nil {
 		return nil, err
@@ -84,3 +86,7 @@ func (r *gerbongRepo) Delete(id uint) error {
 	}
 	return nil
 }
+
+func (r *gerbongRepo) TataLetakGerbongs(gerbongs []models.Gerbong) ([]*models.TataLetakGerbong, error) {
+	return AmbilTataLetakGerbongs(r.db, gerbongs)
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"sort"
 	"strconv"
 	"time"
 
@@ -10,10 +11,14 @@ import (
 
 type JadwalRepo interface {
 	Buat(j *models.Jadwal) (*models.Jadwal, error)
+	BuatTx(tx *gorm.DB, j *models.Jadwal) error
 	GetByID(id uint) (*models.Jadwal, error)
 	Hapus(id uint) error
 	CariJadwal(asal, tujuan, tanggal string, kelas string) ([]models.Jadwal, error)
 	ListSemua() ([]models.Jadwal, error)
+	ListByRentangTanggal(dari, sampai string, kelas string) ([]models.Jadwal, error)
+	ListByRentangTanggalTx(tx *gorm.DB, dari, sampai string, kelas string) ([]models.Jadwal, error)
+	RingkasanRute(asalID, tujuanID uint, dari, sampai string, kelas string) ([]models.RingkasanJadwal, error)
 }
 
 type jadwalRepo struct {
@@ -24,39 +29,85 @@ func NewJadwalRepo(db *gorm.DB) JadwalRepo {
 	return &jadwalRepo{db: db}
 }
 
-func (r *jadwalRepo) Buat(j *models.Jadwal) (*models.Jadwal, error) {
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(j).Error; err != nil {
-			return err
-		}
+func preloadJadwal(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Kereta.Gerbongs").
+		Preload("Asal").
+		Preload("Tujuan").
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan asc")
+		}).
+		Preload("Stops.Stasiun")
+}
 
-		var kursiList []models.Kursi
-		if err := tx.
-			Joins("JOIN gerbongs ON gerbongs.id = kursis.gerbong_id").
-			Where("gerbongs.kereta_id = ? AND gerbongs.kelas = ?", j.KeretaID, j.Kelas).
-			Find(&kursiList).Error; err != nil {
-			return err
+// siapkanStops memastikan jadwal punya minimal stop asal dan tujuan dengan
+// urutan berurutan mulai dari 0.
+func siapkanStops(j *models.Jadwal) {
+	if len(j.Stops) == 0 {
+		berangkat := j.WaktuBerangkat
+		tiba := j.WaktuTiba
+		j.Stops = []models.JadwalStop{
+			{StasiunID: j.AsalID, WaktuBerangkat: &berangkat},
+			{StasiunID: j.TujuanID, WaktuTiba: &tiba, HargaKumulatif: j.Harga},
 		}
+	}
 
-		if len(kursiList) > 0 {
-			now := time.Now()
-			inventories := make([]models.KetersediaanKursi, 0, len(kursiList))
-			for _, s := range kursiList {
-				inventories = append(inventories, models.KetersediaanKursi{
-					TrainScheduleID:   j.ID,
-					SeatID:            s.ID,
-					Status:            "available",
-					ReservedByBooking: 0,
-					ReservedUntil:     nil,
-					UpdatedAt:         now,
-				})
-			}
-			if err := tx.Create(&inventories).Error; err != nil {
-				return err
-			}
-		}
+	sort.SliceStable(j.Stops, func(a, b int) bool {
+		return j.Stops[a].Urutan < j.Stops[b].Urutan
+	})
+	for i := range j.Stops {
+		j.Stops[i].Urutan = i
+	}
+
+	j.AsalID = j.Stops[0].StasiunID
+	j.TujuanID = j.Stops[len(j.Stops)-1].StasiunID
+}
 
+func buatInventoriJadwal(tx *gorm.DB, j *models.Jadwal) error {
+	var kursiList []models.Kursi
+	if err := tx.
+		Joins("JOIN gerbongs ON gerbongs.id = kursis.gerbong_id").
+		Where("gerbongs.kereta_id = ? AND gerbongs.kelas = ?", j.KeretaID, j.Kelas).
+		Find(&kursiList).Error; err != nil {
+		return err
+	}
+
+	if len(kursiList) == 0 {
 		return nil
+	}
+
+	now := time.Now()
+	segmen := j.JumlahSegmen()
+	inventories := make([]models.KetersediaanKursi, 0, len(kursiList)*segmen)
+	for _, s := range kursiList {
+		for seg := 0; seg < segmen; seg++ {
+			inventories = append(inventories, models.KetersediaanKursi{
+				TrainScheduleID:   j.ID,
+				SeatID:            s.ID,
+				Segmen:            seg,
+				Status:            "available",
+				ReservedByBooking: 0,
+				ReservedUntil:     nil,
+				UpdatedAt:         now,
+			})
+		}
+	}
+	return tx.CreateInBatches(&inventories, 500).Error
+}
+
+// BuatTx menyimpan jadwal beserta stop dan inventori kursinya di dalam
+// transaksi milik pemanggil.
+func (r *jadwalRepo) BuatTx(tx *gorm.DB, j *models.Jadwal) error {
+	siapkanStops(j)
+	if err := tx.Create(j).Error; err != nil {
+		return err
+	}
+	return buatInventoriJadwal(tx, j)
+}
+
+func (r *jadwalRepo) Buat(j *models.Jadwal) (*models.Jadwal, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return r.BuatTx(tx, j)
 	})
 
 	if err != nil {
@@ -64,11 +115,7 @@ func (r *jadwalRepo) Buat(j *models.Jadwal) (*models.Jadwal, error) {
 	}
 
 	var out models.Jadwal
-	if err := r.db.
-		Preload("Kereta.Gerbongs").
-		Preload("Asal").
-		Preload("Tujuan").
-		First(&out, j.ID).Error; err != nil {
+	if err := preloadJadwal(r.db).First(&out, j.ID).Error; err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -76,26 +123,24 @@ func (r *jadwalRepo) Buat(j *models.Jadwal) (*models.Jadwal, error) {
 
 func (r *jadwalRepo) GetByID(id uint) (*models.Jadwal, error) {
 	var j models.Jadwal
-	if err := r.db.
-		Preload("Kereta.Gerbongs").
-		Preload("Asal").
-		Preload("Tujuan").
-		First(&j, id).Error; err != nil {
+	if err := preloadJadwal(r.db).First(&j, id).Error; err != nil {
 		return nil, err
 	}
 	return &j, nil
 }
 
 func (r *jadwalRepo) Hapus(id uint) error {
-	return r.db.Delete(&models.Jadwal{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("jadwal_id = ?", id).Delete(&models.JadwalStop{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Jadwal{}, id).Error
+	})
 }
 
 func (r *jadwalRepo) ListSemua() ([]models.Jadwal, error) {
 	var list []models.Jadwal
-	if err := r.db.
-		Preload("Kereta.Gerbongs").
-		Preload("Asal").
-		Preload("Tujuan").
+	if err := preloadJadwal(r.db).
 		Order("tanggal asc, waktu_berangkat asc").
 		Find(&list).Error; err != nil {
 		return nil, err
@@ -103,48 +148,118 @@ func (r *jadwalRepo) ListSemua() ([]models.Jadwal, error) {
 	return list, nil
 }
 
+func (r *jadwalRepo) ListByRentangTanggal(dari, sampai string, kelas string) ([]models.Jadwal, error) {
+	return r.ListByRentangTanggalTx(r.db, dari, sampai, kelas)
+}
+
+// ListByRentangTanggalTx sama dengan ListByRentangTanggal tetapi memakai
+// handle milik pemanggil, misalnya yang sudah diberi context request.
+func (r *jadwalRepo) ListByRentangTanggalTx(tx *gorm.DB, dari, sampai string, kelas string) ([]models.Jadwal, error) {
+	var list []models.Jadwal
+	q := tx.
+		Preload("Kereta").
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan asc")
+		}).
+		Preload("Stops.Stasiun").
+		Where("tanggal BETWEEN ? AND ?", dari, sampai)
+	if kelas != "" {
+		q = q.Where("kelas = ?", kelas)
+	}
+	if err := q.Order("waktu_berangkat asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// resolveStasiunID menerima ID numerik, nama, atau kode stasiun.
+func (r *jadwalRepo) resolveStasiunID(input string) (uint, bool) {
+	if id, err := strconv.Atoi(input); err == nil {
+		return uint(id), true
+	}
+	var stasiun models.Stasiun
+	if err := r.db.Where("nama = ? OR kode = ?", input, input).First(&stasiun).Error; err != nil {
+		return 0, false
+	}
+	return stasiun.ID, true
+}
+
+// CariJadwal mencari jadwal yang melewati stasiun asal lalu stasiun tujuan
+// (berurutan), termasuk jadwal yang asal/tujuannya hanya stop antara.
 func (r *jadwalRepo) CariJadwal(asal, tujuan, tanggal string, kelas string) ([]models.Jadwal, error) {
 	var result []models.Jadwal
 
-	q := r.db.
-		Preload("Kereta.Gerbongs").
-		Preload("Asal").
-		Preload("Tujuan").
-		Where("tanggal = ?", tanggal)
+	q := preloadJadwal(r.db).Where("jadwals.tanggal = ?", tanggal)
 
 	if kelas != "" {
-		q = q.Where("kelas = ?", kelas)
+		q = q.Where("jadwals.kelas = ?", kelas)
 	}
 
+	var asalID, tujuanID uint
 	if asal != "" {
-		if _, err := strconv.Atoi(asal); err == nil {
-			q = q.Where("asal_id = ?", asal)
-		} else {
-			var stasiun models.Stasiun
-			if err := r.db.Where("nama = ? OR kode = ?", asal, asal).First(&stasiun).Error; err == nil {
-				q = q.Where("asal_id = ?", stasiun.ID)
-			} else {
-				return []models.Jadwal{}, nil
-			}
+		id, ok := r.resolveStasiunID(asal)
+		if !ok {
+			return []models.Jadwal{}, nil
 		}
+		asalID = id
+		q = q.Joins("JOIN jadwal_stops naik ON naik.jadwal_id = jadwals.id AND naik.stasiun_id = ?", asalID)
 	}
 
 	if tujuan != "" {
-		if _, err := strconv.Atoi(tujuan); err == nil {
-			q = q.Where("tujuan_id = ?", tujuan)
+		id, ok := r.resolveStasiunID(tujuan)
+		if !ok {
+			return []models.Jadwal{}, nil
+		}
+		tujuanID = id
+		if asalID != 0 {
+			q = q.Joins("JOIN jadwal_stops turun ON turun.jadwal_id = jadwals.id AND turun.stasiun_id = ? AND turun.urutan > naik.urutan", tujuanID)
 		} else {
-			var stasiun models.Stasiun
-			if err := r.db.Where("nama = ? OR kode = ?", tujuan, tujuan).First(&stasiun).Error; err == nil {
-				q = q.Where("tujuan_id = ?", stasiun.ID)
-			} else {
-				return []models.Jadwal{}, nil
-			}
+			q = q.Joins("JOIN jadwal_stops turun ON turun.jadwal_id = jadwals.id AND turun.stasiun_id = ? AND turun.urutan > 0", tujuanID)
 		}
 	}
 
-	if err := q.Order("waktu_berangkat asc").Find(&result).Error; err != nil {
+	if err := q.Distinct("jadwals.*").Order("jadwals.waktu_berangkat asc").Find(&result).Error; err != nil {
 		return nil, err
 	}
 
+	for i := range result {
+		naik, turun, err := result[i].Segmen(asalID, tujuanID)
+		if err != nil {
+			continue
+		}
+		result[i].Naik = naik
+		result[i].Turun = turun
+		result[i].HargaSegmen = result[i].HargaAntara(naik, turun)
+	}
+
 	return result, nil
 }
+
+// RingkasanRute mengagregasi semua jadwal asal-tujuan dalam rentang tanggal
+// beserta keterisian kursinya dalam satu query.
+func (r *jadwalRepo) RingkasanRute(asalID, tujuanID uint, dari, sampai string, kelas string) ([]models.RingkasanJadwal, error) {
+	var rows []models.RingkasanJadwal
+
+	q := r.db.Table("jadwals j").
+		Select(`j.id AS jadwal_id, j.tanggal, j.kelas, j.waktu_berangkat, j.harga,
+			naik.harga_kumulatif AS harga_naik, turun.harga_kumulatif AS harga_turun,
+			COUNT(k.id) AS total_baris,
+			COALESCE(SUM(CASE WHEN k.status <> 'available' THEN 1 ELSE 0 END), 0) AS baris_terisi,
+			COUNT(DISTINCT CASE WHEN k.segmen >= naik.urutan AND k.segmen < turun.urutan THEN k.seat_id END) AS kursi_segmen,
+			COUNT(DISTINCT CASE WHEN k.segmen >= naik.urutan AND k.segmen < turun.urutan AND k.status <> 'available' THEN k.seat_id END) AS kursi_terpakai`).
+		Joins("JOIN jadwal_stops naik ON naik.jadwal_id = j.id AND naik.stasiun_id = ?", asalID).
+		Joins("JOIN jadwal_stops turun ON turun.jadwal_id = j.id AND turun.stasiun_id = ? AND turun.urutan > naik.urutan", tujuanID).
+		Joins("LEFT JOIN ketersediaan_kursis k ON k.train_schedule_id = j.id").
+		Where("j.tanggal BETWEEN ? AND ?", dari, sampai)
+
+	if kelas != "" {
+		q = q.Where("j.kelas = ?", kelas)
+	}
+
+	if err := q.Group("j.id, naik.id, turun.id").
+		Order("j.tanggal asc, j.kelas asc").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
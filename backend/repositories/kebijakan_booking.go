@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+type KebijakanBookingRepo interface {
+	Get() (*models.KebijakanBooking, error)
+	Simpan(k *models.KebijakanBooking) error
+}
+
+type kebijakanBookingRepo struct {
+	db *gorm.DB
+}
+
+func NewKebijakanBookingRepo(db *gorm.DB) KebijakanBookingRepo {
+	return &kebijakanBookingRepo{db: db}
+}
+
+func (r *kebijakanBookingRepo) Get() (*models.KebijakanBooking, error) {
+	return AmbilKebijakanBooking(r.db)
+}
+
+func (r *kebijakanBookingRepo) Simpan(k *models.KebijakanBooking) error {
+	k.ID = models.IDKebijakanBooking
+	return r.db.Save(k).Error
+}
+
+// AmbilKebijakanBooking bisa dipanggil dengan tx agar kebijakan dibaca di
+// dalam transaksi booking.
+func AmbilKebijakanBooking(db *gorm.DB) (*models.KebijakanBooking, error) {
+	var k models.KebijakanBooking
+	err := db.First(&k, models.IDKebijakanBooking).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		k = models.KebijakanBookingBawaan()
+		return &k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
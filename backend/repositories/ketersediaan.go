@@ -10,10 +10,12 @@ import (
 )
 
 type KetersediaanRepo interface {
-	FindAndLockBySchedule(tx *gorm.DB, scheduleID uint, seatIDs []uint) ([]models.KetersediaanKursi, error)
-	MarkReserved(tx *gorm.DB, inventoryIDs []uint, bookingID uint) error
+	FindAndLockBySegment(tx *gorm.DB, scheduleID uint, naikUrutan, turunUrutan int, seatIDs []uint) ([]models.KetersediaanKursi, error)
+	MarkReserved(tx *gorm.DB, inventoryIDs []uint, bookingID uint, reservedUntil time.Time) error
+	PerpanjangReserved(tx *gorm.DB, bookingID uint, reservedUntil time.Time) (int64, error)
 	ReleaseByBooking(tx *gorm.DB, bookingID uint) error
 	GetBySchedule(scheduleID uint) ([]models.KetersediaanKursi, error)
+	GetBySegment(scheduleID uint, naikUrutan, turunUrutan int) ([]models.KetersediaanKursi, error)
 }
 
 type ketersediaanRepo struct {
@@ -24,25 +26,36 @@ func NewKetersediaanRepo(db *gorm.DB) KetersediaanRepo {
 	return &ketersediaanRepo{db: db}
 }
 
-func (r *ketersediaanRepo) FindAndLockBySchedule(tx *gorm.DB, scheduleID uint, seatIDs []uint) ([]models.KetersediaanKursi, error) {
+// FindAndLockBySegment mengunci baris ketersediaan setiap kursi untuk semua
+// segmen yang dilewati penumpang, yaitu segmen naikUrutan s.d. turunUrutan-1.
+func (r *ketersediaanRepo) FindAndLockBySegment(tx *gorm.DB, scheduleID uint, naikUrutan, turunUrutan int, seatIDs []uint) ([]models.KetersediaanKursi, error) {
 	var inv []models.KetersediaanKursi
 	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-		Where("train_schedule_id = ? AND seat_id IN ?", scheduleID, seatIDs).
+		Where("train_schedule_id = ? AND seat_id IN ? AND segmen >= ? AND segmen < ?", scheduleID, seatIDs, naikUrutan, turunUrutan).
+		Order("seat_id asc, segmen asc").
 		Find(&inv).Error; err != nil {
 		return nil, err
 	}
 
-	if len(inv) != len(seatIDs) {
-		existingMap := make(map[uint]bool)
+	expected := len(seatIDs) * (turunUrutan - naikUrutan)
+	if len(inv) != expected {
+		existingMap := make(map[uint]map[int]bool)
 		for _, item := range inv {
-			existingMap[item.SeatID] = true
+			if existingMap[item.SeatID] == nil {
+				existingMap[item.SeatID] = make(map[int]bool)
+			}
+			existingMap[item.SeatID][item.Segmen] = true
 		}
 
 		for _, sid := range seatIDs {
-			if !existingMap[sid] {
+			for seg := naikUrutan; seg < turunUrutan; seg++ {
+				if existingMap[sid][seg] {
+					continue
+				}
 				newInv := models.KetersediaanKursi{
 					TrainScheduleID: scheduleID,
 					SeatID:          sid,
+					Segmen:          seg,
 					Status:          "available",
 					UpdatedAt:       time.Now(),
 				}
@@ -57,9 +70,8 @@ func (r *ketersediaanRepo) FindAndLockBySchedule(tx *gorm.DB, scheduleID uint, s
 	return inv, nil
 }
 
-func (r *ketersediaanRepo) MarkReserved(tx *gorm.DB, inventoryIDs []uint, bookingID uint) error {
+func (r *ketersediaanRepo) MarkReserved(tx *gorm.DB, inventoryIDs []uint, bookingID uint, reservedUntil time.Time) error {
 	now := time.Now()
-	reservedUntil := now.Add(1 * time.Minute)
 
 	return tx.Model(&models.KetersediaanKursi{}).
 		Where("id IN ?", inventoryIDs).
@@ -71,6 +83,18 @@ func (r *ketersediaanRepo) MarkReserved(tx *gorm.DB, inventoryIDs []uint, bookin
 		}).Error
 }
 
+// PerpanjangReserved menggeser reserved_until kursi yang masih ditahan
+// booking dan mengembalikan jumlah baris yang diperbarui.
+func (r *ketersediaanRepo) PerpanjangReserved(tx *gorm.DB, bookingID uint, reservedUntil time.Time) (int64, error) {
+	res := tx.Model(&models.KetersediaanKursi{}).
+		Where("reserved_by_booking = ? AND status = ?", bookingID, "reserved").
+		Updates(map[string]interface{}{
+			"reserved_until": &reservedUntil,
+			"updated_at":     time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
 func (r *ketersediaanRepo) ReleaseByBooking(tx *gorm.DB, bookingID uint) error {
 	now := time.Now()
 	res := tx.Model(&models.KetersediaanKursi{}).
@@ -95,3 +119,13 @@ func (r *ketersediaanRepo) GetBySchedule(scheduleID uint) ([]models.Ketersediaan
 	}
 	return list, nil
 }
+
+func (r *ketersediaanRepo) GetBySegment(scheduleID uint, naikUrutan, turunUrutan int) ([]models.KetersediaanKursi, error) {
+	var list []models.KetersediaanKursi
+	if err := r.db.
+		Where("train_schedule_id = ? AND segmen >= ? AND segmen < ?", scheduleID, naikUrutan, turunUrutan).
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
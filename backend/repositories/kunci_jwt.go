@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+type KunciJWTRepo interface {
+	ListBerlaku() ([]models.KunciJWT, error)
+	BuatAktif(k *models.KunciJWT) error
+	Pensiunkan(kid string) error
+	SimpanPEM(kid, privatePEM string) error
+}
+
+type kunciJWTRepo struct {
+	db *gorm.DB
+}
+
+func NewKunciJWTRepo(db *gorm.DB) KunciJWTRepo {
+	return &kunciJWTRepo{db: db}
+}
+
+func (r *kunciJWTRepo) ListBerlaku() ([]models.KunciJWT, error) {
+	var list []models.KunciJWT
+	if err := r.db.Where("pensiun_pada IS NULL").Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// BuatAktif menyimpan kunci baru sebagai satu-satunya kunci aktif.
+func (r *kunciJWTRepo) BuatAktif(k *models.KunciJWT) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.KunciJWT{}).Where("aktif = ?", true).Update("aktif", false).Error; err != nil {
+			return err
+		}
+		k.Aktif = true
+		return tx.Create(k).Error
+	})
+}
+
+func (r *kunciJWTRepo) Pensiunkan(kid string) error {
+	res := r.db.Model(&models.KunciJWT{}).
+		Where("kid = ? AND aktif = ? AND pensiun_pada IS NULL", kid, false).
+		Update("pensiun_pada", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("kunci tidak ditemukan, sudah pensiun, atau masih aktif")
+	}
+	return nil
+}
+
+func (r *kunciJWTRepo) SimpanPEM(kid, privatePEM string) error {
+	return r.db.Model(&models.KunciJWT{}).Where("kid = ?", kid).Update("private_pem", privatePEM).Error
+}
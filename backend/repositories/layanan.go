@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+type LayananRepo interface {
+	Buat(l *models.LayananTambahan) error
+	ListSemua() ([]models.LayananTambahan, error)
+	ListAktifByKode(kodes []string) ([]models.LayananTambahan, error)
+	ListAktifByKodeTx(tx *gorm.DB, kodes []string) ([]models.LayananTambahan, error)
+	Update(l *models.LayananTambahan) error
+	Delete(id uint) error
+}
+
+type layananRepo struct {
+	db *gorm.DB
+}
+
+func NewLayananRepo(db *gorm.DB) LayananRepo {
+	return &layananRepo{db: db}
+}
+
+func (r *layananRepo) Buat(l *models.LayananTambahan) error {
+	return r.db.Create(l).Error
+}
+
+func (r *layananRepo) ListSemua() ([]models.LayananTambahan, error) {
+	var list []models.LayananTambahan
+	if err := r.db.Order("kode asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *layananRepo) ListAktifByKode(kodes []string) ([]models.LayananTambahan, error) {
+	return r.ListAktifByKodeTx(r.db, kodes)
+}
+
+// ListAktifByKodeTx sama dengan ListAktifByKode tetapi memakai transaksi
+// milik pemanggil, misalnya transaksi booking.
+func (r *layananRepo) ListAktifByKodeTx(tx *gorm.DB, kodes []string) ([]models.LayananTambahan, error) {
+	var list []models.LayananTambahan
+	if len(kodes) == 0 {
+		return list, nil
+	}
+	if err := tx.Where("kode IN ? AND aktif = ?", kodes, true).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *layananRepo) Update(l *models.LayananTambahan) error {
+	var ex models.LayananTambahan
+	if err := r.db.First(&ex, l.ID).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&ex).Select("kode", "nama", "harga", "per_penumpang", "aktif").Updates(l).Error
+}
+
+func (r *layananRepo) Delete(id uint) error {
+	res := r.db.Delete(&models.LayananTambahan{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("layanan tidak ditemukan")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+// PenumpangTersimpanRepo selalu memfilter berdasarkan user pemilik sehingga
+// user tidak bisa membaca atau mengubah data penumpang user lain.
+type PenumpangTersimpanRepo interface {
+	ListByUser(userID uint) ([]models.PenumpangTersimpan, error)
+	GetMilikUser(id, userID uint) (*models.PenumpangTersimpan, error)
+	Buat(p *models.PenumpangTersimpan) error
+	Update(p *models.PenumpangTersimpan) error
+	Hapus(id, userID uint) error
+}
+
+type penumpangTersimpanRepo struct {
+	db *gorm.DB
+}
+
+func NewPenumpangTersimpanRepo(db *gorm.DB) PenumpangTersimpanRepo {
+	return &penumpangTersimpanRepo{db: db}
+}
+
+func (r *penumpangTersimpanRepo) ListByUser(userID uint) ([]models.PenumpangTersimpan, error) {
+	var list []models.PenumpangTersimpan
+	if err := r.db.Where("user_id = ?", userID).Order("nama asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *penumpangTersimpanRepo) GetMilikUser(id, userID uint) (*models.PenumpangTersimpan, error) {
+	var p models.PenumpangTersimpan
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *penumpangTersimpanRepo) Buat(p *models.PenumpangTersimpan) error {
+	return r.db.Create(p).Error
+}
+
+func (r *penumpangTersimpanRepo) Update(p *models.PenumpangTersimpan) error {
+	return r.db.Model(&models.PenumpangTersimpan{}).
+		Where("id = ? AND user_id = ?", p.ID, p.UserID).
+		Updates(map[string]interface{}{
+			"nama":             p.Nama,
+			"jenis_identitas":  p.JenisIdentitas,
+			"no_identitas":     p.NoIdentitas,
+			"no_identitas_idx": models.IndeksIdentitas(string(p.NoIdentitas)),
+			"tanggal_lahir":    p.TanggalLahir,
+
+			"paspor_berlaku_sampai": p.PasporBerlakuSampai,
+		}).Error
+}
+
+func (r *penumpangTersimpanRepo) Hapus(id, userID uint) error {
+	res := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.PenumpangTersimpan{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
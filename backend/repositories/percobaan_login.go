@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+type FilterPercobaanLogin struct {
+	Email    string
+	IP       string
+	UserID   uint
+	Berhasil *bool
+	Dari     *time.Time
+	Sampai   *time.Time
+	Limit    int
+}
+
+type PercobaanLoginRepo interface {
+	Catat(p *models.PercobaanLogin) error
+	GagalTerakhir(kolom, nilai string, sejak time.Time) (int64, *time.Time, error)
+	Cari(f FilterPercobaanLogin) ([]models.PercobaanLogin, error)
+}
+
+type percobaanLoginRepo struct {
+	db *gorm.DB
+}
+
+func NewPercobaanLoginRepo(db *gorm.DB) PercobaanLoginRepo {
+	return &percobaanLoginRepo{db: db}
+}
+
+func (r *percobaanLoginRepo) Catat(p *models.PercobaanLogin) error {
+	return r.db.Create(p).Error
+}
+
+type ringkasanGagal struct {
+	Jumlah   int64
+	Terakhir *time.Time
+}
+
+// GagalTerakhir menghitung login gagal (tidak termasuk yang diblokir) untuk
+// email atau ip sejak waktu tertentu, beserta waktu gagal terakhir. Untuk
+// email, hitungan dimulai ulang setelah login berhasil.
+func (r *percobaanLoginRepo) GagalTerakhir(kolom, nilai string, sejak time.Time) (int64, *time.Time, error) {
+	if kolom == "email" {
+		var sukses models.PercobaanLogin
+		err := r.db.Select("created_at").
+			Where("email = ? AND berhasil = ? AND created_at >= ?", nilai, true, sejak).
+			Order("created_at desc").Limit(1).Find(&sukses).Error
+		if err != nil {
+			return 0, nil, err
+		}
+		if sukses.CreatedAt.After(sejak) {
+			sejak = sukses.CreatedAt
+		}
+	}
+
+	var out ringkasanGagal
+	err := r.db.Model(&models.PercobaanLogin{}).
+		Select("COUNT(*) AS jumlah, MAX(created_at) AS terakhir").
+		Where(kolom+" = ? AND berhasil = ? AND alasan <> ? AND created_at >= ?", nilai, false, models.AlasanLoginDiblokir, sejak).
+		Scan(&out).Error
+	if err != nil {
+		return 0, nil, err
+	}
+	return out.Jumlah, out.Terakhir, nil
+}
+
+func (r *percobaanLoginRepo) Cari(f FilterPercobaanLogin) ([]models.PercobaanLogin, error) {
+	q := r.db.Model(&models.PercobaanLogin{})
+	if f.Email != "" {
+		q = q.Where("email = ?", f.Email)
+	}
+	if f.IP != "" {
+		q = q.Where("ip = ?", f.IP)
+	}
+	if f.UserID != 0 {
+		q = q.Where("user_id = ?", f.UserID)
+	}
+	if f.Berhasil != nil {
+		q = q.Where("berhasil = ?", *f.Berhasil)
+	}
+	if f.Dari != nil {
+		q = q.Where("created_at >= ?", *f.Dari)
+	}
+	if f.Sampai != nil {
+		q = q.Where("created_at < ?", *f.Sampai)
+	}
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+
+	var list []models.PercobaanLogin
+	if err := q.Order("created_at desc").Limit(f.Limit).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepo interface {
+	Buat(t *models.RefreshToken) error
+	GetByHash(hash string) (*models.RefreshToken, error)
+	Rotasi(id uint, now time.Time) (bool, error)
+	CabutFamily(familyID string, now time.Time) error
+	CabutSemuaUser(userID uint, now time.Time) error
+}
+
+type refreshTokenRepo struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepo(db *gorm.DB) RefreshTokenRepo {
+	return &refreshTokenRepo{db: db}
+}
+
+func (r *refreshTokenRepo) Buat(t *models.RefreshToken) error {
+	return r.db.Create(t).Error
+}
+
+func (r *refreshTokenRepo) GetByHash(hash string) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Rotasi menandai token terpakai secara atomik. false berarti token sudah
+// terpakai/dicabut lebih dulu (termasuk oleh request paralel).
+func (r *refreshTokenRepo) Rotasi(id uint, now time.Time) (bool, error) {
+	res := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"used_at": now, "revoked_at": now})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *refreshTokenRepo) CabutFamily(familyID string, now time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+// CabutSemuaUser mencabut semua refresh token user dan menaikkan versi token
+// sehingga semua access token yang sudah terbit tidak berlaku lagi.
+func (r *refreshTokenRepo) CabutSemuaUser(userID uint, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"versi_token":        gorm.Expr("versi_token + 1"),
+			"token_dicabut_pada": now,
+		}).Error
+	})
+}
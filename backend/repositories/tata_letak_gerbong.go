@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTataLetakDipakai  = errors.New("tata letak masih dipakai gerbong")
+	ErrKodeTataLetakAda  = errors.New("kode tata letak sudah dipakai")
+	ErrKelasTataLetakAda = errors.New("kelas ini sudah punya tata letak bawaan")
+)
+
+type TataLetakGerbongRepo interface {
+	Buat(t *models.TataLetakGerbong) error
+	GetByID(id uint) (*models.TataLetakGerbong, error)
+	ListSemua() ([]models.TataLetakGerbong, error)
+	Simpan(t *models.TataLetakGerbong) error
+	Delete(id uint) error
+}
+
+type tataLetakGerbongRepo struct {
+	db *gorm.DB
+}
+
+func NewTataLetakGerbongRepo(db *gorm.DB) TataLetakGerbongRepo {
+	return &tataLetakGerbongRepo{db: db}
+}
+
+func (r *tataLetakGerbongRepo) Buat(t *models.TataLetakGerbong) error {
+	return r.simpan(t, func(tx *gorm.DB) error { return tx.Create(t).Error })
+}
+
+func (r *tataLetakGerbongRepo) GetByID(id uint) (*models.TataLetakGerbong, error) {
+	var t models.TataLetakGerbong
+	if err := r.db.First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *tataLetakGerbongRepo) ListSemua() ([]models.TataLetakGerbong, error) {
+	var list []models.TataLetakGerbong
+	if err := r.db.Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Simpan menulis semua kolom supaya daftar yang dikosongkan ikut tersimpan.
+func (r *tataLetakGerbongRepo) Simpan(t *models.TataLetakGerbong) error {
+	return r.simpan(t, func(tx *gorm.DB) error { return tx.Save(t).Error })
+}
+
+// simpan menolak kode atau kelas yang sudah dipakai template lain. Kelas
+// dicek di sini karena kolomnya boleh kosong untuk banyak template.
+func (r *tataLetakGerbongRepo) simpan(t *models.TataLetakGerbong, tulis func(tx *gorm.DB) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if t.Kelas != "" {
+			var lain []models.TataLetakGerbong
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("kelas = ? AND id <> ?", t.Kelas, t.ID).
+				Limit(1).Find(&lain).Error; err != nil {
+				return err
+			}
+			if len(lain) > 0 {
+				return ErrKelasTataLetakAda
+			}
+		}
+		if err := tulis(tx); err != nil {
+			if IsDuplikat(err) {
+				return ErrKodeTataLetakAda
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (r *tataLetakGerbongRepo) Delete(id uint) error {
+	var dipakai int64
+	if err := r.db.Model(&models.Gerbong{}).Where("tata_letak_id = ?", id).Count(&dipakai).Error; err != nil {
+		return err
+	}
+	if dipakai > 0 {
+		return ErrTataLetakDipakai
+	}
+	res := r.db.Delete(&models.TataLetakGerbong{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AmbilTataLetak mengembalikan tata letak satu gerbong, lihat
+// AmbilTataLetakGerbongs.
+func AmbilTataLetak(db *gorm.DB, g *models.Gerbong) (*models.TataLetakGerbong, error) {
+	if g.TataLetakID != nil && g.TataLetak != nil && g.TataLetak.ID == *g.TataLetakID {
+		return g.TataLetak, nil
+	}
+	out, err := AmbilTataLetakGerbongs(db, []models.Gerbong{*g})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+// AmbilTataLetakGerbongs mengembalikan tata letak setiap gerbong (urutan
+// sama dengan gerbongs) dengan satu query: pilihan gerbong itu sendiri, lalu
+// template untuk kelasnya, lalu bawaan kelas. Gerbong tanpa pilihan yang
+// kursinya dibuat sebelum ada tata letak (Kursis harus di-preload) tetap
+// dianggap memakai susunan standar 2-2.
+func AmbilTataLetakGerbongs(db *gorm.DB, gerbongs []models.Gerbong) ([]*models.TataLetakGerbong, error) {
+	var semua []models.TataLetakGerbong
+	if err := db.Order("id asc").Find(&semua).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.TataLetakGerbong, len(semua))
+	byKode := make(map[string]*models.TataLetakGerbong, len(semua))
+	byKelas := make(map[string]*models.TataLetakGerbong, len(semua))
+	for i := range semua {
+		t := &semua[i]
+		byID[t.ID] = t
+		byKode[t.Kode] = t
+		if _, ada := byKelas[t.Kelas]; t.Kelas != "" && !ada {
+			byKelas[t.Kelas] = t
+		}
+	}
+	bawaan := func(t models.TataLetakGerbong) *models.TataLetakGerbong {
+		if tersimpan, ok := byKode[t.Kode]; ok {
+			return tersimpan
+		}
+		return &t
+	}
+
+	out := make([]*models.TataLetakGerbong, len(gerbongs))
+	for i := range gerbongs {
+		g := &gerbongs[i]
+		switch {
+		case g.TataLetakID == nil && g.KursiTanpaAtribut():
+			out[i] = bawaan(models.TataLetakStandar)
+		case g.TataLetakID != nil && byID[*g.TataLetakID] != nil:
+			out[i] = byID[*g.TataLetakID]
+		case byKelas[g.Kelas] != nil:
+			out[i] = byKelas[g.Kelas]
+		default:
+			out[i] = bawaan(models.TataLetakBawaan(g.Kelas))
+		}
+	}
+	return out, nil
+}
+
+// SeedTataLetakBawaan menyimpan template bawaan yang belum ada di database.
+// Template bawaan untuk kelas yang sudah punya template sendiri disimpan
+// tanpa kelas agar setiap kelas tetap hanya punya satu template.
+func SeedTataLetakBawaan(db *gorm.DB) error {
+	for _, t := range models.DaftarTataLetakBawaan() {
+		var jumlah int64
+		if err := db.Model(&models.TataLetakGerbong{}).Where("kode = ?", t.Kode).Count(&jumlah).Error; err != nil {
+			return err
+		}
+		if jumlah > 0 {
+			continue
+		}
+		if t.Kelas != "" {
+			if err := db.Model(&models.TataLetakGerbong{}).Where("kelas = ?", t.Kelas).Count(&jumlah).Error; err != nil {
+				return err
+			}
+			if jumlah > 0 {
+				t.Kelas = ""
+			}
+		}
+		if err := db.Create(&t).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
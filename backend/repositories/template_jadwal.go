@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+type TemplateJadwalRepo interface {
+	Buat(t *models.TemplateJadwal) error
+	GetByID(id uint) (*models.TemplateJadwal, error)
+	ListSemua() ([]models.TemplateJadwal, error)
+	ListAktif() ([]models.TemplateJadwal, error)
+	Update(t *models.TemplateJadwal) error
+	Delete(id uint) error
+	TambahPengecualian(p *models.TemplateJadwalPengecualian) error
+	HapusPengecualian(templateID uint, tanggal string) error
+}
+
+type templateJadwalRepo struct {
+	db *gorm.DB
+}
+
+func NewTemplateJadwalRepo(db *gorm.DB) TemplateJadwalRepo {
+	return &templateJadwalRepo{db: db}
+}
+
+func preloadTemplate(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Kereta").
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan asc")
+		}).
+		Preload("Stops.Stasiun").
+		Preload("HargaKelas").
+		Preload("Pengecualian", func(db *gorm.DB) *gorm.DB {
+			return db.Order("tanggal asc")
+		})
+}
+
+func (r *templateJadwalRepo) Buat(t *models.TemplateJadwal) error {
+	return r.db.Create(t).Error
+}
+
+func (r *templateJadwalRepo) GetByID(id uint) (*models.TemplateJadwal, error) {
+	var t models.TemplateJadwal
+	if err := preloadTemplate(r.db).First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *templateJadwalRepo) ListSemua() ([]models.TemplateJadwal, error) {
+	var list []models.TemplateJadwal
+	if err := preloadTemplate(r.db).Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *templateJadwalRepo) ListAktif() ([]models.TemplateJadwal, error) {
+	var list []models.TemplateJadwal
+	if err := preloadTemplate(r.db).Where("aktif = ?", true).Order("id asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Update mengganti header template beserta stop dan harga kelasnya. Jadwal
+// yang sudah terbentuk tidak diubah; perubahan berlaku untuk tanggal yang
+// belum digenerate.
+func (r *templateJadwalRepo) Update(t *models.TemplateJadwal) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var ex models.TemplateJadwal
+		if err := tx.First(&ex, t.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&ex).Select(
+			"nama", "kereta_id", "jam_berangkat", "hari_operasi",
+			"berlaku_mulai", "berlaku_sampai", "aktif", "updated_at",
+		).Updates(t).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("template_id = ?", t.ID).Delete(&models.TemplateStop{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", t.ID).Delete(&models.TemplateHargaKelas{}).Error; err != nil {
+			return err
+		}
+		for i := range t.Stops {
+			t.Stops[i].ID = 0
+			t.Stops[i].TemplateID = t.ID
+		}
+		for i := range t.HargaKelas {
+			t.HargaKelas[i].ID = 0
+			t.HargaKelas[i].TemplateID = t.ID
+		}
+		if len(t.Stops) > 0 {
+			if err := tx.Omit("Stasiun").Create(&t.Stops).Error; err != nil {
+				return err
+			}
+		}
+		if len(t.HargaKelas) > 0 {
+			if err := tx.Create(&t.HargaKelas).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete hanya menghapus template. Jadwal yang sudah terbentuk tetap ada.
+func (r *templateJadwalRepo) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&models.TemplateJadwal{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("template jadwal tidak ditemukan")
+		}
+		for _, m := range []interface{}{&models.TemplateStop{}, &models.TemplateHargaKelas{}, &models.TemplateJadwalPengecualian{}} {
+			if err := tx.Where("template_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *templateJadwalRepo) TambahPengecualian(p *models.TemplateJadwalPengecualian) error {
+	var jumlah int64
+	if err := r.db.Model(&models.TemplateJadwalPengecualian{}).
+		Where("template_id = ? AND tanggal = ?", p.TemplateID, p.Tanggal).
+		Count(&jumlah).Error; err != nil {
+		return err
+	}
+	if jumlah > 0 {
+		return nil
+	}
+	return r.db.Create(p).Error
+}
+
+func (r *templateJadwalRepo) HapusPengecualian(templateID uint, tanggal string) error {
+	res := r.db.Where("template_id = ? AND tanggal = ?", templateID, tanggal).Delete(&models.TemplateJadwalPengecualian{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("tanggal pengecualian tidak ditemukan")
+	}
+	return nil
+}
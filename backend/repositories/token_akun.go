@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+type TokenAkunRepo interface {
+	Buat(t *models.TokenAkun) error
+	GetByHash(hash string) (*models.TokenAkun, error)
+	Pakai(id uint, now time.Time) (bool, error)
+}
+
+type tokenAkunRepo struct {
+	db *gorm.DB
+}
+
+func NewTokenAkunRepo(db *gorm.DB) TokenAkunRepo {
+	return &tokenAkunRepo{db: db}
+}
+
+// Buat menyimpan token baru dan membatalkan token sejenis milik user yang
+// belum terpakai, sehingga hanya link terakhir yang berlaku.
+func (r *tokenAkunRepo) Buat(t *models.TokenAkun) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TokenAkun{}).
+			Where("user_id = ? AND jenis = ? AND dipakai_pada IS NULL", t.UserID, t.Jenis).
+			Update("dipakai_pada", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(t).Error
+	})
+}
+
+func (r *tokenAkunRepo) GetByHash(hash string) (*models.TokenAkun, error) {
+	var t models.TokenAkun
+	err := r.db.Where("token_hash = ?", hash).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Pakai menandai token terpakai secara atomik; false jika sudah terpakai.
+func (r *tokenAkunRepo) Pakai(id uint, now time.Time) (bool, error) {
+	res := r.db.Model(&models.TokenAkun{}).
+		Where("id = ? AND dipakai_pada IS NULL", id).
+		Update("dipakai_pada", now)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
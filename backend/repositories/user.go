@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"errors"
+	"time"
 
 	"github.com/fitranmei/Mooove-/backend/models"
 	"gorm.io/gorm"
@@ -11,6 +12,11 @@ type UserRepo interface {
 	Create(user *models.User) error
 	FindByEmail(email string) (*models.User, error)
 	FindByID(id uint) (*models.User, error)
+	GrantRole(userID uint, role string) error
+	RevokeRole(userID uint, role string) error
+	UpdatePassword(userID uint, password string) error
+	SetEmailTerverifikasi(userID uint, waktu time.Time) error
+	UpdateProfil(userID uint, fullname, email string, resetVerifikasi bool) error
 }
 
 type userRepo struct {
@@ -27,7 +33,7 @@ func (r *userRepo) Create(user *models.User) error {
 
 func (r *userRepo) FindByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.Preload("Roles").Where("email = ?", email).First(&user).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
@@ -36,9 +42,54 @@ func (r *userRepo) FindByEmail(email string) (*models.User, error) {
 
 func (r *userRepo) FindByID(id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := r.db.Preload("Roles").First(&user, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
 	return &user, err
 }
+
+// GrantRole idempoten: role yang sudah dimiliki tidak ditambahkan lagi.
+func (r *userRepo) GrantRole(userID uint, role string) error {
+	var jumlah int64
+	if err := r.db.Model(&models.UserRole{}).Where("user_id = ? AND role = ?", userID, role).Count(&jumlah).Error; err != nil {
+		return err
+	}
+	if jumlah > 0 {
+		return nil
+	}
+	return r.db.Create(&models.UserRole{UserID: userID, Role: role}).Error
+}
+
+func (r *userRepo) RevokeRole(userID uint, role string) error {
+	res := r.db.Where("user_id = ? AND role = ?", userID, role).Delete(&models.UserRole{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("user tidak memiliki role tersebut")
+	}
+	return nil
+}
+
+func (r *userRepo) UpdatePassword(userID uint, password string) error {
+	hashed, err := bcryptGenerate(password)
+	if err != nil {
+		return err
+	}
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("password", hashed).Error
+}
+
+func (r *userRepo) SetEmailTerverifikasi(userID uint, waktu time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("email_terverifikasi_pada", waktu).Error
+}
+
+// UpdateProfil mengganti nama dan email. resetVerifikasi dipakai saat email
+// berubah agar alamat baru diverifikasi ulang.
+func (r *userRepo) UpdateProfil(userID uint, fullname, email string, resetVerifikasi bool) error {
+	kolom := map[string]interface{}{"fullname": fullname, "email": email}
+	if resetVerifikasi {
+		kolom["email_terverifikasi_pada"] = nil
+	}
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Updates(kolom).Error
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"gorm.io/gorm"
+)
+
+type VoucherRepo interface {
+	Buat(v *models.Voucher) error
+	GetByID(id uint) (*models.Voucher, error)
+	ListSemua() ([]models.Voucher, error)
+	Update(v *models.Voucher) error
+	Delete(id uint) error
+}
+
+type voucherRepo struct {
+	db *gorm.DB
+}
+
+func NewVoucherRepo(db *gorm.DB) VoucherRepo {
+	return &voucherRepo{db: db}
+}
+
+func (r *voucherRepo) Buat(v *models.Voucher) error {
+	return r.db.Create(v).Error
+}
+
+func (r *voucherRepo) GetByID(id uint) (*models.Voucher, error) {
+	var v models.Voucher
+	if err := r.db.First(&v, id).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *voucherRepo) ListSemua() ([]models.Voucher, error) {
+	var list []models.Voucher
+	if err := r.db.Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *voucherRepo) Update(v *models.Voucher) error {
+	var ex models.Voucher
+	if err := r.db.First(&ex, v.ID).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&ex).Select("*").Omit("id", "created_at").Updates(v).Error
+}
+
+func (r *voucherRepo) Delete(id uint) error {
+	res := r.db.Delete(&models.Voucher{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("voucher tidak ditemukan")
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+const (
+	ttlTokenVerifikasi = 24 * time.Hour
+	ttlTokenReset      = 1 * time.Hour
+)
+
+var (
+	ErrTokenAkunTidakValid     = errors.New("token tidak valid, sudah dipakai, atau kedaluwarsa")
+	ErrEmailSudahTerverifikasi = errors.New("email sudah terverifikasi")
+	ErrEmailSudahDipakai       = errors.New("email sudah terdaftar")
+	ErrPasswordLamaSalah       = errors.New("password lama salah")
+	ErrPasswordSalah           = errors.New("password salah")
+)
+
+// AkunService menangani verifikasi email dan reset password memakai token
+// sekali pakai yang dikirim lewat email.
+type AkunService struct {
+	users       repositories.UserRepo
+	tokens      repositories.TokenAkunRepo
+	refreshRepo repositories.RefreshTokenRepo
+	mail        PengirimEmail
+	appURL      string
+	resetURL    string
+}
+
+// resetURL adalah halaman (web atau deep link aplikasi) yang menerima
+// ?token=...; bila kosong dipakai halaman bawaan API di APP_URL/reset-password.
+func NewAkunService(users repositories.UserRepo, tokens repositories.TokenAkunRepo, rr repositories.RefreshTokenRepo, mail PengirimEmail, appURL, resetURL string) *AkunService {
+	if resetURL == "" {
+		resetURL = strings.TrimRight(appURL, "/") + "/reset-password"
+	}
+	return &AkunService{users: users, tokens: tokens, refreshRepo: rr, mail: mail, appURL: appURL, resetURL: resetURL}
+}
+
+func (s *AkunService) buatToken(userID uint, jenis string, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	err := s.tokens.Buat(&models.TokenAkun{
+		UserID:    userID,
+		Jenis:     jenis,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// pakaiToken memvalidasi lalu menghabiskan token. Token salah jenis,
+// kedaluwarsa, atau sudah dipakai ditolak dengan error yang sama.
+func (s *AkunService) pakaiToken(token, jenis string) (*models.TokenAkun, error) {
+	t, err := s.tokens.GetByHash(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if t == nil || t.Jenis != jenis || t.DipakaiPada != nil || now.After(t.ExpiresAt) {
+		return nil, ErrTokenAkunTidakValid
+	}
+	ok, err := s.tokens.Pakai(t.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTokenAkunTidakValid
+	}
+	return t, nil
+}
+
+func (s *AkunService) KirimVerifikasi(ctx context.Context, userID uint) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if user.EmailTerverifikasi() {
+		return ErrEmailSudahTerverifikasi
+	}
+
+	token, err := s.buatToken(user.ID, models.TokenVerifikasiEmail, ttlTokenVerifikasi)
+	if err != nil {
+		return err
+	}
+	isi := fmt.Sprintf("Halo %s,\n\nKlik link berikut untuk memverifikasi email akun Mooove kamu:\n%s/api/v1/auth/verifikasi-email?token=%s\n\nLink berlaku 24 jam.\n",
+		user.Fullname, s.appURL, token)
+	return s.mail.Kirim(ctx, user.Email, "Verifikasi email Mooove", isi)
+}
+
+func (s *AkunService) Verifikasi(ctx context.Context, token string) error {
+	t, err := s.pakaiToken(token, models.TokenVerifikasiEmail)
+	if err != nil {
+		return err
+	}
+	return s.users.SetEmailTerverifikasi(t.UserID, time.Now())
+}
+
+// LupaPassword selalu berhasil dari sisi pemanggil agar tidak bisa dipakai
+// untuk menebak email yang terdaftar.
+func (s *AkunService) LupaPassword(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	token, err := s.buatToken(user.ID, models.TokenResetPassword, ttlTokenReset)
+	if err != nil {
+		return err
+	}
+	isi := fmt.Sprintf("Halo %s,\n\nKami menerima permintaan reset password akun Mooove kamu. Gunakan link berikut:\n%s?token=%s\n\nLink berlaku 1 jam. Abaikan email ini jika kamu tidak memintanya.\n",
+		user.Fullname, s.resetURL, token)
+	if err := s.mail.Kirim(ctx, user.Email, "Reset password Mooove", isi); err != nil {
+		log.Printf("[akun] gagal mengirim email reset ke user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword mengganti password dan mencabut semua sesi user. Reset juga
+// membuktikan kepemilikan email, jadi email ikut ditandai terverifikasi.
+func (s *AkunService) ResetPassword(ctx context.Context, token, passwordBaru string) error {
+	if err := validasiPassword(passwordBaru); err != nil {
+		return err
+	}
+	t, err := s.pakaiToken(token, models.TokenResetPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(t.UserID, passwordBaru); err != nil {
+		return err
+	}
+
+	user, err := s.users.FindByID(t.UserID)
+	if err != nil {
+		return err
+	}
+	if user != nil && !user.EmailTerverifikasi() {
+		if err := s.users.SetEmailTerverifikasi(user.ID, time.Now()); err != nil {
+			return err
+		}
+	}
+	return s.refreshRepo.CabutSemuaUser(t.UserID, time.Now())
+}
+
+type UpdateProfilInput struct {
+	Fullname string `json:"fullname"`
+	Email    string `json:"email"`
+	// Password saat ini, wajib bila email diganti.
+	Password string `json:"password"`
+}
+
+// UpdateProfil mengganti nama dan/atau email. Mengganti email memerlukan
+// password saat ini seperti GantiPassword, karena email dipakai untuk reset
+// password. Email baru harus diverifikasi ulang, jadi status verifikasi
+// direset dan email verifikasi dikirim.
+func (s *AkunService) UpdateProfil(ctx context.Context, userID uint, in UpdateProfilInput) (*models.User, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+
+	fullname := strings.TrimSpace(in.Fullname)
+	if fullname == "" {
+		fullname = user.Fullname
+	}
+	email := normalisasiEmail(in.Email)
+	if email == "" {
+		email = user.Email
+	}
+
+	gantiEmail := !strings.EqualFold(email, user.Email)
+	if gantiEmail {
+		if !models.MatchesHash(in.Password, user.Password) {
+			return nil, ErrPasswordSalah
+		}
+		if !models.IsValidEmail(email) {
+			return nil, errors.New("format email tidak valid")
+		}
+		lain, err := s.users.FindByEmail(email)
+		if err != nil {
+			return nil, err
+		}
+		if lain != nil && lain.ID != user.ID {
+			return nil, ErrEmailSudahDipakai
+		}
+	}
+
+	if err := s.users.UpdateProfil(user.ID, fullname, email, gantiEmail); err != nil {
+		return nil, err
+	}
+
+	if gantiEmail {
+		if err := s.KirimVerifikasi(ctx, user.ID); err != nil {
+			log.Printf("[akun] gagal mengirim verifikasi email baru user %d: %v", user.ID, err)
+		}
+	}
+	return s.users.FindByID(user.ID)
+}
+
+// GantiPassword memerlukan password lama dan mencabut semua sesi, termasuk
+// sesi yang sedang dipakai.
+func (s *AkunService) GantiPassword(ctx context.Context, userID uint, passwordLama, passwordBaru string) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if !models.MatchesHash(passwordLama, user.Password) {
+		return ErrPasswordLamaSalah
+	}
+	if err := validasiPassword(passwordBaru); err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(user.ID, passwordBaru); err != nil {
+		return err
+	}
+	return s.refreshRepo.CabutSemuaUser(user.ID, time.Now())
+}
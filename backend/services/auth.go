@@ -2,91 +2,134 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrRefreshTokenTidakValid = errors.New("refresh token tidak valid atau sudah kedaluwarsa")
+
+// hashPalsu dipakai untuk menyamakan waktu respons login dengan email yang
+// tidak terdaftar.
+var hashPalsu = func() string {
+	h, _ := bcrypt.GenerateFromPassword([]byte(uuid.NewString()), bcrypt.DefaultCost)
+	return string(h)
+}()
+
 type AuthServiceImpl struct {
-	repo   models.AuthRepository
-	jwtKey string
-	ttl    time.Duration
+	repo        models.AuthRepository
+	refreshRepo repositories.RefreshTokenRepo
+	kunci       *PengelolaKunci
+	pelindung   *PelindungLogin
+	ttl         time.Duration
+	refreshTTL  time.Duration
 }
 
-func NewAuthServiceImpl(repo models.AuthRepository, jwtKey string, ttl time.Duration) models.AuthService {
-	return &AuthServiceImpl{repo: repo, jwtKey: jwtKey, ttl: ttl}
+func NewAuthServiceImpl(repo models.AuthRepository, refreshRepo repositories.RefreshTokenRepo, kunci *PengelolaKunci, pelindung *PelindungLogin, ttl, refreshTTL time.Duration) models.AuthService {
+	return &AuthServiceImpl{repo: repo, refreshRepo: refreshRepo, kunci: kunci, pelindung: pelindung, ttl: ttl, refreshTTL: refreshTTL}
 }
 
-func (s *AuthServiceImpl) Register(ctx context.Context, registerData *models.AuthCredentials) (string, *models.User, error) {
+func (s *AuthServiceImpl) Register(ctx context.Context, registerData *models.AuthCredentials) (*models.TokenAuth, *models.User, error) {
 	if registerData == nil {
-		return "", nil, errors.New("no register data")
+		return nil, nil, errors.New("no register data")
 	}
 
 	if !models.IsValidEmail(registerData.Email) {
-		return "", nil, errors.New("invalid email format")
+		return nil, nil, errors.New("invalid email format")
 	}
 
 	existingUser, err := s.repo.GetUser(ctx, "email = ?", registerData.Email)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 	if existingUser != nil {
-		return "", nil, errors.New("email sudah terdaftar")
+		return nil, nil, errors.New("email sudah terdaftar")
 	}
 
-	if len(registerData.Password) < 6 {
-		return "", nil, errors.New("password harus memiliki minimal 6 karakter")
-	}
-
-	hasUpper := false
-	for _, c := range registerData.Password {
-		if c >= 'A' && c <= 'Z' {
-			hasUpper = true
-			break
-		}
-	}
-
-	if !hasUpper {
-		return "", nil, errors.New("password harus mengandung minimal 1 huruf kapital")
+	if err := validasiPassword(registerData.Password); err != nil {
+		return nil, nil, err
 	}
 
 	user, err := s.repo.RegisterUser(ctx, registerData)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
-	token, err := s.generateToken(user)
+	token, err := s.terbitkanToken(user, uuid.NewString())
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
 	user.Password = ""
 	return token, user, nil
 }
 
-func (s *AuthServiceImpl) Login(ctx context.Context, loginData *models.AuthCredentials) (string, *models.User, error) {
+func validasiPassword(password string) error {
+	if len(password) < 6 {
+		return errors.New("password harus memiliki minimal 6 karakter")
+	}
+
+	hasUpper := false
+	for _, c := range password {
+		if c >= 'A' && c <= 'Z' {
+			hasUpper = true
+			break
+		}
+	}
+
+	if !hasUpper {
+		return errors.New("password harus mengandung minimal 1 huruf kapital")
+	}
+	return nil
+}
+
+func (s *AuthServiceImpl) Login(ctx context.Context, loginData *models.AuthCredentials) (*models.TokenAuth, *models.User, error) {
 	if loginData == nil {
-		return "", nil, errors.New("no login data")
+		return nil, nil, errors.New("no login data")
 	}
 
-	user, err := s.repo.GetUser(ctx, "email = ?", loginData.Email)
+	email := normalisasiEmail(loginData.Email)
+	if err := s.pelindung.Periksa(email, infoKlienDari(ctx).IP, time.Now()); err != nil {
+		var blokir *TerlaluBanyakPercobaanError
+		if errors.As(err, &blokir) {
+			s.pelindung.Catat(ctx, email, nil, models.AlasanLoginDiblokir)
+		}
+		return nil, nil, err
+	}
+
+	user, err := s.repo.GetUser(ctx, "email = ?", email)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
 	if user == nil {
-		return "", nil, errors.New("email tidak ditemukan, silakan daftar terlebih dahulu")
+		// tetap jalankan bcrypt supaya waktu respons tidak membedakan email
+		// terdaftar dan tidak
+		models.MatchesHash(loginData.Password, hashPalsu)
+		s.pelindung.Catat(ctx, email, nil, models.AlasanLoginEmailTakAda)
+		return nil, nil, ErrLoginGagal
 	}
 
 	if !models.MatchesHash(loginData.Password, user.Password) {
-		return "", nil, errors.New("password salah")
+		s.pelindung.Catat(ctx, email, &user.ID, models.AlasanLoginPasswordSalah)
+		return nil, nil, ErrLoginGagal
 	}
 
-	token, err := s.generateToken(user)
+	s.pelindung.Catat(ctx, email, &user.ID, models.AlasanLoginBerhasil)
+
+	token, err := s.terbitkanToken(user, uuid.NewString())
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
 	user.Password = ""
@@ -99,8 +142,110 @@ func (s *AuthServiceImpl) generateToken(user *models.User) (string, error) {
 		"sub":      user.ID,
 		"email":    user.Email,
 		"fullname": user.Fullname,
+		"roles":    user.DaftarRole(),
+		"ver":      user.VersiToken,
+		"iat":      time.Now().Unix(),
 		"exp":      time.Now().Add(s.ttl).Unix(),
 	}
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString([]byte(s.jwtKey))
+	return s.kunci.Tandatangani(claims)
+}
+
+// terbitkanToken membuat access token dan refresh token baru dalam family
+// yang diberikan.
+func (s *AuthServiceImpl) terbitkanToken(user *models.User, familyID string) (*models.TokenAuth, error) {
+	access, err := s.generateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+
+	rt := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := s.refreshRepo.Buat(rt); err != nil {
+		return nil, err
+	}
+
+	return &models.TokenAuth{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(s.ttl / time.Second),
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Refresh merotasi refresh token. Token yang sudah pernah dipakai menandakan
+// kebocoran, sehingga seluruh family (semua turunan dari login yang sama)
+// dicabut.
+func (s *AuthServiceImpl) Refresh(ctx context.Context, refreshToken string) (*models.TokenAuth, error) {
+	rt, err := s.refreshRepo.GetByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if rt == nil {
+		return nil, ErrRefreshTokenTidakValid
+	}
+
+	now := time.Now()
+	if rt.UsedAt != nil {
+		log.Printf("[auth] refresh token dipakai ulang, mencabut family %s user %d", rt.FamilyID, rt.UserID)
+		if err := s.refreshRepo.CabutFamily(rt.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenTidakValid
+	}
+	if rt.RevokedAt != nil || now.After(rt.ExpiresAt) {
+		return nil, ErrRefreshTokenTidakValid
+	}
+
+	ok, err := s.refreshRepo.Rotasi(rt.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// kalah balapan dengan request lain yang memakai token yang sama
+		if err := s.refreshRepo.CabutFamily(rt.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenTidakValid
+	}
+
+	user, err := s.repo.GetUser(ctx, "id = ?", rt.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrRefreshTokenTidakValid
+	}
+	return s.terbitkanToken(user, rt.FamilyID)
+}
+
+// Logout mencabut family dari refresh token tersebut (satu perangkat).
+func (s *AuthServiceImpl) Logout(ctx context.Context, refreshToken string) error {
+	rt, err := s.refreshRepo.GetByHash(hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if rt == nil {
+		return nil
+	}
+	return s.refreshRepo.CabutFamily(rt.FamilyID, time.Now())
+}
+
+// LogoutSemua mencabut semua sesi user, termasuk access token yang masih
+// berlaku.
+func (s *AuthServiceImpl) LogoutSemua(ctx context.Context, userID uint) error {
+	return s.refreshRepo.CabutSemuaUser(userID, time.Now())
 }
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+)
+
+// Kode error batas booking, dipakai client untuk menampilkan pesan yang tepat.
+const (
+	KodeMaksPenumpang   = "MAKS_PENUMPANG_PER_BOOKING"
+	KodeMaksHoldAktif   = "MAKS_HOLD_AKTIF"
+	KodeMaksKursiJadwal = "MAKS_KURSI_PER_JADWAL"
+)
+
+type BatasBookingError struct {
+	Kode  string
+	Batas int
+	Pesan string
+}
+
+func (e *BatasBookingError) Error() string {
+	return e.Pesan
+}
+
+// cekBatasBooking menerapkan batas penumpang dan hold aktif dari
+// KebijakanBooking. Baris user dikunci FOR UPDATE sampai transaksi selesai
+// supaya booking bersamaan dari user yang sama dihitung berurutan, termasuk
+// di cekBatasKursiJadwal. Booking tanpa login hanya dibatasi jumlah
+// penumpangnya.
+func cekBatasBooking(tx *gorm.DB, kebijakan *models.KebijakanBooking, userID *uint, penumpangs []models.Penumpang) error {
+	if maks := kebijakan.MaksPenumpangPerBooking; maks > 0 && len(penumpangs) > maks {
+		return &BatasBookingError{
+			Kode:  KodeMaksPenumpang,
+			Batas: maks,
+			Pesan: fmt.Sprintf("maksimal %d penumpang per booking", maks),
+		}
+	}
+	if userID == nil {
+		return nil
+	}
+
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, *userID).Error; err != nil {
+		return err
+	}
+
+	if maks := kebijakan.MaksHoldAktifPerUser; maks > 0 {
+		var hold int64
+		if err := tx.Model(&models.Booking{}).
+			Where("user_id = ? AND status = ?", *userID, "pending").
+			Count(&hold).Error; err != nil {
+			return err
+		}
+		if hold >= int64(maks) {
+			return &BatasBookingError{
+				Kode:  KodeMaksHoldAktif,
+				Batas: maks,
+				Pesan: fmt.Sprintf("kamu masih punya %d booking yang belum dibayar, selesaikan atau batalkan terlebih dahulu", hold),
+			}
+		}
+	}
+	return nil
+}
+
+// cekBatasKursiJadwal menerapkan MaksKursiPerJadwal. Dipanggil setelah
+// NormalisasiPenumpang supaya bayi yang hanya mengisi tanggal lahir tidak
+// terhitung berkursi, dan setelah cekBatasBooking yang mengunci baris user.
+func cekBatasKursiJadwal(tx *gorm.DB, kebijakan *models.KebijakanBooking, userID *uint, legs []BookingLegInput, penumpangs []models.Penumpang) error {
+	if userID == nil {
+		return nil
+	}
+	if maks := kebijakan.MaksKursiPerJadwal; maks > 0 {
+		berkursi := int64(models.JumlahBerkursi(penumpangs))
+		for _, leg := range legs {
+			var terpakai int64
+			if err := tx.Table("penumpangs").
+				Joins("JOIN bookings ON bookings.id = penumpangs.booking_id").
+				Joins("JOIN booking_legs ON booking_legs.id = penumpangs.booking_leg_id").
+				Where("bookings.user_id = ? AND bookings.status IN ? AND booking_legs.train_schedule_id = ? AND penumpangs.seat_id IS NOT NULL",
+					*userID, []string{"pending", "paid"}, leg.ScheduleID).
+				Count(&terpakai).Error; err != nil {
+				return err
+			}
+			if terpakai+berkursi > int64(maks) {
+				return &BatasBookingError{
+					Kode:  KodeMaksKursiJadwal,
+					Batas: maks,
+					Pesan: fmt.Sprintf("maksimal %d kursi per user untuk satu jadwal (sudah %d)", maks, terpakai),
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -2,41 +2,270 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/fitranmei/Mooove-/backend/models"
 	"github.com/fitranmei/Mooove-/backend/repositories"
 	"github.com/fitranmei/Mooove-/backend/utils"
 )
 
+// OpsiBooking berisi aturan booking yang bisa diatur lewat config.
+type OpsiBooking struct {
+	WajibVerifikasiEmail bool
+
+	// LamaHold berformat "kanal:menit,...", misalnya "web:15,app:15,loket:5".
+	// Kanal "default" dipakai untuk kanal yang tidak terdaftar.
+	LamaHold            string
+	PerpanjangHoldMenit int
+}
+
+const (
+	KanalDefault        = "default"
+	lamaHoldBawaanMenit = 15
+)
+
+var (
+	ErrEmailBelumTerverifikasi = errors.New("verifikasi email terlebih dahulu sebelum melakukan booking")
+	ErrHoldSudahDiperpanjang   = errors.New("hold booking ini sudah pernah diperpanjang")
+	ErrPembayaranBelumDimulai  = errors.New("hold hanya bisa diperpanjang setelah pembayaran dimulai")
+	ErrHoldKedaluwarsa         = errors.New("hold kursi sudah berakhir")
+	ErrBookingBersamaan        = errors.New("booking lain sedang memproses kursi atau identitas yang sama, silakan coba lagi")
+)
+
 type BookingService struct {
 	db               *gorm.DB
 	bookingRepo      repositories.BookingRepo
 	ketersediaanRepo repositories.KetersediaanRepo
+	tarifSvc         *TarifService
+	voucherSvc       *VoucherService
+	opsi             OpsiBooking
+	lamaHold         map[string]int
 }
 
-func NewBookingService(db *gorm.DB, br repositories.BookingRepo, kr repositories.KetersediaanRepo) *BookingService {
-	return &BookingService{db: db, bookingRepo: br, ketersediaanRepo: kr}
+func NewBookingService(db *gorm.DB, br repositories.BookingRepo, kr repositories.KetersediaanRepo, ts *TarifService, vs *VoucherService, opsi OpsiBooking) *BookingService {
+	lamaHold := parsePetaInt(opsi.LamaHold)
+	if lamaHold[KanalDefault] <= 0 {
+		lamaHold[KanalDefault] = lamaHoldBawaanMenit
+	}
+	return &BookingService{db: db, bookingRepo: br, ketersediaanRepo: kr, tarifSvc: ts, voucherSvc: vs, opsi: opsi, lamaHold: lamaHold}
 }
 
-func (s *BookingService) CreateBookingWithReserve(ctx context.Context, userID *uint, scheduleID uint, seatIDs []uint, penumpangs []models.Penumpang, total int64) (*models.Booking, error) {
-	if len(seatIDs) == 0 || len(seatIDs) != len(penumpangs) {
-		return nil, fmt.Errorf("jumlah kursi dan penumpang tidak sesuai")
+// kanalHold mengembalikan kanal yang dikenal beserta lama hold-nya.
+func (s *BookingService) kanalHold(kanal string) (string, time.Duration) {
+	kanal = strings.ToLower(strings.TrimSpace(kanal))
+	menit, ok := s.lamaHold[kanal]
+	if !ok || menit <= 0 {
+		kanal, menit = KanalDefault, s.lamaHold[KanalDefault]
+	}
+	return kanal, time.Duration(menit) * time.Minute
+}
+
+// BookingLegInput adalah permintaan kursi pada satu jadwal. SeatIDs[i] dipakai
+// oleh penumpang berkursi ke-i (bayi dilewati).
+type BookingLegInput struct {
+	ScheduleID     uint   `json:"schedule_id"`
+	NaikStasiunID  uint   `json:"naik_stasiun_id"`
+	TurunStasiunID uint   `json:"turun_stasiun_id"`
+	SeatIDs        []uint `json:"seat_ids"`
+}
+
+type PermintaanBooking struct {
+	Legs       []BookingLegInput  `json:"legs"`
+	Penumpangs []models.Penumpang `json:"penumpangs"`
+	Layanan    []LayananInput     `json:"layanan"`
+
+	KodeVoucher string `json:"kode_voucher"`
+
+	// Kanal asal booking (web, app, loket) yang menentukan lama hold kursi
+	Kanal string `json:"kanal"`
+
+	// AutoKursi memilihkan kursi untuk leg yang seat_ids-nya kosong
+	// berdasarkan Preferensi.
+	AutoKursi  bool            `json:"auto_kursi"`
+	Preferensi PreferensiKursi `json:"preferensi_kursi"`
+}
+
+// HargaTidakSesuaiError dikembalikan jika total dari client berbeda dengan
+// quote server. Quote disertakan agar client bisa menampilkan harga terbaru.
+type HargaTidakSesuaiError struct {
+	Quote *models.Quote
+}
+
+func (e *HargaTidakSesuaiError) Error() string {
+	return fmt.Sprintf("total harga tidak sesuai, harga seharusnya %d", e.Quote.Total)
+}
+
+// isiPenumpangTersimpan menyalin data dari buku penumpang user untuk setiap
+// penumpang yang mengisi penumpang_tersimpan_id. Tipe tetap ditentukan dari
+// tanggal lahir oleh NormalisasiPenumpang.
+func (s *BookingService) isiPenumpangTersimpan(ctx context.Context, userID *uint, penumpangs []models.Penumpang) error {
+	for i := range penumpangs {
+		p := &penumpangs[i]
+		if p.PenumpangTersimpanID == nil {
+			continue
+		}
+		if userID == nil {
+			return fmt.Errorf("penumpang %d: login diperlukan untuk memakai penumpang tersimpan", i+1)
+		}
+		var simpan models.PenumpangTersimpan
+		err := s.db.WithContext(ctx).
+			Where("id = ? AND user_id = ?", *p.PenumpangTersimpanID, *userID).
+			First(&simpan).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("penumpang %d: penumpang tersimpan %d tidak ditemukan", i+1, *p.PenumpangTersimpanID)
+		}
+		if err != nil {
+			return err
+		}
+		p.Nama = simpan.Nama
+		p.JenisIdentitas = simpan.JenisIdentitas
+		p.NoIdentitas = simpan.NoIdentitas
+		p.TanggalLahir = simpan.TanggalLahir
+		p.PasporBerlakuSampai = simpan.PasporBerlakuSampai
+	}
+	return nil
+}
+
+// CreateBookingWithReserve membuat satu booking untuk satu atau beberapa leg
+// dan mengunci semua kursi di semua leg dalam satu transaksi. Jika satu kursi
+// saja gagal dikunci, seluruh booking dibatalkan.
+func (s *BookingService) CreateBookingWithReserve(ctx context.Context, userID *uint, req PermintaanBooking, totalHarga int64) (*models.Booking, error) {
+	legs := req.Legs
+	penumpangs := req.Penumpangs
+	if len(legs) == 0 {
+		return nil, fmt.Errorf("booking minimal memiliki satu jadwal")
+	}
+	if len(penumpangs) == 0 {
+		return nil, fmt.Errorf("penumpang wajib diisi")
+	}
+	if req.AutoKursi {
+		if err := req.Preferensi.validasi(); err != nil {
+			return nil, err
+		}
+	}
+	if s.opsi.WajibVerifikasiEmail && userID != nil {
+		var user models.User
+		if err := s.db.WithContext(ctx).First(&user, *userID).Error; err != nil {
+			return nil, err
+		}
+		if !user.EmailTerverifikasi() {
+			return nil, ErrEmailBelumTerverifikasi
+		}
+	}
+	if err := s.isiPenumpangTersimpan(ctx, userID, penumpangs); err != nil {
+		return nil, err
 	}
 
 	var booking models.Booking
 
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		kebijakan, err := repositories.AmbilKebijakanBooking(tx)
+		if err != nil {
+			return err
+		}
+		if err := cekBatasBooking(tx, kebijakan, userID, penumpangs); err != nil {
+			return err
+		}
+
+		jadwals := make([]models.Jadwal, len(legs))
+		naiks := make([]*models.JadwalStop, len(legs))
+		turuns := make([]*models.JadwalStop, len(legs))
+		for i, leg := range legs {
+			if err := tx.Preload("Stops", func(db *gorm.DB) *gorm.DB {
+				return db.Order("urutan asc")
+			}).First(&jadwals[i], leg.ScheduleID).Error; err != nil {
+				return fmt.Errorf("jadwal %d tidak ditemukan", leg.ScheduleID)
+			}
+
+			naik, turun, err := jadwals[i].Segmen(leg.NaikStasiunID, leg.TurunStasiunID)
+			if err != nil {
+				return fmt.Errorf("leg %d: %w", i+1, err)
+			}
+			naiks[i], turuns[i] = naik, turun
+
+			if i > 0 {
+				prevTiba := jadwals[i-1].TibaDi(turuns[i-1])
+				if jadwals[i].BerangkatDari(naik).Before(prevTiba) {
+					return fmt.Errorf("leg %d berangkat sebelum leg %d tiba", i+1, i)
+				}
+			}
+		}
+
+		if err := models.ValidasiDokumenPenumpang(penumpangs, jadwals[0].BerangkatDari(naiks[0])); err != nil {
+			return err
+		}
+		if err := models.NormalisasiPenumpang(penumpangs, jadwals[0].BerangkatDari(naiks[0])); err != nil {
+			return err
+		}
+		if err := cekBatasKursiJadwal(tx, kebijakan, userID, legs, penumpangs); err != nil {
+			return err
+		}
+		if err := cekIdentitasGanda(tx, kebijakan, penumpangs, jadwals, naiks, turuns); err != nil {
+			return err
+		}
+		// Semua leg, baik kursi otomatis maupun pilihan sendiri, dikunci
+		// dalam satu putaran berurutan menurut jadwal agar dua booking
+		// multi-leg yang bersamaan tidak saling menunggu (deadlock).
+		urutanKunci := make([]int, len(legs))
+		for i := range urutanKunci {
+			urutanKunci[i] = i
+		}
+		sort.SliceStable(urutanKunci, func(a, b int) bool {
+			return legs[urutanKunci[a]].ScheduleID < legs[urutanKunci[b]].ScheduleID
+		})
+
+		berkursi := models.JumlahBerkursi(penumpangs)
+		var invIDs []uint
+		for _, i := range urutanKunci {
+			if req.AutoKursi && len(legs[i].SeatIDs) == 0 {
+				ids, err := pilihKursiOtomatis(tx, &jadwals[i], naiks[i], turuns[i], berkursi, req.Preferensi)
+				if err != nil {
+					return fmt.Errorf("leg %d: %w", i+1, err)
+				}
+				legs[i].SeatIDs = ids
+			}
+			if len(legs[i].SeatIDs) != berkursi {
+				return fmt.Errorf("leg %d: jumlah kursi (%d) harus sama dengan penumpang yang membutuhkan kursi (%d)", i+1, len(legs[i].SeatIDs), berkursi)
+			}
+
+			inv, err := s.ketersediaanRepo.FindAndLockBySegment(tx, legs[i].ScheduleID, naiks[i].Urutan, turuns[i].Urutan, legs[i].SeatIDs)
+			if err != nil {
+				return err
+			}
+			for _, r := range inv {
+				if r.Status != "available" {
+					return fmt.Errorf("kursi %d pada jadwal %d tidak tersedia", r.SeatID, legs[i].ScheduleID)
+				}
+				invIDs = append(invIDs, r.ID)
+			}
+		}
+
+		quote, konteks, err := s.tarifSvc.hitung(tx, req)
+		if err != nil {
+			return err
+		}
+
 		now := time.Now()
+		kanal, lamaHold := s.kanalHold(req.Kanal)
+		reservedUntil := now.Add(lamaHold)
 		booking = models.Booking{
 			UserID:          userID,
-			TrainScheduleID: scheduleID,
+			TrainScheduleID: legs[0].ScheduleID,
+			NaikStasiunID:   naiks[0].StasiunID,
+			TurunStasiunID:  turuns[0].StasiunID,
+			Tipe:            tipeBooking(naiks, turuns),
 			Status:          "pending",
-			TotalPrice:      total,
+			TotalPrice:      quote.Total,
+			ReservedUntil:   &reservedUntil,
+			Kanal:           kanal,
 			CreatedAt:       now,
 			UpdatedAt:       now,
 		}
@@ -44,38 +273,78 @@ func (s *BookingService) CreateBookingWithReserve(ctx context.Context, userID *u
 			return err
 		}
 
-		inv, err := s.ketersediaanRepo.FindAndLockBySchedule(tx, scheduleID, seatIDs)
-		if err != nil {
-			return err
+		if req.KodeVoucher != "" {
+			konteks.UserID = userID
+			item, err := s.voucherSvc.Reservasi(tx, req.KodeVoucher, booking.ID, *konteks)
+			if err != nil {
+				return err
+			}
+			quote.Tambah(*item)
 		}
 
-		for _, r := range inv {
-			if r.Status != "available" {
-				return fmt.Errorf("kursi %d tidak tersedia", r.SeatID)
+		if quote.Total != totalHarga {
+			return &HargaTidakSesuaiError{Quote: quote}
+		}
+		if booking.TotalPrice != quote.Total {
+			booking.TotalPrice = quote.Total
+			if err := s.bookingRepo.SimpanUpdate(tx, &booking); err != nil {
+				return err
 			}
 		}
 
-		var invIDs []uint
-		for _, r := range inv {
-			invIDs = append(invIDs, r.ID)
+		for _, item := range quote.Items {
+			item.BookingID = booking.ID
+			item.CreatedAt = now
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+			booking.Items = append(booking.Items, item)
 		}
-		if err := s.ketersediaanRepo.MarkReserved(tx, invIDs, booking.ID); err != nil {
+
+		if err := s.ketersediaanRepo.MarkReserved(tx, invIDs, booking.ID, reservedUntil); err != nil {
 			return err
 		}
 
 		now2 := time.Now()
-		for i := range penumpangs {
-			penumpangs[i].BookingID = booking.ID
-			penumpangs[i].SeatID = seatIDs[i]
-			penumpangs[i].CreatedAt = now2
-			if err := tx.Create(&penumpangs[i]).Error; err != nil {
+		for i, leg := range legs {
+			bl := models.BookingLeg{
+				BookingID:       booking.ID,
+				Urutan:          i,
+				TrainScheduleID: leg.ScheduleID,
+				NaikStasiunID:   naiks[i].StasiunID,
+				TurunStasiunID:  turuns[i].StasiunID,
+				CreatedAt:       now2,
+			}
+			if err := tx.Create(&bl).Error; err != nil {
 				return err
 			}
+			booking.Legs = append(booking.Legs, bl)
+
+			kursiKe := 0
+			for p := range penumpangs {
+				row := penumpangs[p]
+				row.ID = 0
+				row.BookingID = booking.ID
+				row.BookingLegID = bl.ID
+				row.SeatID = nil
+				if row.Berkursi() {
+					row.SeatID = &leg.SeatIDs[kursiKe]
+					kursiKe++
+				}
+				row.CreatedAt = now2
+				if err := tx.Create(&row).Error; err != nil {
+					return err
+				}
+				booking.Penumpangs = append(booking.Penumpangs, row)
+			}
 		}
 
 		return nil
 	})
 
+	if repositories.IsDeadlock(err) {
+		return nil, ErrBookingBersamaan
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +352,16 @@ func (s *BookingService) CreateBookingWithReserve(ctx context.Context, userID *u
 	return &booking, nil
 }
 
+func tipeBooking(naiks, turuns []*models.JadwalStop) string {
+	if len(naiks) == 1 {
+		return "sekali_jalan"
+	}
+	if turuns[len(turuns)-1].StasiunID == naiks[0].StasiunID {
+		return "pulang_pergi"
+	}
+	return "transit"
+}
+
 func (s *BookingService) CompleteBookingAndIssueTickets(ctx context.Context, bookingID uint) error {
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		booking, err := s.bookingRepo.GetByID(bookingID)
@@ -107,7 +386,7 @@ func (s *BookingService) CompleteBookingAndIssueTickets(ctx context.Context, boo
 		}
 
 		var penumpangs []models.Penumpang
-		if err := tx.Where("booking_id = ?", bookingID).Find(&penumpangs).Error; err != nil {
+		if err := tx.Where("booking_id = ?", bookingID).Order("booking_leg_id asc, id asc").Find(&penumpangs).Error; err != nil {
 			return err
 		}
 
@@ -115,13 +394,14 @@ func (s *BookingService) CompleteBookingAndIssueTickets(ctx context.Context, boo
 			noTiket := fmt.Sprintf("T-%d-%03d", bookingID, i+1)
 
 			tiket := models.Tiket{
-				BookingID:   bookingID,
-				PenumpangID: p.ID,
-				SeatID:      p.SeatID,
-				NoTiket:     noTiket,
-				IssuedAt:    &now,
-				CreatedAt:   now,
-				UpdatedAt:   now,
+				BookingID:    bookingID,
+				BookingLegID: p.BookingLegID,
+				PenumpangID:  p.ID,
+				SeatID:       p.SeatID,
+				NoTiket:      noTiket,
+				IssuedAt:     &now,
+				CreatedAt:    now,
+				UpdatedAt:    now,
 			}
 
 			if err := tx.Create(&tiket).Error; err != nil {
@@ -148,7 +428,12 @@ func (s *BookingService) CompleteBookingAndIssueTickets(ctx context.Context, boo
 			}
 		}
 
+		if err := SetStatusRedemption(tx, bookingID, "redeemed"); err != nil {
+			return err
+		}
+
 		booking.Status = "paid"
+		booking.ReservedUntil = nil
 		booking.UpdatedAt = now
 		if err := s.bookingRepo.SimpanUpdate(tx, booking); err != nil {
 			return err
@@ -180,6 +465,10 @@ func (s *BookingService) ReleaseBookingReservation(ctx context.Context, bookingI
 			return err
 		}
 
+		if err := SetStatusRedemption(tx, bookingID, "released"); err != nil {
+			return err
+		}
+
 		booking.Status = "cancelled"
 		booking.UpdatedAt = time.Now()
 		if err := s.bookingRepo.SimpanUpdate(tx, booking); err != nil {
@@ -189,3 +478,139 @@ func (s *BookingService) ReleaseBookingReservation(ctx context.Context, bookingI
 		return nil
 	})
 }
+
+// PerpanjangHold menambah waktu hold sekali saja, dan hanya setelah
+// pembayaran dimulai supaya hold tidak bisa diperpanjang tanpa niat bayar.
+// Kursi dan booking diperbarui bersama sehingga expires_at tetap satu.
+func (s *BookingService) PerpanjangHold(ctx context.Context, bookingID uint, userID *uint) (*models.Booking, error) {
+	var booking models.Booking
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, bookingID).Error; err != nil {
+			return err
+		}
+		if booking.UserID != nil && (userID == nil || *booking.UserID != *userID) {
+			return fmt.Errorf("tidak berhak memperpanjang booking ini")
+		}
+		if booking.Status != "pending" {
+			return fmt.Errorf("booking tidak dalam status pending")
+		}
+		if booking.DiperpanjangPada != nil {
+			return ErrHoldSudahDiperpanjang
+		}
+		now := time.Now()
+		if booking.ReservedUntil == nil || !booking.ReservedUntil.After(now) {
+			return ErrHoldKedaluwarsa
+		}
+
+		var jumlahPayment int64
+		if err := tx.Model(&models.Payment{}).
+			Where("booking_id = ? AND status IN ?", bookingID, []string{"created", "pending"}).
+			Count(&jumlahPayment).Error; err != nil {
+			return err
+		}
+		if jumlahPayment == 0 {
+			return ErrPembayaranBelumDimulai
+		}
+
+		baru := booking.ReservedUntil.Add(time.Duration(s.opsi.PerpanjangHoldMenit) * time.Minute)
+		n, err := s.ketersediaanRepo.PerpanjangReserved(tx, bookingID, baru)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrHoldKedaluwarsa
+		}
+
+		booking.ReservedUntil = &baru
+		booking.DiperpanjangPada = &now
+		return tx.Model(&booking).Updates(map[string]interface{}{
+			"reserved_until":    baru,
+			"diperpanjang_pada": now,
+			"updated_at":        now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// TerapkanVoucher memasang voucher pada booking pending yang belum memulai
+// pembayaran. Kuota voucher ditahan sampai booking dibayar atau dilepas.
+func (s *BookingService) TerapkanVoucher(ctx context.Context, bookingID uint, userID *uint, kode string) (*models.Booking, error) {
+	var booking models.Booking
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Legs", func(db *gorm.DB) *gorm.DB {
+				return db.Order("urutan asc")
+			}).
+			Preload("Legs.TrainSchedule").
+			Preload("Items").
+			First(&booking, bookingID).Error; err != nil {
+			return err
+		}
+
+		if booking.UserID != nil && (userID == nil || *booking.UserID != *userID) {
+			return fmt.Errorf("tidak berhak mengubah booking ini")
+		}
+		if booking.Status != "pending" {
+			return fmt.Errorf("booking tidak dalam status pending")
+		}
+
+		var jumlahPayment int64
+		if err := tx.Model(&models.Payment{}).Where("booking_id = ?", bookingID).Count(&jumlahPayment).Error; err != nil {
+			return err
+		}
+		if jumlahPayment > 0 {
+			return fmt.Errorf("voucher tidak bisa dipasang setelah pembayaran dimulai")
+		}
+
+		var jumlahVoucher int64
+		if err := tx.Model(&models.VoucherRedemption{}).
+			Where("booking_id = ? AND status <> ?", bookingID, "released").
+			Count(&jumlahVoucher).Error; err != nil {
+			return err
+		}
+		if jumlahVoucher > 0 {
+			return fmt.Errorf("booking sudah memakai voucher")
+		}
+
+		konteks := KonteksVoucher{
+			UserID:   userID,
+			AsalID:   booking.NaikStasiunID,
+			TujuanID: booking.TurunStasiunID,
+			Subtotal: booking.TotalPrice,
+		}
+		for _, leg := range booking.Legs {
+			konteks.Kelas = append(konteks.Kelas, leg.TrainSchedule.Kelas)
+			if leg.Urutan > 0 && leg.TurunStasiunID != booking.NaikStasiunID {
+				konteks.TujuanID = leg.TurunStasiunID
+			}
+		}
+
+		item, err := s.voucherSvc.Reservasi(tx, kode, bookingID, konteks)
+		if err != nil {
+			return err
+		}
+		item.BookingID = bookingID
+		item.CreatedAt = time.Now()
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		booking.Items = append(booking.Items, *item)
+
+		booking.TotalPrice += item.Subtotal
+		booking.UpdatedAt = time.Now()
+		return tx.Model(&models.Booking{}).Where("id = ?", bookingID).
+			Updates(map[string]interface{}{
+				"total_price": booking.TotalPrice,
+				"updated_at":  booking.UpdatedAt,
+			}).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+)
+
+func TestCreateBookingDewasaDanBayi(t *testing.T) {
+	conn := dbUji(t)
+	jadwal, kursis := jadwalUji(t, conn, "eksekutif", 200000, 4)
+	svc := bookingServiceUji(conn, "")
+	// layanan dibaca di dalam transaksi booking; dbUji hanya punya satu
+	// koneksi sehingga query di luar transaksi akan macet
+	if err := conn.Create(&models.LayananTambahan{Kode: "BAGASI", Nama: "Bagasi", Harga: 25000, Aktif: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	lahirBayi := time.Now().AddDate(-1, 0, 0).Format("2006-01-02")
+	req := PermintaanBooking{
+		Legs: []BookingLegInput{{ScheduleID: jadwal.ID, SeatIDs: []uint{kursis[0].ID}}},
+		Penumpangs: []models.Penumpang{
+			{Nama: "Budi", NoIdentitas: "3171010101900001"},
+			{Nama: "Ani", TanggalLahir: lahirBayi},
+		},
+		Layanan: []LayananInput{{Kode: "BAGASI", Jumlah: 1}},
+	}
+	quote, err := svc.tarifSvc.Quote(context.Background(), nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	booking, err := svc.CreateBookingWithReserve(context.Background(), nil, req, quote.Total)
+	if err != nil {
+		t.Fatalf("booking dewasa + bayi gagal: %v", err)
+	}
+
+	var penumpangs []models.Penumpang
+	if err := conn.Where("booking_id = ?", booking.ID).Order("id asc").Find(&penumpangs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(penumpangs) != 2 {
+		t.Fatalf("tersimpan %d penumpang, want 2", len(penumpangs))
+	}
+	if p := penumpangs[0]; p.Tipe != models.TipeDewasa || p.SeatID == nil || *p.SeatID != kursis[0].ID {
+		t.Errorf("dewasa: tipe %q seat %v, want dewasa di kursi %d", p.Tipe, p.SeatID, kursis[0].ID)
+	}
+	if p := penumpangs[1]; p.Tipe != models.TipeBayi || p.SeatID != nil {
+		t.Errorf("bayi: tipe %q seat %v, want bayi tanpa kursi", p.Tipe, p.SeatID)
+	}
+
+	var reserved int64
+	if err := conn.Model(&models.KetersediaanKursi{}).
+		Where("reserved_by_booking = ? AND status = ?", booking.ID, "reserved").
+		Count(&reserved).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reserved != int64(jadwal.JumlahSegmen()) {
+		t.Errorf("%d baris ketersediaan direservasi, want %d (satu kursi di semua segmen)", reserved, jadwal.JumlahSegmen())
+	}
+}
+
+func TestBatasKursiPerJadwalTidakMenghitungBayi(t *testing.T) {
+	conn := dbUji(t)
+	jadwal, kursis := jadwalUji(t, conn, "eksekutif", 200000, 4)
+	svc := bookingServiceUji(conn, "")
+
+	user := models.User{Email: "budi@example.com", Fullname: "Budi"}
+	if err := conn.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	kebijakan := models.KebijakanBookingBawaan()
+	kebijakan.MaksKursiPerJadwal = 1
+	if err := conn.Create(&kebijakan).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	pesan := func(kursiID uint, penumpangs ...models.Penumpang) error {
+		req := PermintaanBooking{
+			Legs:       []BookingLegInput{{ScheduleID: jadwal.ID, SeatIDs: []uint{kursiID}}},
+			Penumpangs: penumpangs,
+		}
+		quote, err := svc.tarifSvc.Quote(context.Background(), &user.ID, req)
+		if err != nil {
+			return err
+		}
+		_, err = svc.CreateBookingWithReserve(context.Background(), &user.ID, req, quote.Total)
+		return err
+	}
+
+	// bayi hanya mengisi tanggal lahir; tipenya baru diketahui setelah
+	// normalisasi dan tidak boleh dihitung sebagai kursi
+	lahirBayi := time.Now().AddDate(-1, 0, 0).Format("2006-01-02")
+	if err := pesan(kursis[0].ID,
+		models.Penumpang{Nama: "Budi", NoIdentitas: "3171010101900001"},
+		models.Penumpang{Nama: "Ani", TanggalLahir: lahirBayi},
+	); err != nil {
+		t.Fatalf("dewasa + bayi dengan batas 1 kursi ditolak: %v", err)
+	}
+
+	err := pesan(kursis[1].ID, models.Penumpang{Nama: "Citra", NoIdentitas: "3171014101900002"})
+	var batas *BatasBookingError
+	if !errors.As(err, &batas) || batas.Kode != KodeMaksKursiJadwal {
+		t.Fatalf("kursi kedua di jadwal yang sama: err = %v, want %s", err, KodeMaksKursiJadwal)
+	}
+}
@@ -78,7 +78,30 @@ func cleanupOnce(db *gorm.DB) {
 				continue
 			}
 
+			if err := SetStatusRedemption(db, b.ID, "released"); err != nil {
+				log.Printf("[cleanup] gagal melepas voucher booking %d: %v", b.ID, err)
+			}
+
 			log.Printf("[cleanup] booking %d otomatis dibatalkan (kursi expired)", b.ID)
 		}
 	}
+
+	releaseVoucherBatal(db, now)
+}
+
+// releaseVoucherBatal melepas voucher yang masih reserved untuk booking yang
+// sudah dibatalkan lewat jalur lain (hapus booking, pembayaran gagal).
+func releaseVoucherBatal(db *gorm.DB, now time.Time) {
+	res := db.Exec(`
+		UPDATE voucher_redemptions r
+		JOIN bookings b ON b.id = r.booking_id
+		SET r.status = 'released', r.updated_at = ?
+		WHERE r.status = 'reserved' AND b.status IN ('cancelled', 'expired')`, now)
+	if res.Error != nil {
+		log.Printf("[cleanup] gagal melepas voucher: %v", res.Error)
+		return
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("[cleanup] melepas %d voucher dari booking batal", res.RowsAffected)
+	}
 }
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+var ErrKonfirmasiPasswordSalah = errors.New("password tidak sesuai")
+
+const namaAnonim = "Pengguna dihapus"
+
+// ArsipDataPribadi adalah seluruh data pribadi user yang diekspor.
+type ArsipDataPribadi struct {
+	DibuatPada         time.Time                   `json:"dibuat_pada"`
+	Profil             ProfilArsip                 `json:"profil"`
+	PenumpangTersimpan []models.PenumpangTersimpan `json:"penumpang_tersimpan"`
+	Bookings           []models.Booking            `json:"bookings"`
+	Tiket              []models.Tiket              `json:"tiket"`
+	Payments           []models.Payment            `json:"payments"`
+	RiwayatLogin       []models.PercobaanLogin     `json:"riwayat_login"`
+}
+
+type ProfilArsip struct {
+	ID                     uint       `json:"id"`
+	Email                  string     `json:"email"`
+	Fullname               string     `json:"fullname"`
+	Roles                  []string   `json:"roles"`
+	EmailTerverifikasiPada *time.Time `json:"email_terverifikasi_pada"`
+	CreatedAt              time.Time  `json:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at"`
+}
+
+// DataPribadiService menangani ekspor dan penghapusan data pribadi user.
+// Booking, tiket, dan payment tidak dihapus karena dibutuhkan untuk
+// pembukuan; yang dihapus hanya data yang mengidentifikasi orang.
+type DataPribadiService struct {
+	db               *gorm.DB
+	users            repositories.UserRepo
+	ketersediaanRepo repositories.KetersediaanRepo
+}
+
+func NewDataPribadiService(db *gorm.DB, users repositories.UserRepo, kr repositories.KetersediaanRepo) *DataPribadiService {
+	return &DataPribadiService{db: db, users: users, ketersediaanRepo: kr}
+}
+
+func (s *DataPribadiService) Ekspor(ctx context.Context, userID uint) (*ArsipDataPribadi, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+
+	arsip := &ArsipDataPribadi{
+		DibuatPada: time.Now(),
+		Profil: ProfilArsip{
+			ID:                     user.ID,
+			Email:                  user.Email,
+			Fullname:               user.Fullname,
+			Roles:                  user.DaftarRole(),
+			EmailTerverifikasiPada: user.EmailTerverifikasiPada,
+			CreatedAt:              user.CreatedAt,
+			UpdatedAt:              user.UpdatedAt,
+		},
+	}
+
+	db := s.db.WithContext(ctx)
+	if err := db.Where("user_id = ?", userID).Order("id asc").Find(&arsip.PenumpangTersimpan).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Preload("Penumpangs").
+		Preload("Items").
+		Preload("Legs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan asc")
+		}).
+		Where("user_id = ?", userID).
+		Order("id asc").
+		Find(&arsip.Bookings).Error; err != nil {
+		return nil, err
+	}
+
+	bookingIDs := make([]uint, len(arsip.Bookings))
+	for i, b := range arsip.Bookings {
+		bookingIDs[i] = b.ID
+	}
+	if len(bookingIDs) > 0 {
+		if err := db.Where("booking_id IN ?", bookingIDs).Order("id asc").Find(&arsip.Tiket).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Where("booking_id IN ?", bookingIDs).Order("id asc").Find(&arsip.Payments).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := db.Where("user_id = ? OR email = ?", userID, user.Email).Order("created_at asc").Find(&arsip.RiwayatLogin).Error; err != nil {
+		return nil, err
+	}
+	return arsip, nil
+}
+
+// HapusAkun menganonimkan akun setelah konfirmasi password. Booking pending
+// dibatalkan dan kursinya dilepas. Baris user tetap ada (dengan email dan
+// nama anonim) supaya booking lama masih punya pemilik untuk pembukuan,
+// tetapi tidak bisa dipakai login lagi dan semua token dicabut.
+func (s *DataPribadiService) HapusAkun(ctx context.Context, userID uint, password string) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if !models.MatchesHash(password, user.Password) {
+		return ErrKonfirmasiPasswordSalah
+	}
+
+	now := time.Now()
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pending []models.Booking
+		if err := tx.Where("user_id = ? AND status = ?", userID, "pending").Find(&pending).Error; err != nil {
+			return err
+		}
+		for _, b := range pending {
+			if err := s.ketersediaanRepo.ReleaseByBooking(tx, b.ID); err != nil {
+				return err
+			}
+			if err := SetStatusRedemption(tx, b.ID, "released"); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Booking{}).Where("id = ?", b.ID).
+				Updates(map[string]interface{}{"status": "cancelled", "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+
+		bookingIDs := tx.Model(&models.Booking{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Model(&models.Penumpang{}).Where("booking_id IN (?)", bookingIDs).
+			Updates(map[string]interface{}{
+				"nama":                   namaAnonim,
+				"no_identitas":           "",
+				"no_identitas_idx":       "",
+				"jenis_identitas":        "",
+				"tanggal_lahir":          "",
+				"paspor_berlaku_sampai":  "",
+				"penumpang_tersimpan_id": nil,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.PenumpangTersimpan{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? OR email = ?", userID, user.Email).Delete(&models.PercobaanLogin{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TokenAkun{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+
+		// password kosong tidak pernah cocok dengan bcrypt, jadi akun tidak
+		// bisa dipakai login lagi
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"email":                    fmt.Sprintf("dihapus-%d@mooove.invalid", userID),
+			"fullname":                 namaAnonim,
+			"password":                 "",
+			"email_terverifikasi_pada": nil,
+			"token_dicabut_pada":       now,
+			"dihapus_pada":             now,
+		}).Error
+	})
+}
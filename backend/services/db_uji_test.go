@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/fitranmei/Mooove-/backend/db"
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+var aturPenyandiUji sync.Once
+
+// aturPenyandiDev memasang penyandi identitas dengan kunci pengembangan
+// untuk seluruh test di paket ini.
+func aturPenyandiDev(t *testing.T) {
+	t.Helper()
+	aturPenyandiUji.Do(func() {
+		p, err := NewPenyandiIdentitasDariConfig("", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		models.SetPenyandiIdentitas(p)
+	})
+}
+
+// dbUji membuka database SQLite di memori dengan foreign key aktif dan semua
+// tabel dari db.SemuaModel. Setiap test mendapat database sendiri.
+func dbUji(t *testing.T) *gorm.DB {
+	t.Helper()
+	aturPenyandiDev(t)
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_pragma=foreign_keys(1)", t.Name())
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// satu koneksi: query yang lupa memakai transaksi booking akan macet
+	// alih-alih lolos diam-diam
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// SQLite tidak mengenal kolom enum MySQL; ganti dengan text di schema
+	// yang sudah di-cache database ini sebelum tabel dibuat.
+	for _, m := range db.SemuaModel() {
+		stmt := &gorm.Statement{DB: conn}
+		if err := stmt.Parse(m); err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range stmt.Schema.Fields {
+			if strings.HasPrefix(string(f.DataType), "enum(") {
+				f.DataType = "text"
+			}
+		}
+	}
+	if err := conn.AutoMigrate(db.SemuaModel()...); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// jadwalUji menyimpan satu kereta dengan satu gerbong 2-2 berisi kapasitas
+// kursi dan jadwal GMR-BD-CN besok beserta inventori kursinya.
+func jadwalUji(t *testing.T, conn *gorm.DB, kelas string, harga int64, kapasitas int) (*models.Jadwal, []models.Kursi) {
+	t.Helper()
+	stasiuns := []models.Stasiun{
+		{Kode: "GMR", Nama: "Gambir"},
+		{Kode: "BD", Nama: "Bandung"},
+		{Kode: "CN", Nama: "Cirebon"},
+	}
+	if err := conn.Create(&stasiuns).Error; err != nil {
+		t.Fatal(err)
+	}
+	kereta := models.Kereta{Nama: "Argo Uji"}
+	if err := conn.Create(&kereta).Error; err != nil {
+		t.Fatal(err)
+	}
+	gerbong := models.Gerbong{KeretaID: kereta.ID, NomorGerbong: 1, Kelas: kelas, KapasitasKursi: kapasitas}
+	if err := conn.Create(&gerbong).Error; err != nil {
+		t.Fatal(err)
+	}
+	kursis := models.TataLetakStandar.Susun(gerbong.ID, kapasitas)
+	if err := conn.Create(&kursis).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	berangkat := time.Now().Add(24 * time.Hour).Truncate(time.Minute)
+	tibaBD := berangkat.Add(3 * time.Hour)
+	berangkatBD := tibaBD.Add(5 * time.Minute)
+	tiba := berangkat.Add(6 * time.Hour)
+	j := &models.Jadwal{
+		KeretaID:       kereta.ID,
+		WaktuBerangkat: berangkat,
+		WaktuTiba:      tiba,
+		Tanggal:        berangkat.Format("2006-01-02"),
+		Kelas:          kelas,
+		Harga:          harga,
+		Stops: []models.JadwalStop{
+			{StasiunID: stasiuns[0].ID, WaktuBerangkat: &berangkat},
+			{StasiunID: stasiuns[1].ID, WaktuTiba: &tibaBD, WaktuBerangkat: &berangkatBD, HargaKumulatif: harga / 2},
+			{StasiunID: stasiuns[2].ID, WaktuTiba: &tiba, HargaKumulatif: harga},
+		},
+	}
+	if err := repositories.NewJadwalRepo(conn).BuatTx(conn, j); err != nil {
+		t.Fatal(err)
+	}
+	return j, kursis
+}
+
+// bookingServiceUji merangkai BookingService seperti di cmd/api tanpa
+// faktor kelas dan diskon selain bawaan.
+func bookingServiceUji(conn *gorm.DB, diskonTipe string) *BookingService {
+	jadwalRepo := repositories.NewJadwalRepo(conn)
+	voucher := NewVoucherService(conn)
+	tarif := NewTarifService(conn, repositories.NewLayananRepo(conn), jadwalRepo, voucher,
+		NewHargaDinamisService(repositories.NewAturanHargaRepo(conn)), "", diskonTipe)
+	return NewBookingService(conn, repositories.NewBookingRepo(conn), repositories.NewKetersediaanRepo(conn), tarif, voucher, OpsiBooking{})
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PengirimEmail mengirim email teks biasa. Implementasi dipilih lewat
+// MAIL_DRIVER: smtp untuk produksi, file/log untuk lokal dan pengujian.
+type PengirimEmail interface {
+	Kirim(ctx context.Context, ke, subjek, isi string) error
+}
+
+type PengirimSMTP struct {
+	host string
+	port string
+	user string
+	pass string
+	dari string
+}
+
+func NewPengirimSMTP(host, port, user, pass, dari string) *PengirimSMTP {
+	return &PengirimSMTP{host: host, port: port, user: user, pass: pass, dari: dari}
+}
+
+func (p *PengirimSMTP) Kirim(ctx context.Context, ke, subjek, isi string) error {
+	var auth smtp.Auth
+	if p.user != "" {
+		auth = smtp.PlainAuth("", p.user, p.pass, p.host)
+	}
+	// envelope sender (MAIL FROM) hanya alamatnya, tanpa nama tampilan
+	pengirim, err := mail.ParseAddress(p.dari)
+	if err != nil {
+		return fmt.Errorf("MAIL_FROM tidak valid: %w", err)
+	}
+	return smtp.SendMail(net.JoinHostPort(p.host, p.port), auth, pengirim.Address, []string{ke}, formatEmail(p.dari, ke, subjek, isi))
+}
+
+// PengirimFile menulis setiap email sebagai file .eml di dir. Jika dir
+// kosong, email hanya dicetak ke log.
+type PengirimFile struct {
+	dir  string
+	dari string
+}
+
+func NewPengirimFile(dir, dari string) *PengirimFile {
+	return &PengirimFile{dir: dir, dari: dari}
+}
+
+func (p *PengirimFile) Kirim(ctx context.Context, ke, subjek, isi string) error {
+	msg := formatEmail(p.dari, ke, subjek, isi)
+	if p.dir == "" {
+		log.Printf("[mail] ke=%s subjek=%q\n%s", ke, subjek, isi)
+		return nil
+	}
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return err
+	}
+	nama := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000"), strings.NewReplacer("@", "_at_", "/", "_").Replace(ke))
+	path := filepath.Join(p.dir, nama)
+	if err := os.WriteFile(path, msg, 0o644); err != nil {
+		return err
+	}
+	log.Printf("[mail] email ke %s ditulis ke %s", ke, path)
+	return nil
+}
+
+func formatEmail(dari, ke, subjek, isi string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", dari)
+	fmt.Fprintf(&b, "To: %s\r\n", ke)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subjek)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(isi, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// NewPengirimEmail memilih implementasi berdasarkan driver (smtp, file, log).
+func NewPengirimEmail(driver, host, port, user, pass, dari, dir string) PengirimEmail {
+	switch driver {
+	case "smtp":
+		return NewPengirimSMTP(host, port, user, pass, dari)
+	case "log":
+		return NewPengirimFile("", dari)
+	default:
+		return NewPengirimFile(dir, dari)
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// PenyediaKunci membungkus (wrap) dan membuka data key memakai key
+// encryption key (KEK). Implementasi env menyimpan KEK di memori; penyedia
+// KMS cukup memanggil API encrypt/decrypt miliknya tanpa pernah membuka KEK.
+type PenyediaKunci interface {
+	KidAktif() string
+	Bungkus(dek []byte) (kid string, terbungkus []byte, err error)
+	Buka(kid string, terbungkus []byte) ([]byte, error)
+}
+
+// PenyediaKunciEnv membaca KEK dari config dengan format
+// "kid:base64,kid2:base64". Kunci pertama dipakai untuk enkripsi baru,
+// sisanya hanya untuk membaca data lama sampai dienkripsi ulang.
+type PenyediaKunciEnv struct {
+	aktif string
+	kunci map[string]cipher.AEAD
+}
+
+func NewPenyediaKunciEnv(nama, spec string) (*PenyediaKunciEnv, error) {
+	p := &PenyediaKunciEnv{kunci: map[string]cipher.AEAD{}}
+	for _, bagian := range strings.Split(spec, ",") {
+		bagian = strings.TrimSpace(bagian)
+		if bagian == "" {
+			continue
+		}
+		kid, b64, ok := strings.Cut(bagian, ":")
+		if !ok || kid == "" || strings.Contains(kid, ".") {
+			return nil, fmt.Errorf("%s: format harus kid:base64", nama)
+		}
+		raw, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("%s %s: kunci harus 32 byte base64", nama, kid)
+		}
+		aead, err := aeadDari(raw)
+		if err != nil {
+			return nil, err
+		}
+		p.kunci[kid] = aead
+		if p.aktif == "" {
+			p.aktif = kid
+		}
+	}
+	if p.aktif == "" {
+		return nil, fmt.Errorf("%s kosong", nama)
+	}
+	return p, nil
+}
+
+func (p *PenyediaKunciEnv) KidAktif() string {
+	return p.aktif
+}
+
+func (p *PenyediaKunciEnv) Bungkus(dek []byte) (string, []byte, error) {
+	sandi, err := segel(p.kunci[p.aktif], dek)
+	return p.aktif, sandi, err
+}
+
+func (p *PenyediaKunciEnv) Buka(kid string, terbungkus []byte) ([]byte, error) {
+	aead, ok := p.kunci[kid]
+	if !ok {
+		return nil, fmt.Errorf("kunci %s tidak tersedia", kid)
+	}
+	return bukaSegel(aead, terbungkus)
+}
+
+func aeadDari(kunci []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(kunci)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// segel mengembalikan nonce||ciphertext.
+func segel(aead cipher.AEAD, plain []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+func bukaSegel(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext terlalu pendek")
+	}
+	n := aead.NonceSize()
+	return aead.Open(nil, data[:n], data[n:], nil)
+}
+
+const awalanSandi = "enc1."
+
+// PenyandiAmplop memakai envelope encryption: setiap nilai dienkripsi dengan
+// data key acak (AES-256-GCM), lalu data key dibungkus oleh PenyediaKunci.
+// Format tersimpan: enc1.<kid>.<dek terbungkus>.<nonce+ciphertext>.
+type PenyandiAmplop struct {
+	penyedia    PenyediaKunci
+	kunciIndeks []byte
+}
+
+func NewPenyandiAmplop(penyedia PenyediaKunci, kunciIndeks []byte) *PenyandiAmplop {
+	return &PenyandiAmplop{penyedia: penyedia, kunciIndeks: kunciIndeks}
+}
+
+func (p *PenyandiAmplop) Enkripsi(plain string) (string, error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	aead, err := aeadDari(dek)
+	if err != nil {
+		return "", err
+	}
+	sandi, err := segel(aead, []byte(plain))
+	if err != nil {
+		return "", err
+	}
+	kid, terbungkus, err := p.penyedia.Bungkus(dek)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return awalanSandi + kid + "." + enc.EncodeToString(terbungkus) + "." + enc.EncodeToString(sandi), nil
+}
+
+func (p *PenyandiAmplop) Dekripsi(nilai string) (string, error) {
+	bagian := strings.Split(strings.TrimPrefix(nilai, awalanSandi), ".")
+	if len(bagian) != 3 {
+		return "", errors.New("format identitas terenkripsi tidak valid")
+	}
+	enc := base64.RawURLEncoding
+	terbungkus, err := enc.DecodeString(bagian[1])
+	if err != nil {
+		return "", err
+	}
+	sandi, err := enc.DecodeString(bagian[2])
+	if err != nil {
+		return "", err
+	}
+	dek, err := p.penyedia.Buka(bagian[0], terbungkus)
+	if err != nil {
+		return "", err
+	}
+	aead, err := aeadDari(dek)
+	if err != nil {
+		return "", err
+	}
+	plain, err := bukaSegel(aead, sandi)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (p *PenyandiAmplop) Tersandi(nilai string) bool {
+	return strings.HasPrefix(nilai, awalanSandi)
+}
+
+// PerluSandiUlang bernilai true untuk plaintext lama atau nilai yang
+// dibungkus kunci selain kunci aktif.
+func (p *PenyandiAmplop) PerluSandiUlang(nilai string) bool {
+	if nilai == "" {
+		return false
+	}
+	return !strings.HasPrefix(nilai, awalanSandi+p.penyedia.KidAktif()+".")
+}
+
+// Indeks adalah HMAC-SHA256 dengan kunci terpisah dari kunci enkripsi,
+// sehingga nilai yang sama selalu menghasilkan indeks yang sama.
+func (p *PenyandiAmplop) Indeks(plain string) string {
+	mac := hmac.New(sha256.New, p.kunciIndeks)
+	mac.Write([]byte(plain))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// kunciDev adalah kunci tetap yang diketahui publik, hanya untuk
+// pengembangan lokal lewat IDENTITAS_KUNCI_DEV=true.
+func kunciDev(label string) []byte {
+	h := sha256.Sum256([]byte("mooove-dev:" + label))
+	return h[:]
+}
+
+// NewPenyandiIdentitasDariConfig membuat penyandi dari IDENTITAS_KUNCI dan
+// IDENTITAS_KUNCI_INDEKS. Keduanya wajib diisi; kunci pengembangan hanya
+// dipakai bila dev bernilai true.
+func NewPenyandiIdentitasDariConfig(spec, indeksB64 string, dev bool) (*PenyandiAmplop, error) {
+	if (spec == "" || indeksB64 == "") && !dev {
+		return nil, errors.New("IDENTITAS_KUNCI dan IDENTITAS_KUNCI_INDEKS wajib diisi (IDENTITAS_KUNCI_DEV=true untuk pengembangan lokal)")
+	}
+	if spec == "" {
+		log.Printf("[identitas] IDENTITAS_KUNCI kosong, memakai kunci pengembangan; jangan dipakai di production")
+		spec = "dev:" + base64.StdEncoding.EncodeToString(kunciDev("identitas-kek"))
+	}
+	penyedia, err := NewPenyediaKunciEnv("IDENTITAS_KUNCI", spec)
+	if err != nil {
+		return nil, err
+	}
+
+	indeks := kunciDev("identitas-indeks")
+	if indeksB64 != "" {
+		indeks, err = base64.StdEncoding.DecodeString(indeksB64)
+		if err != nil || len(indeks) < 32 {
+			return nil, errors.New("IDENTITAS_KUNCI_INDEKS harus minimal 32 byte base64")
+		}
+	} else {
+		log.Printf("[identitas] IDENTITAS_KUNCI_INDEKS kosong, memakai kunci pengembangan; jangan dipakai di production")
+	}
+	return NewPenyandiAmplop(penyedia, indeks), nil
+}
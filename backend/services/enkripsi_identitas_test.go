@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+)
+
+// kunciUji adalah kunci 32 byte base64 yang berisi satu byte berulang.
+func kunciUji(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func penyandiUji(t *testing.T, spec string, indeks byte) *PenyandiAmplop {
+	t.Helper()
+	p, err := NewPenyandiIdentitasDariConfig(spec, kunciUji(indeks), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPenyandiAmplopRoundTrip(t *testing.T) {
+	p := penyandiUji(t, "k1:"+kunciUji(1), 9)
+	for _, plain := range []string{"3171010101900001", "A1234567", "123456789012", "nomor-lama dengan spasi"} {
+		t.Run(plain, func(t *testing.T) {
+			sandi, err := p.Enkripsi(plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !p.Tersandi(sandi) || strings.Contains(sandi, plain) {
+				t.Fatalf("Enkripsi(%q) = %q, want nilai tersandi tanpa plaintext", plain, sandi)
+			}
+			got, err := p.Dekripsi(sandi)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != plain {
+				t.Errorf("Dekripsi = %q, want %q", got, plain)
+			}
+
+			// DEK acak per nilai: dua enkripsi tidak pernah sama
+			lagi, err := p.Enkripsi(plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lagi == sandi {
+				t.Error("dua enkripsi nilai yang sama menghasilkan sandi yang sama")
+			}
+		})
+	}
+}
+
+func TestPenyandiAmplopRotasiKunci(t *testing.T) {
+	lama := penyandiUji(t, "k1:"+kunciUji(1), 9)
+	sandiLama, err := lama.Enkripsi("3171010101900001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baru := penyandiUji(t, "k2:"+kunciUji(2)+",k1:"+kunciUji(1), 9)
+	got, err := baru.Dekripsi(sandiLama)
+	if err != nil || got != "3171010101900001" {
+		t.Fatalf("kunci lama tidak terbaca setelah rotasi: %q, %v", got, err)
+	}
+	if !baru.PerluSandiUlang(sandiLama) || !baru.PerluSandiUlang("3171010101900001") {
+		t.Error("sandi kunci lama dan plaintext seharusnya perlu disandi ulang")
+	}
+	sandiBaru, err := baru.Enkripsi(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baru.PerluSandiUlang(sandiBaru) || baru.PerluSandiUlang("") {
+		t.Error("sandi kunci aktif dan nilai kosong tidak perlu disandi ulang")
+	}
+
+	tanpaLama := penyandiUji(t, "k2:"+kunciUji(2), 9)
+	if _, err := tanpaLama.Dekripsi(sandiLama); err == nil {
+		t.Error("sandi kunci yang sudah dibuang tetap terbaca")
+	}
+	// ubah satu karakter di tengah ciphertext; tag GCM harus menolaknya
+	rusak := []byte(sandiLama)
+	i := strings.LastIndex(sandiLama, ".") + 4
+	if rusak[i] == 'A' {
+		rusak[i] = 'B'
+	} else {
+		rusak[i] = 'A'
+	}
+	if _, err := baru.Dekripsi(string(rusak)); err == nil {
+		t.Error("sandi yang diubah tetap terbaca")
+	}
+}
+
+func TestIndeksIdentitasStabil(t *testing.T) {
+	p := penyandiUji(t, "k1:"+kunciUji(1), 9)
+	// indeks hanya bergantung pada kunci indeks, bukan kunci enkripsi
+	pRotasi := penyandiUji(t, "k2:"+kunciUji(2), 9)
+	pIndeksLain := penyandiUji(t, "k1:"+kunciUji(1), 8)
+
+	// nilai tetap: indeks yang tersimpan di database harus tetap cocok
+	// setelah restart atau rotasi kunci enkripsi
+	const want = "f20caab74b1d88764c94c77c229ace10e7eb374101a1dfa821c9bdb2fb033df8"
+	if got := p.Indeks("3171010101900001"); got != want {
+		t.Errorf("Indeks = %s, want %s (HMAC-SHA256 dengan kunci indeks)", got, want)
+	}
+	if p.Indeks("3171010101900001") != pRotasi.Indeks("3171010101900001") {
+		t.Error("indeks berubah setelah rotasi kunci enkripsi")
+	}
+	if p.Indeks("3171010101900001") == pIndeksLain.Indeks("3171010101900001") {
+		t.Error("kunci indeks berbeda menghasilkan indeks yang sama")
+	}
+
+	aturPenyandiDev(t)
+	tests := []struct {
+		a, b string
+		sama bool
+	}{
+		{a: "3171010101900001", b: "3171 0101-0190 0001", sama: true},
+		{a: "A1234567", b: "a1234567", sama: true},
+		{a: "A1234567", b: "A.123.4567", sama: true},
+		{a: "3171010101900001", b: "3171010101900002", sama: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"|"+tt.b, func(t *testing.T) {
+			if sama := models.IndeksIdentitas(tt.a) == models.IndeksIdentitas(tt.b); sama != tt.sama {
+				t.Errorf("IndeksIdentitas(%q) == IndeksIdentitas(%q) = %v, want %v", tt.a, tt.b, sama, tt.sama)
+			}
+		})
+	}
+	if got := models.IndeksIdentitas(" - "); got != "" {
+		t.Errorf("IndeksIdentitas nomor kosong = %q, want kosong", got)
+	}
+}
+
+func TestIdentitasValueScan(t *testing.T) {
+	aturPenyandiDev(t)
+
+	v, err := models.Identitas("3171010101900001").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sandi, ok := v.(string)
+	if !ok || !strings.HasPrefix(sandi, awalanSandi) {
+		t.Fatalf("Value = %v, want sandi berawalan %s", v, awalanSandi)
+	}
+
+	tests := []struct {
+		nama string
+		src  interface{}
+		want models.Identitas
+	}{
+		{nama: "sandi string", src: sandi, want: "3171010101900001"},
+		{nama: "sandi bytes", src: []byte(sandi), want: "3171010101900001"},
+		{nama: "plaintext lama", src: "3171010101900001", want: "3171010101900001"},
+		{nama: "null", src: nil, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			var got models.Identitas
+			if err := got.Scan(tt.src); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
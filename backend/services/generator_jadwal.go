@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+const formatTanggal = "2006-01-02"
+
+// GeneratorJadwalService membentuk Jadwal beserta stop dan inventori kursinya
+// dari TemplateJadwal untuk rentang hari ke depan. Generator idempoten: satu
+// template hanya menghasilkan satu jadwal per tanggal per kelas, jadi aman
+// dijalankan berulang kali. Untuk membatalkan satu tanggal gunakan pengecualian
+// template, bukan menghapus jadwalnya.
+type GeneratorJadwalService struct {
+	db         *gorm.DB
+	repo       repositories.TemplateJadwalRepo
+	jadwalRepo repositories.JadwalRepo
+	hari       int
+}
+
+func NewGeneratorJadwalService(db *gorm.DB, repo repositories.TemplateJadwalRepo, jr repositories.JadwalRepo, hari int) *GeneratorJadwalService {
+	if hari <= 0 {
+		hari = 30
+	}
+	return &GeneratorJadwalService{db: db, repo: repo, jadwalRepo: jr, hari: hari}
+}
+
+// ValidasiTemplate memeriksa isian template sebelum disimpan.
+func ValidasiTemplate(t *models.TemplateJadwal) error {
+	if t.KeretaID == 0 {
+		return errors.New("kereta_id wajib diisi")
+	}
+	if _, err := time.Parse("15:04", t.JamBerangkat); err != nil {
+		return errors.New("jam_berangkat harus berformat HH:MM")
+	}
+	if _, err := parseHariOperasi(t.HariOperasi); err != nil {
+		return err
+	}
+	mulai, err := time.Parse(formatTanggal, t.BerlakuMulai)
+	if err != nil {
+		return errors.New("berlaku_mulai harus berformat YYYY-MM-DD")
+	}
+	if t.BerlakuSampai != "" {
+		sampai, err := time.Parse(formatTanggal, t.BerlakuSampai)
+		if err != nil {
+			return errors.New("berlaku_sampai harus berformat YYYY-MM-DD")
+		}
+		if sampai.Before(mulai) {
+			return errors.New("berlaku_sampai tidak boleh sebelum berlaku_mulai")
+		}
+	}
+
+	if len(t.Stops) < 2 {
+		return errors.New("template minimal punya 2 stop (asal dan tujuan)")
+	}
+	// menit dihitung dari keberangkatan stop pertama, jadi stop pertama
+	// selalu berangkat di menit 0 (jam_berangkat)
+	if m := t.Stops[0].MenitBerangkat; m != nil && *m != 0 {
+		return errors.New("stop ke-1: menit_berangkat harus 0 atau kosong")
+	}
+	akhir := len(t.Stops) - 1
+	sebelum := -1
+	persenSebelum := 0
+	for i, st := range t.Stops {
+		if st.StasiunID == 0 {
+			return fmt.Errorf("stop ke-%d: stasiun_id wajib diisi", i+1)
+		}
+		if i > 0 && st.MenitTiba == nil {
+			return fmt.Errorf("stop ke-%d: menit_tiba wajib diisi", i+1)
+		}
+		if i < akhir && i > 0 && st.MenitBerangkat == nil {
+			return fmt.Errorf("stop ke-%d: menit_berangkat wajib diisi", i+1)
+		}
+		if st.MenitTiba != nil {
+			if *st.MenitTiba <= sebelum {
+				return fmt.Errorf("stop ke-%d: waktu harus bertambah sepanjang rute", i+1)
+			}
+			sebelum = *st.MenitTiba
+		}
+		if st.MenitBerangkat != nil {
+			if *st.MenitBerangkat < sebelum {
+				return fmt.Errorf("stop ke-%d: waktu harus bertambah sepanjang rute", i+1)
+			}
+			sebelum = *st.MenitBerangkat
+		}
+		if st.PersenHarga < 0 || st.PersenHarga > 100 {
+			return fmt.Errorf("stop ke-%d: persen_harga harus 0-100", i+1)
+		}
+		// persen stop pertama dan terakhir diabaikan (selalu 0 dan 100)
+		if i > 0 && i < akhir {
+			if st.PersenHarga <= persenSebelum || st.PersenHarga >= 100 {
+				return fmt.Errorf("stop ke-%d: persen_harga harus naik dari stop sebelumnya dan kurang dari 100", i+1)
+			}
+			persenSebelum = st.PersenHarga
+		}
+	}
+
+	if len(t.HargaKelas) == 0 {
+		return errors.New("harga_kelas minimal satu kelas")
+	}
+	kelas := make(map[string]bool)
+	for _, h := range t.HargaKelas {
+		if h.Kelas == "" || h.Harga <= 0 {
+			return errors.New("harga_kelas: kelas wajib diisi dan harga harus lebih dari 0")
+		}
+		if kelas[h.Kelas] {
+			return fmt.Errorf("harga_kelas: kelas %s duplikat", h.Kelas)
+		}
+		kelas[h.Kelas] = true
+	}
+	return nil
+}
+
+// parseHariOperasi membaca daftar hari "1,2,3" dengan 1=Senin sampai 7=Minggu.
+func parseHariOperasi(s string) (map[time.Weekday]bool, error) {
+	out := make(map[time.Weekday]bool)
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 7 {
+			return nil, errors.New("hari_operasi berisi angka 1 (Senin) sampai 7 (Minggu), dipisah koma")
+		}
+		out[time.Weekday(n%7)] = true
+	}
+	if len(out) == 0 {
+		return nil, errors.New("hari_operasi wajib diisi")
+	}
+	return out, nil
+}
+
+// Generate menjalankan generator untuk semua template aktif mulai hari ini.
+// Template yang gagal dicatat lalu dilewati supaya template lain tetap
+// digenerate; error gabungan dikembalikan di akhir.
+func (s *GeneratorJadwalService) Generate(ctx context.Context) (int, error) {
+	list, err := s.repo.ListAktif()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	var gagal []error
+	for i := range list {
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+		n, err := s.GenerateTemplate(&list[i], time.Now())
+		total += n
+		if err != nil {
+			log.Printf("[generator] template %d dilewati: %v", list[i].ID, err)
+			gagal = append(gagal, fmt.Errorf("template %d: %w", list[i].ID, err))
+		}
+	}
+	return total, errors.Join(gagal...)
+}
+
+// GenerateTemplate membentuk jadwal yang belum ada untuk satu template pada
+// rentang [dari, dari+hari). Baris template dikunci selama transaksi supaya
+// dua generator yang berjalan bersamaan tidak membuat jadwal ganda.
+func (s *GeneratorJadwalService) GenerateTemplate(t *models.TemplateJadwal, dari time.Time) (int, error) {
+	if !t.Aktif {
+		return 0, nil
+	}
+	if err := ValidasiTemplate(t); err != nil {
+		return 0, err
+	}
+	hariOperasi, _ := parseHariOperasi(t.HariOperasi)
+	jam, _ := time.Parse("15:04", t.JamBerangkat)
+
+	awal := time.Date(dari.Year(), dari.Month(), dari.Day(), 0, 0, 0, 0, time.Local)
+	akhir := awal.AddDate(0, 0, s.hari-1)
+
+	libur := make(map[string]bool, len(t.Pengecualian))
+	for _, p := range t.Pengecualian {
+		libur[p.Tanggal] = true
+	}
+
+	dibuat := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var kunci models.TemplateJadwal
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&kunci, t.ID).Error; err != nil {
+			return err
+		}
+
+		var sudahAda []models.Jadwal
+		if err := tx.Select("tanggal", "kelas").
+			Where("template_id = ? AND tanggal BETWEEN ? AND ?", t.ID, awal.Format(formatTanggal), akhir.Format(formatTanggal)).
+			Find(&sudahAda).Error; err != nil {
+			return err
+		}
+		ada := make(map[string]bool, len(sudahAda))
+		for _, j := range sudahAda {
+			ada[j.Tanggal+"|"+j.Kelas] = true
+		}
+
+		for d := awal; !d.After(akhir); d = d.AddDate(0, 0, 1) {
+			tanggal := d.Format(formatTanggal)
+			if tanggal < t.BerlakuMulai || (t.BerlakuSampai != "" && tanggal > t.BerlakuSampai) {
+				continue
+			}
+			if !hariOperasi[d.Weekday()] || libur[tanggal] {
+				continue
+			}
+
+			berangkat := time.Date(d.Year(), d.Month(), d.Day(), jam.Hour(), jam.Minute(), 0, 0, time.Local)
+			if berangkat.Before(dari) {
+				continue
+			}
+
+			for _, hk := range t.HargaKelas {
+				if ada[tanggal+"|"+hk.Kelas] {
+					continue
+				}
+				j := jadwalDariTemplate(t, berangkat, hk)
+				if err := j.ValidasiStops(); err != nil {
+					return fmt.Errorf("kelas %s: %w", hk.Kelas, err)
+				}
+				if err := s.jadwalRepo.BuatTx(tx, j); err != nil {
+					return err
+				}
+				dibuat++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return dibuat, nil
+}
+
+func jadwalDariTemplate(t *models.TemplateJadwal, berangkat time.Time, hk models.TemplateHargaKelas) *models.Jadwal {
+	templateID := t.ID
+	j := &models.Jadwal{
+		KeretaID:       t.KeretaID,
+		Tanggal:        berangkat.Format(formatTanggal),
+		Kelas:          hk.Kelas,
+		Harga:          hk.Harga,
+		TemplateID:     &templateID,
+		WaktuBerangkat: berangkat,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	akhir := len(t.Stops) - 1
+	for i, st := range t.Stops {
+		stop := models.JadwalStop{
+			Urutan:         i,
+			StasiunID:      st.StasiunID,
+			HargaKumulatif: hk.Harga * int64(st.PersenHarga) / 100,
+		}
+		if i == 0 {
+			w := berangkat
+			stop.WaktuBerangkat = &w
+			stop.HargaKumulatif = 0
+		} else {
+			tiba := berangkat.Add(time.Duration(*st.MenitTiba) * time.Minute)
+			stop.WaktuTiba = &tiba
+			if i < akhir {
+				w := berangkat.Add(time.Duration(*st.MenitBerangkat) * time.Minute)
+				stop.WaktuBerangkat = &w
+			}
+		}
+		if i == akhir {
+			stop.HargaKumulatif = hk.Harga
+			j.WaktuTiba = *stop.WaktuTiba
+		}
+		j.Stops = append(j.Stops, stop)
+	}
+	return j
+}
+
+// StartGeneratorJadwal menjalankan generator sekali saat start lalu berkala
+// sesuai interval.
+func StartGeneratorJadwal(ctx context.Context, svc *GeneratorJadwalService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		log.Printf("[generator] jadwal-generator started, interval=%v, hari=%d", interval, svc.hari)
+		jalankan := func() {
+			n, err := svc.Generate(ctx)
+			if err != nil {
+				log.Printf("[generator] gagal generate jadwal: %v", err)
+			}
+			if n > 0 {
+				log.Printf("[generator] membuat %d jadwal baru dari template", n)
+			}
+		}
+		jalankan()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("[generator] jadwal-generator stopped by context")
+				return
+			case <-ticker.C:
+				jalankan()
+			}
+		}
+	}()
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+func menit(m int) *int { return &m }
+
+// templateUji: GMR 08:00, BD tiba 120 berangkat 125 (50%), CN tiba 240,
+// setiap hari, dua kelas.
+func templateUji(keretaID uint, stasiunIDs [3]uint, mulai string) *models.TemplateJadwal {
+	return &models.TemplateJadwal{
+		Nama:         "Argo Pagi",
+		KeretaID:     keretaID,
+		JamBerangkat: "08:00",
+		HariOperasi:  "1,2,3,4,5,6,7",
+		BerlakuMulai: mulai,
+		Aktif:        true,
+		Stops: []models.TemplateStop{
+			{Urutan: 0, StasiunID: stasiunIDs[0]},
+			{Urutan: 1, StasiunID: stasiunIDs[1], MenitTiba: menit(120), MenitBerangkat: menit(125), PersenHarga: 50},
+			{Urutan: 2, StasiunID: stasiunIDs[2], MenitTiba: menit(240)},
+		},
+		HargaKelas: []models.TemplateHargaKelas{
+			{Kelas: "eksekutif", Harga: 200000},
+			{Kelas: "ekonomi", Harga: 80000},
+		},
+	}
+}
+
+func TestValidasiTemplate(t *testing.T) {
+	tests := []struct {
+		nama    string
+		ubah    func(t *models.TemplateJadwal)
+		wantErr string
+	}{
+		{nama: "template valid", ubah: func(t *models.TemplateJadwal) {}},
+		{
+			nama:    "stop pertama berangkat setelah menit 0",
+			ubah:    func(t *models.TemplateJadwal) { t.Stops[0].MenitBerangkat = menit(10) },
+			wantErr: "stop ke-1: menit_berangkat",
+		},
+		{
+			nama: "stop pertama dengan menit 0 boleh",
+			ubah: func(t *models.TemplateJadwal) { t.Stops[0].MenitBerangkat = menit(0) },
+		},
+		{
+			nama:    "persen harga stop antara kosong",
+			ubah:    func(t *models.TemplateJadwal) { t.Stops[1].PersenHarga = 0 },
+			wantErr: "stop ke-2: persen_harga",
+		},
+		{
+			nama:    "persen harga stop antara 100",
+			ubah:    func(t *models.TemplateJadwal) { t.Stops[1].PersenHarga = 100 },
+			wantErr: "stop ke-2: persen_harga",
+		},
+		{
+			nama:    "waktu mundur",
+			ubah:    func(t *models.TemplateJadwal) { t.Stops[2].MenitTiba = menit(100) },
+			wantErr: "stop ke-3: waktu",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			tmpl := templateUji(1, [3]uint{1, 2, 3}, "2026-01-01")
+			tt.ubah(tmpl)
+			err := ValidasiTemplate(tmpl)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidasiTemplate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidasiTemplate = %v, want error berisi %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJadwalDariTemplate(t *testing.T) {
+	tmpl := templateUji(7, [3]uint{1, 2, 3}, "2026-01-01")
+	tmpl.ID = 4
+	berangkat := time.Date(2026, 1, 5, 8, 0, 0, 0, time.Local)
+
+	j := jadwalDariTemplate(tmpl, berangkat, tmpl.HargaKelas[0])
+	if err := j.ValidasiStops(); err != nil {
+		t.Fatalf("ValidasiStops = %v", err)
+	}
+	if j.KeretaID != 7 || j.TemplateID == nil || *j.TemplateID != 4 || j.Kelas != "eksekutif" || j.Tanggal != "2026-01-05" {
+		t.Errorf("jadwal = %+v", j)
+	}
+	if !j.WaktuTiba.Equal(berangkat.Add(4 * time.Hour)) {
+		t.Errorf("WaktuTiba = %v, want %v", j.WaktuTiba, berangkat.Add(4*time.Hour))
+	}
+	bd := j.Stops[1]
+	if !bd.WaktuTiba.Equal(berangkat.Add(120*time.Minute)) || !bd.WaktuBerangkat.Equal(berangkat.Add(125*time.Minute)) {
+		t.Errorf("stop BD tiba %v berangkat %v", bd.WaktuTiba, bd.WaktuBerangkat)
+	}
+	var harga []int64
+	for _, s := range j.Stops {
+		harga = append(harga, s.HargaKumulatif)
+	}
+	if harga[0] != 0 || harga[1] != 100000 || harga[2] != 200000 {
+		t.Errorf("harga kumulatif = %v, want [0 100000 200000]", harga)
+	}
+}
+
+func TestGenerateTemplate(t *testing.T) {
+	conn := dbUji(t)
+	stasiuns := []models.Stasiun{{Kode: "GMR", Nama: "Gambir"}, {Kode: "BD", Nama: "Bandung"}, {Kode: "CN", Nama: "Cirebon"}}
+	if err := conn.Create(&stasiuns).Error; err != nil {
+		t.Fatal(err)
+	}
+	kereta := models.Kereta{Nama: "Argo Uji"}
+	if err := conn.Create(&kereta).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dari := time.Now().AddDate(0, 0, 1)
+	dari = time.Date(dari.Year(), dari.Month(), dari.Day(), 0, 0, 0, 0, time.Local)
+	libur := dari.AddDate(0, 0, 2).Format(formatTanggal)
+	tmpl := templateUji(kereta.ID, [3]uint{stasiuns[0].ID, stasiuns[1].ID, stasiuns[2].ID}, dari.Format(formatTanggal))
+	tmpl.Pengecualian = []models.TemplateJadwalPengecualian{{Tanggal: libur, Keterangan: "perawatan jalur"}}
+	if err := conn.Create(tmpl).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	jadwalRepo := repositories.NewJadwalRepo(conn)
+	gen := NewGeneratorJadwalService(conn, repositories.NewTemplateJadwalRepo(conn), jadwalRepo, 5)
+
+	// 5 hari dikurangi 1 tanggal pengecualian, 2 kelas per hari
+	n, err := gen.GenerateTemplate(tmpl, dari)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 8 {
+		t.Errorf("generate pertama membuat %d jadwal, want 8", n)
+	}
+
+	n, err = gen.GenerateTemplate(tmpl, dari)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("generate ulang membuat %d jadwal, want 0", n)
+	}
+
+	var jadwals []models.Jadwal
+	if err := conn.Where("template_id = ?", tmpl.ID).Find(&jadwals).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(jadwals) != 8 {
+		t.Errorf("tersimpan %d jadwal, want 8", len(jadwals))
+	}
+	for _, j := range jadwals {
+		if j.Tanggal == libur {
+			t.Errorf("jadwal %d dibuat pada tanggal pengecualian %s", j.ID, libur)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+// gtfsRouteTypeRail adalah route_type GTFS untuk kereta antarkota.
+const gtfsRouteTypeRail = "2"
+
+type InfoAgensi struct {
+	Nama     string
+	URL      string
+	Timezone string
+}
+
+// GtfsService mengekspor stasiun, kereta, dan jadwal ke feed GTFS static.
+//
+// Pemetaan yang dipakai:
+//   - Stasiun -> stops.txt, stop_id = Kode
+//   - Kereta  -> routes.txt, route_id = ID kereta
+//   - Jadwal  -> trips.txt + stop_times.txt. Jadwal dengan kereta, rute, dan
+//     jam berangkat yang sama (berbeda kelas) digabung menjadi satu trip.
+//   - Tanggal -> calendar.txt, satu service_id per tanggal operasi.
+type GtfsService struct {
+	db         *gorm.DB
+	jadwalRepo repositories.JadwalRepo
+	agensi     InfoAgensi
+}
+
+func NewGtfsService(db *gorm.DB, jr repositories.JadwalRepo, agensi InfoAgensi) *GtfsService {
+	return &GtfsService{db: db, jadwalRepo: jr, agensi: agensi}
+}
+
+type tripGtfs struct {
+	id       string
+	jadwal   *models.Jadwal
+	service  string
+	tanggal  time.Time
+	headsign string
+}
+
+// Ekspor menulis feed GTFS dalam format zip untuk jadwal pada rentang
+// tanggal [dari, sampai] (YYYY-MM-DD). Data yang tidak bisa diekspor
+// (misalnya stasiun tanpa koordinat) dilewati dan dilaporkan sebagai
+// peringatan.
+func (s *GtfsService) Ekspor(w io.Writer, dari, sampai string) ([]string, error) {
+	awal, err := time.ParseInLocation(formatTanggal, dari, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("tanggal dari tidak valid: %w", err)
+	}
+	akhir, err := time.ParseInLocation(formatTanggal, sampai, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("tanggal sampai tidak valid: %w", err)
+	}
+	if akhir.Before(awal) {
+		return nil, fmt.Errorf("tanggal sampai tidak boleh sebelum tanggal dari")
+	}
+
+	var stasiuns []models.Stasiun
+	if err := s.db.Order("kode asc").Find(&stasiuns).Error; err != nil {
+		return nil, err
+	}
+	jadwals, err := s.jadwalRepo.ListByRentangTanggal(dari, sampai, "")
+	if err != nil {
+		return nil, err
+	}
+	return tulisFeedGtfs(w, s.agensi, stasiuns, jadwals)
+}
+
+func tulisFeedGtfs(w io.Writer, agensi InfoAgensi, stasiuns []models.Stasiun, jadwals []models.Jadwal) ([]string, error) {
+	var peringatan []string
+
+	// GTFS mewajibkan koordinat untuk stop; stasiun tanpa koordinat tidak
+	// ikut diekspor, begitu juga pemberhentian di stasiun tersebut
+	var stops []models.Stasiun
+	kodeStasiun := make(map[uint]string, len(stasiuns))
+	for _, st := range stasiuns {
+		if st.Latitude == nil || st.Longitude == nil {
+			peringatan = append(peringatan, fmt.Sprintf("stasiun %s dilewati: koordinat belum diisi", st.Kode))
+			continue
+		}
+		stops = append(stops, st)
+		kodeStasiun[st.ID] = st.Kode
+	}
+
+	trips, keretas := kelompokkanTrip(jadwals)
+	trips, tripPeringatan := saringTrip(trips, kodeStasiun)
+	peringatan = append(peringatan, tripPeringatan...)
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		nama string
+		rows [][]string
+	}{
+		{"agency.txt", barisAgency(agensi)},
+		{"stops.txt", barisStops(stops)},
+		{"routes.txt", barisRoutes(keretas)},
+		{"trips.txt", barisTrips(trips)},
+		{"stop_times.txt", barisStopTimes(trips, kodeStasiun)},
+		{"calendar.txt", barisCalendar(trips)},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.nama)
+		if err != nil {
+			return nil, err
+		}
+		cw := csv.NewWriter(fw)
+		if err := cw.WriteAll(f.rows); err != nil {
+			return nil, err
+		}
+	}
+	return peringatan, zw.Close()
+}
+
+// saringTrip membuang trip yang tersisa kurang dari dua pemberhentian
+// setelah stasiun tanpa koordinat dilewati.
+func saringTrip(trips []tripGtfs, kodeStasiun map[uint]string) ([]tripGtfs, []string) {
+	var peringatan []string
+	out := trips[:0]
+	for _, t := range trips {
+		jumlah := 0
+		for _, st := range t.jadwal.Stops {
+			if _, ok := kodeStasiun[st.StasiunID]; ok {
+				jumlah++
+			}
+		}
+		if jumlah < 2 {
+			peringatan = append(peringatan, fmt.Sprintf("trip %s dilewati: kurang dari dua stasiun yang bisa diekspor", t.id))
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, peringatan
+}
+
+// kelompokkanTrip menggabungkan jadwal beda kelas pada perjalanan fisik yang
+// sama. ID trip memakai ID jadwal terkecil dari kelompok agar stabil.
+func kelompokkanTrip(jadwals []models.Jadwal) ([]tripGtfs, []models.Kereta) {
+	sort.SliceStable(jadwals, func(a, b int) bool { return jadwals[a].ID < jadwals[b].ID })
+
+	sudah := make(map[string]bool)
+	keretaMap := make(map[uint]models.Kereta)
+	var trips []tripGtfs
+	for i := range jadwals {
+		j := &jadwals[i]
+		if len(j.Stops) < 2 {
+			continue
+		}
+		kunci := fmt.Sprintf("%d|%d|%d|%d", j.KeretaID, j.AsalID, j.TujuanID, j.WaktuBerangkat.Unix())
+		if sudah[kunci] {
+			continue
+		}
+		sudah[kunci] = true
+
+		tanggal, err := time.ParseInLocation(formatTanggal, j.Tanggal, time.Local)
+		if err != nil {
+			tanggal = time.Date(j.WaktuBerangkat.Year(), j.WaktuBerangkat.Month(), j.WaktuBerangkat.Day(), 0, 0, 0, 0, time.Local)
+		}
+		trips = append(trips, tripGtfs{
+			id:       "J" + strconv.FormatUint(uint64(j.ID), 10),
+			jadwal:   j,
+			service:  "D" + tanggal.Format("20060102"),
+			tanggal:  tanggal,
+			headsign: j.Stops[len(j.Stops)-1].Stasiun.Nama,
+		})
+		keretaMap[j.KeretaID] = j.Kereta
+	}
+
+	keretas := make([]models.Kereta, 0, len(keretaMap))
+	for _, k := range keretaMap {
+		keretas = append(keretas, k)
+	}
+	sort.Slice(keretas, func(a, b int) bool { return keretas[a].ID < keretas[b].ID })
+	return trips, keretas
+}
+
+func barisAgency(agensi InfoAgensi) [][]string {
+	return [][]string{
+		{"agency_id", "agency_name", "agency_url", "agency_timezone"},
+		{"MOOOVE", agensi.Nama, agensi.URL, agensi.Timezone},
+	}
+}
+
+// barisStops menulis stasiun sebagai stop biasa (location_type 0) karena
+// stop_times tidak boleh merujuk ke stop bertipe station.
+func barisStops(stasiuns []models.Stasiun) [][]string {
+	rows := [][]string{{"stop_id", "stop_code", "stop_name", "stop_desc", "stop_lat", "stop_lon", "location_type"}}
+	for _, st := range stasiuns {
+		rows = append(rows, []string{st.Kode, st.Kode, st.Nama, st.Kota, formatKoordinat(st.Latitude), formatKoordinat(st.Longitude), "0"})
+	}
+	return rows
+}
+
+func formatKoordinat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 6, 64)
+}
+
+func barisRoutes(keretas []models.Kereta) [][]string {
+	rows := [][]string{{"route_id", "agency_id", "route_short_name", "route_long_name", "route_type"}}
+	for _, k := range keretas {
+		rows = append(rows, []string{strconv.FormatUint(uint64(k.ID), 10), "MOOOVE", k.Nama, k.Nama, gtfsRouteTypeRail})
+	}
+	return rows
+}
+
+func barisTrips(trips []tripGtfs) [][]string {
+	rows := [][]string{{"route_id", "service_id", "trip_id", "trip_headsign"}}
+	for _, t := range trips {
+		rows = append(rows, []string{strconv.FormatUint(uint64(t.jadwal.KeretaID), 10), t.service, t.id, t.headsign})
+	}
+	return rows
+}
+
+func barisStopTimes(trips []tripGtfs, kodeStasiun map[uint]string) [][]string {
+	rows := [][]string{{"trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"}}
+	for _, t := range trips {
+		for _, st := range t.jadwal.Stops {
+			tiba, berangkat := st.WaktuTiba, st.WaktuBerangkat
+			if tiba == nil {
+				tiba = berangkat
+			}
+			if berangkat == nil {
+				berangkat = tiba
+			}
+			kode, ok := kodeStasiun[st.StasiunID]
+			if tiba == nil || !ok {
+				continue
+			}
+			rows = append(rows, []string{
+				t.id,
+				waktuGtfs(t.tanggal, *tiba),
+				waktuGtfs(t.tanggal, *berangkat),
+				kode,
+				strconv.Itoa(st.Urutan + 1),
+			})
+		}
+	}
+	return rows
+}
+
+// barisCalendar membuat satu service per tanggal yang hanya aktif pada hari
+// itu saja.
+func barisCalendar(trips []tripGtfs) [][]string {
+	rows := [][]string{{"service_id", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday", "start_date", "end_date"}}
+	sudah := make(map[string]bool)
+	for _, t := range trips {
+		if sudah[t.service] {
+			continue
+		}
+		sudah[t.service] = true
+
+		hari := make([]string, 7)
+		for i := range hari {
+			hari[i] = "0"
+		}
+		// kolom GTFS dimulai dari Senin, time.Weekday dari Minggu
+		hari[(int(t.tanggal.Weekday())+6)%7] = "1"
+		tgl := t.tanggal.Format("20060102")
+		rows = append(rows, append(append([]string{t.service}, hari...), tgl, tgl))
+	}
+	return rows
+}
+
+// waktuGtfs memformat waktu sebagai HH:MM:SS relatif terhadap tengah malam
+// tanggal service. Jam boleh melewati 24 untuk perjalanan lintas hari.
+func waktuGtfs(tanggal, t time.Time) string {
+	d := t.In(tanggal.Location()).Sub(tanggal)
+	if d < 0 {
+		d = 0
+	}
+	detik := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", detik/3600, (detik/60)%60, detik%60)
+}
+
+// parseWaktuGtfs membaca HH:MM[:SS] sebagai durasi sejak tengah malam tanggal
+// service, kebalikan dari waktuGtfs.
+func parseWaktuGtfs(s string) (time.Duration, error) {
+	var jam, menit, detik int
+	if n, _ := fmt.Sscanf(s, "%d:%d:%d", &jam, &menit, &detik); n < 2 {
+		return 0, fmt.Errorf("format waktu %q harus HH:MM atau HH:MM:SS", s)
+	}
+	if jam < 0 || menit < 0 || menit > 59 || detik < 0 || detik > 59 {
+		return 0, fmt.Errorf("format waktu %q tidak valid", s)
+	}
+	return time.Duration(jam)*time.Hour + time.Duration(menit)*time.Minute + time.Duration(detik)*time.Second, nil
+}
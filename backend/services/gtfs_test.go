@@ -0,0 +1,134 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+)
+
+func koordinat(v float64) *float64 { return &v }
+
+func waktuFixture(hari, jam, menit int) *time.Time {
+	t := time.Date(2026, 1, hari, jam, menit, 0, 0, time.Local)
+	return &t
+}
+
+// fixtureGtfs: satu perjalanan GMR-BD-CN yang lewat tengah malam dan dijual
+// dua kelas, plus satu jadwal ke stasiun tanpa koordinat.
+func fixtureGtfs() ([]models.Stasiun, []models.Jadwal) {
+	stasiuns := []models.Stasiun{
+		{ID: 2, Kode: "BD", Nama: "Bandung", Kota: "Bandung", Latitude: koordinat(-6.914744), Longitude: koordinat(107.60981)},
+		{ID: 3, Kode: "CN", Nama: "Cirebon", Kota: "Cirebon", Latitude: koordinat(-6.705), Longitude: koordinat(108.555)},
+		{ID: 1, Kode: "GMR", Nama: "Gambir", Kota: "Jakarta", Latitude: koordinat(-6.176655), Longitude: koordinat(106.830583)},
+		{ID: 4, Kode: "XX", Nama: "Tanpa Koordinat", Kota: "Entah"},
+	}
+	kereta := models.Kereta{ID: 7, Nama: "Argo Parahyangan"}
+	stops := func(jadwalID uint) []models.JadwalStop {
+		return []models.JadwalStop{
+			{JadwalID: jadwalID, Urutan: 0, StasiunID: 1, WaktuBerangkat: waktuFixture(5, 21, 0)},
+			{JadwalID: jadwalID, Urutan: 1, StasiunID: 2, WaktuTiba: waktuFixture(5, 23, 50), WaktuBerangkat: waktuFixture(5, 23, 55)},
+			{JadwalID: jadwalID, Urutan: 2, StasiunID: 3, WaktuTiba: waktuFixture(6, 1, 30), Stasiun: models.Stasiun{Nama: "Cirebon"}},
+		}
+	}
+	berangkat := *waktuFixture(5, 21, 0)
+	jadwals := []models.Jadwal{
+		{ID: 11, KeretaID: 7, Kereta: kereta, AsalID: 1, TujuanID: 3, WaktuBerangkat: berangkat, Tanggal: "2026-01-05", Kelas: "ekonomi", Stops: stops(11)},
+		{ID: 10, KeretaID: 7, Kereta: kereta, AsalID: 1, TujuanID: 3, WaktuBerangkat: berangkat, Tanggal: "2026-01-05", Kelas: "eksekutif", Stops: stops(10)},
+		{ID: 12, KeretaID: 7, Kereta: kereta, AsalID: 1, TujuanID: 4, WaktuBerangkat: berangkat, Tanggal: "2026-01-05", Kelas: "eksekutif", Stops: []models.JadwalStop{
+			{JadwalID: 12, Urutan: 0, StasiunID: 1, WaktuBerangkat: waktuFixture(5, 21, 0)},
+			{JadwalID: 12, Urutan: 1, StasiunID: 4, WaktuTiba: waktuFixture(5, 22, 0), Stasiun: models.Stasiun{Nama: "Tanpa Koordinat"}},
+		}},
+	}
+	return stasiuns, jadwals
+}
+
+func bacaZipGtfs(t *testing.T, data []byte) map[string][][]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string][][]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		isi, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(bytes.NewReader(isi)).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		out[f.Name] = rows
+	}
+	return out
+}
+
+func TestTulisFeedGtfs(t *testing.T) {
+	stasiuns, jadwals := fixtureGtfs()
+	agensi := InfoAgensi{Nama: "Mooove", URL: "https://mooove.id", Timezone: "Asia/Jakarta"}
+
+	var buf bytes.Buffer
+	peringatan, err := tulisFeedGtfs(&buf, agensi, stasiuns, jadwals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPeringatan := []string{
+		"stasiun XX dilewati: koordinat belum diisi",
+		"trip J12 dilewati: kurang dari dua stasiun yang bisa diekspor",
+	}
+	if !reflect.DeepEqual(peringatan, wantPeringatan) {
+		t.Errorf("peringatan = %q, want %q", peringatan, wantPeringatan)
+	}
+
+	want := map[string][][]string{
+		"agency.txt": {
+			{"agency_id", "agency_name", "agency_url", "agency_timezone"},
+			{"MOOOVE", "Mooove", "https://mooove.id", "Asia/Jakarta"},
+		},
+		"stops.txt": {
+			{"stop_id", "stop_code", "stop_name", "stop_desc", "stop_lat", "stop_lon", "location_type"},
+			{"BD", "BD", "Bandung", "Bandung", "-6.914744", "107.609810", "0"},
+			{"CN", "CN", "Cirebon", "Cirebon", "-6.705000", "108.555000", "0"},
+			{"GMR", "GMR", "Gambir", "Jakarta", "-6.176655", "106.830583", "0"},
+		},
+		"routes.txt": {
+			{"route_id", "agency_id", "route_short_name", "route_long_name", "route_type"},
+			{"7", "MOOOVE", "Argo Parahyangan", "Argo Parahyangan", "2"},
+		},
+		"trips.txt": {
+			{"route_id", "service_id", "trip_id", "trip_headsign"},
+			{"7", "D20260105", "J10", "Cirebon"},
+		},
+		"stop_times.txt": {
+			{"trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"},
+			{"J10", "21:00:00", "21:00:00", "GMR", "1"},
+			{"J10", "23:50:00", "23:55:00", "BD", "2"},
+			{"J10", "25:30:00", "25:30:00", "CN", "3"},
+		},
+		"calendar.txt": {
+			{"service_id", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday", "start_date", "end_date"},
+			{"D20260105", "1", "0", "0", "0", "0", "0", "0", "20260105", "20260105"},
+		},
+	}
+
+	got := bacaZipGtfs(t, buf.Bytes())
+	if len(got) != len(want) {
+		t.Errorf("zip berisi %d file, want %d", len(got), len(want))
+	}
+	for nama, rows := range want {
+		if !reflect.DeepEqual(got[nama], rows) {
+			t.Errorf("%s:\n got  %q\n want %q", nama, got[nama], rows)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+// HargaDinamisService menaikkan/menurunkan harga jadwal berdasarkan tingkat
+// keterisian kursi dan sisa waktu sebelum keberangkatan.
+type HargaDinamisService struct {
+	repo repositories.AturanHargaRepo
+}
+
+func NewHargaDinamisService(repo repositories.AturanHargaRepo) *HargaDinamisService {
+	return &HargaDinamisService{repo: repo}
+}
+
+type muatanJadwal struct {
+	TrainScheduleID uint
+	Total           int64
+	Terisi          int64
+}
+
+// persenMuatan menghitung persentase baris ketersediaan (semua segmen) yang
+// sudah reserved/booked per jadwal dalam satu query.
+func persenMuatan(tx *gorm.DB, jadwalIDs []uint) (map[uint]int, error) {
+	out := make(map[uint]int)
+	if len(jadwalIDs) == 0 {
+		return out, nil
+	}
+
+	var rows []muatanJadwal
+	if err := tx.Model(&models.KetersediaanKursi{}).
+		Select("train_schedule_id, COUNT(*) AS total, SUM(CASE WHEN status <> 'available' THEN 1 ELSE 0 END) AS terisi").
+		Where("train_schedule_id IN ?", jadwalIDs).
+		Group("train_schedule_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, r := range rows {
+		if r.Total > 0 {
+			out[r.TrainScheduleID] = int(r.Terisi * 100 / r.Total)
+		}
+	}
+	return out, nil
+}
+
+func faktorAturan(aturan []models.AturanHarga, jenis, kelas string, nilai int) int {
+	for i := range aturan {
+		if aturan[i].Jenis == jenis && aturan[i].Cocok(kelas, nilai) {
+			return aturan[i].Faktor
+		}
+	}
+	return 100
+}
+
+// Faktor mengembalikan faktor harga (persen) per jadwal ID.
+func (s *HargaDinamisService) Faktor(tx *gorm.DB, jadwals []*models.Jadwal, now time.Time) (map[uint]int, error) {
+	out := make(map[uint]int, len(jadwals))
+	if len(jadwals) == 0 {
+		return out, nil
+	}
+
+	aturan, err := s.repo.ListAktifTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(aturan) == 0 {
+		for _, j := range jadwals {
+			out[j.ID] = 100
+		}
+		return out, nil
+	}
+
+	ids := make([]uint, 0, len(jadwals))
+	for _, j := range jadwals {
+		ids = append(ids, j.ID)
+	}
+	muatan, err := persenMuatan(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, j := range jadwals {
+		out[j.ID] = faktorUntuk(aturan, j.Kelas, j.WaktuBerangkat, muatan[j.ID], now)
+	}
+	return out, nil
+}
+
+// FaktorDariMuatan memuat aturan aktif sekali lalu mengembalikan fungsi
+// faktor harga untuk pemanggil yang sudah menghitung muatan sendiri, seperti
+// kalender harga yang mengambil muatan dari query agregatnya.
+func (s *HargaDinamisService) FaktorDariMuatan(now time.Time) (func(kelas string, berangkat time.Time, muatan int) int, error) {
+	aturan, err := s.repo.ListAktif()
+	if err != nil {
+		return nil, err
+	}
+	return func(kelas string, berangkat time.Time, muatan int) int {
+		return faktorUntuk(aturan, kelas, berangkat, muatan, now)
+	}, nil
+}
+
+func faktorUntuk(aturan []models.AturanHarga, kelas string, berangkat time.Time, muatan int, now time.Time) int {
+	hari := int(berangkat.Sub(now).Hours() / 24)
+	if hari < 0 {
+		hari = 0
+	}
+	f := faktorAturan(aturan, "muatan", kelas, muatan)
+	return f * faktorAturan(aturan, "hari", kelas, hari) / 100
+}
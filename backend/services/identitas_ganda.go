@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+)
+
+// IdentitasBentrokError dikembalikan bila satu atau lebih penumpang sudah
+// punya booking aktif yang waktunya bertabrakan. Booking lain tidak
+// disebutkan karena bisa milik user lain.
+type IdentitasBentrokError struct {
+	Kesalahan []models.KesalahanField
+}
+
+func (e *IdentitasBentrokError) Error() string {
+	field := make([]string, len(e.Kesalahan))
+	for i, k := range e.Kesalahan {
+		field[i] = k.Field
+	}
+	return "identitas penumpang sudah dipakai pada perjalanan lain di waktu yang sama: " + strings.Join(field, ", ")
+}
+
+type jendelaWaktu struct {
+	mulai, selesai time.Time
+}
+
+func (a jendelaWaktu) bertabrakan(b jendelaWaktu, jeda time.Duration) bool {
+	return a.mulai.Before(b.selesai.Add(jeda)) && b.mulai.Before(a.selesai.Add(jeda))
+}
+
+// cekIdentitasGanda dijalankan di dalam transaksi booking. Baris penumpang
+// dengan blind index yang sama dibaca FOR UPDATE; di InnoDB ini juga
+// mengambil gap lock. Gap lock tidak saling menghalangi, jadi dua booking
+// bersamaan dengan identitas yang sama sama-sama lolos pembacaan lalu
+// deadlock saat insert penumpang. InnoDB membatalkan salah satunya sehingga
+// keduanya tidak pernah tersimpan; CreateBookingWithReserve mengubah deadlock
+// itu menjadi ErrBookingBersamaan agar client mengulang.
+func cekIdentitasGanda(tx *gorm.DB, kebijakan *models.KebijakanBooking, penumpangs []models.Penumpang, jadwals []models.Jadwal, naiks, turuns []*models.JadwalStop) error {
+	if !kebijakan.CegahIdentitasGanda {
+		return nil
+	}
+	jeda := time.Duration(kebijakan.JedaIdentitasMenit) * time.Minute
+
+	var salah []models.KesalahanField
+	tambah := func(i int) {
+		salah = append(salah, models.KesalahanField{
+			Field: fmt.Sprintf("penumpangs[%d].no_identitas", i),
+			Pesan: "identitas sudah dipakai pada perjalanan lain yang waktunya bertabrakan",
+		})
+	}
+
+	pemakai := map[string]int{}
+	var daftar []string
+	for i := range penumpangs {
+		idx := models.IndeksIdentitas(string(penumpangs[i].NoIdentitas))
+		if idx == "" {
+			continue
+		}
+		if _, ada := pemakai[idx]; ada {
+			// satu booking berisi identitas yang sama dua kali
+			tambah(i)
+			continue
+		}
+		pemakai[idx] = i
+		daftar = append(daftar, idx)
+	}
+	if len(daftar) > 0 {
+		if err := cekBookingAktif(tx, daftar, pemakai, jadwals, naiks, turuns, jeda, tambah); err != nil {
+			return err
+		}
+	}
+
+	if len(salah) > 0 {
+		return &IdentitasBentrokError{Kesalahan: salah}
+	}
+	return nil
+}
+
+// cekBookingAktif memanggil tandai untuk setiap penumpang yang identitasnya
+// sudah ada di booking pending/paid dengan leg yang bertabrakan.
+func cekBookingAktif(tx *gorm.DB, daftar []string, pemakai map[string]int, jadwals []models.Jadwal, naiks, turuns []*models.JadwalStop, jeda time.Duration, tandai func(int)) error {
+	diminta := make([]jendelaWaktu, len(jadwals))
+	for i := range jadwals {
+		diminta[i] = jendelaWaktu{jadwals[i].BerangkatDari(naiks[i]), jadwals[i].TibaDi(turuns[i])}
+	}
+
+	var aktif []struct {
+		NoIdentitasIdx string
+		BookingLegID   uint
+	}
+	if err := tx.Table("penumpangs").
+		Select("penumpangs.no_identitas_idx, penumpangs.booking_leg_id").
+		Joins("JOIN bookings ON bookings.id = penumpangs.booking_id").
+		Where("penumpangs.no_identitas_idx IN ? AND bookings.status IN ?", daftar, []string{"pending", "paid"}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scan(&aktif).Error; err != nil {
+		return err
+	}
+	if len(aktif) == 0 {
+		return nil
+	}
+
+	legIDs := make([]uint, 0, len(aktif))
+	for _, a := range aktif {
+		legIDs = append(legIDs, a.BookingLegID)
+	}
+	var legs []models.BookingLeg
+	if err := tx.Preload("TrainSchedule.Stops").Where("id IN ?", legIDs).Find(&legs).Error; err != nil {
+		return err
+	}
+	jendelaLeg := make(map[uint]jendelaWaktu, len(legs))
+	for _, l := range legs {
+		naik, turun, err := l.TrainSchedule.Segmen(l.NaikStasiunID, l.TurunStasiunID)
+		if err != nil {
+			// stop lama sudah berubah; pakai jendela jadwal penuh
+			jendelaLeg[l.ID] = jendelaWaktu{l.TrainSchedule.WaktuBerangkat, l.TrainSchedule.WaktuTiba}
+			continue
+		}
+		jendelaLeg[l.ID] = jendelaWaktu{l.TrainSchedule.BerangkatDari(naik), l.TrainSchedule.TibaDi(turun)}
+	}
+
+	ditandai := map[int]bool{}
+	for _, a := range aktif {
+		i := pemakai[a.NoIdentitasIdx]
+		j, ok := jendelaLeg[a.BookingLegID]
+		if !ok || ditandai[i] {
+			continue
+		}
+		for _, d := range diminta {
+			if d.bertabrakan(j, jeda) {
+				ditandai[i] = true
+				tandai(i)
+				break
+			}
+		}
+	}
+	return nil
+}
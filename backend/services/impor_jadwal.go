@@ -0,0 +1,717 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+const maksHariImporGtfs = 366
+
+// errBatalkanImpor dipakai untuk me-rollback transaksi pada dry-run atau saat
+// ada baris yang tidak valid.
+var errBatalkanImpor = errors.New("impor dibatalkan")
+
+// OpsiImpor mengatur impor. Kelas dan Harga hanya dipakai untuk GTFS karena
+// feed GTFS tidak membawa kelas/harga; Dari/Sampai membatasi tanggal service
+// GTFS yang dibentuk menjadi jadwal.
+type OpsiImpor struct {
+	DryRun bool
+	Kelas  string
+	Harga  int64
+	Dari   string
+	Sampai string
+}
+
+type KesalahanBaris struct {
+	File  string `json:"file"`
+	Baris int    `json:"baris"`
+	Pesan string `json:"pesan"`
+}
+
+type HasilImpor struct {
+	DryRun            bool             `json:"dry_run"`
+	Disimpan          bool             `json:"disimpan"`
+	StasiunBaru       int              `json:"stasiun_baru"`
+	StasiunDiperbarui int              `json:"stasiun_diperbarui"`
+	JadwalBaru        int              `json:"jadwal_baru"`
+	JadwalDilewati    int              `json:"jadwal_dilewati"`
+	Kesalahan         []KesalahanBaris `json:"kesalahan"`
+}
+
+func (h *HasilImpor) salah(file string, baris int, format string, args ...interface{}) {
+	h.Kesalahan = append(h.Kesalahan, KesalahanBaris{File: file, Baris: baris, Pesan: fmt.Sprintf(format, args...)})
+}
+
+type stasiunImpor struct {
+	Kode      string
+	Nama      string
+	Kota      string
+	Latitude  *float64
+	Longitude *float64
+}
+
+type stopImpor struct {
+	Kode           string
+	Tiba           *time.Duration
+	Berangkat      *time.Duration
+	HargaKumulatif int64
+}
+
+type perjalananImpor struct {
+	File    string
+	Baris   int
+	Kereta  string
+	Tanggal time.Time
+	Kelas   string
+	Harga   int64
+	Stops   []stopImpor
+}
+
+// ImporJadwalService memuat stasiun dan jadwal secara massal dari feed GTFS
+// (zip) atau CSV. Kereta beserta gerbong kelas yang dijual harus sudah
+// terdaftar dengan nama yang sama. Semua perubahan disimpan dalam satu
+// transaksi dan hanya di-commit bila tidak ada baris yang salah.
+//
+// Layout CSV: satu baris per stop, header wajib:
+//
+//	perjalanan,kereta,tanggal,kelas,harga,urutan,kode_stasiun,nama_stasiun,kota,tiba,berangkat,harga_kumulatif
+//
+// Baris dengan perjalanan+kelas yang sama membentuk satu jadwal. tanggal
+// berformat YYYY-MM-DD, tiba/berangkat HH:MM[:SS] sejak tengah malam tanggal
+// tersebut (boleh >= 24:00 untuk lintas hari). nama_stasiun, kota, dan
+// harga_kumulatif boleh kosong; harga_kumulatif yang kosong dihitung dari
+// lama perjalanan sejak stasiun awal.
+type ImporJadwalService struct {
+	db         *gorm.DB
+	jadwalRepo repositories.JadwalRepo
+}
+
+func NewImporJadwalService(db *gorm.DB, jr repositories.JadwalRepo) *ImporJadwalService {
+	return &ImporJadwalService{db: db, jadwalRepo: jr}
+}
+
+func (s *ImporJadwalService) ImporCsv(r io.Reader, opsi OpsiImpor) (*HasilImpor, error) {
+	hasil := &HasilImpor{DryRun: opsi.DryRun}
+	stasiuns, perjalanan, err := bacaCsvImpor(r, hasil)
+	if err != nil {
+		return nil, err
+	}
+	return s.simpan(stasiuns, perjalanan, opsi, hasil)
+}
+
+func (s *ImporJadwalService) ImporGtfs(data []byte, opsi OpsiImpor) (*HasilImpor, error) {
+	hasil := &HasilImpor{DryRun: opsi.DryRun}
+	stasiuns, perjalanan, err := bacaGtfsImpor(data, opsi, hasil)
+	if err != nil {
+		return nil, err
+	}
+	return s.simpan(stasiuns, perjalanan, opsi, hasil)
+}
+
+// tabel adalah isi file CSV berheader, baris[i] bernomor baris file i+2.
+type tabel struct {
+	kolom map[string]int
+	baris [][]string
+}
+
+func bacaTabel(r io.Reader) (*tabel, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file kosong")
+	}
+	t := &tabel{kolom: make(map[string]int), baris: rows[1:]}
+	for i, k := range rows[0] {
+		t.kolom[strings.TrimSpace(strings.TrimPrefix(k, "\ufeff"))] = i
+	}
+	return t, nil
+}
+
+func (t *tabel) wajib(kolom ...string) error {
+	for _, k := range kolom {
+		if _, ok := t.kolom[k]; !ok {
+			return fmt.Errorf("kolom %s tidak ada", k)
+		}
+	}
+	return nil
+}
+
+func (t *tabel) ambil(row []string, kolom string) string {
+	i, ok := t.kolom[kolom]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func parseWaktuOpsional(s string) (*time.Duration, error) {
+	if s == "" {
+		return nil, nil
+	}
+	d, err := parseWaktuGtfs(s)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func parseKoordinat(s string) *float64 {
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+func bacaCsvImpor(r io.Reader, hasil *HasilImpor) (map[string]stasiunImpor, []perjalananImpor, error) {
+	const file = "csv"
+	t, err := bacaTabel(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := t.wajib("perjalanan", "kereta", "tanggal", "kelas", "harga", "urutan", "kode_stasiun", "tiba", "berangkat"); err != nil {
+		return nil, nil, err
+	}
+
+	type barisStop struct {
+		urutan int
+		stop   stopImpor
+	}
+	stasiuns := make(map[string]stasiunImpor)
+	perjalananMap := make(map[string]*perjalananImpor)
+	stopMap := make(map[string][]barisStop)
+	var urutanKunci []string
+
+	for i, row := range t.baris {
+		no := i + 2
+		kunci := t.ambil(row, "perjalanan") + "|" + t.ambil(row, "kelas")
+		kode := t.ambil(row, "kode_stasiun")
+		if kode == "" || len(kode) > 10 {
+			hasil.salah(file, no, "kode_stasiun wajib diisi, maksimal 10 karakter")
+			continue
+		}
+		if nama := t.ambil(row, "nama_stasiun"); nama != "" {
+			stasiuns[kode] = stasiunImpor{Kode: kode, Nama: nama, Kota: t.ambil(row, "kota")}
+		}
+
+		urutan, err := strconv.Atoi(t.ambil(row, "urutan"))
+		if err != nil {
+			hasil.salah(file, no, "urutan harus angka")
+			continue
+		}
+		tiba, err := parseWaktuOpsional(t.ambil(row, "tiba"))
+		if err != nil {
+			hasil.salah(file, no, "tiba: %v", err)
+			continue
+		}
+		berangkat, err := parseWaktuOpsional(t.ambil(row, "berangkat"))
+		if err != nil {
+			hasil.salah(file, no, "berangkat: %v", err)
+			continue
+		}
+		var hargaKumulatif int64 = -1
+		if v := t.ambil(row, "harga_kumulatif"); v != "" {
+			if hargaKumulatif, err = strconv.ParseInt(v, 10, 64); err != nil || hargaKumulatif < 0 {
+				hasil.salah(file, no, "harga_kumulatif harus angka >= 0")
+				continue
+			}
+		}
+
+		p, ok := perjalananMap[kunci]
+		if !ok {
+			tanggal, err := time.ParseInLocation(formatTanggal, t.ambil(row, "tanggal"), time.Local)
+			if err != nil {
+				hasil.salah(file, no, "tanggal harus berformat YYYY-MM-DD")
+				continue
+			}
+			harga, err := strconv.ParseInt(t.ambil(row, "harga"), 10, 64)
+			if err != nil || harga <= 0 {
+				hasil.salah(file, no, "harga harus angka lebih dari 0")
+				continue
+			}
+			p = &perjalananImpor{
+				File:    file,
+				Baris:   no,
+				Kereta:  t.ambil(row, "kereta"),
+				Tanggal: tanggal,
+				Kelas:   t.ambil(row, "kelas"),
+				Harga:   harga,
+			}
+			if p.Kereta == "" || p.Kelas == "" {
+				hasil.salah(file, no, "kereta dan kelas wajib diisi")
+				continue
+			}
+			perjalananMap[kunci] = p
+			urutanKunci = append(urutanKunci, kunci)
+		}
+		stopMap[kunci] = append(stopMap[kunci], barisStop{urutan: urutan, stop: stopImpor{
+			Kode: kode, Tiba: tiba, Berangkat: berangkat, HargaKumulatif: hargaKumulatif,
+		}})
+	}
+
+	out := make([]perjalananImpor, 0, len(urutanKunci))
+	for _, kunci := range urutanKunci {
+		list := stopMap[kunci]
+		sort.SliceStable(list, func(a, b int) bool { return list[a].urutan < list[b].urutan })
+		p := perjalananMap[kunci]
+		for _, b := range list {
+			p.Stops = append(p.Stops, b.stop)
+		}
+		out = append(out, *p)
+	}
+	return stasiuns, out, nil
+}
+
+func bukaFileZip(zr *zip.Reader, nama string) (*tabel, error) {
+	for _, f := range zr.File {
+		if f.Name != nama {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return bacaTabel(rc)
+	}
+	return nil, nil
+}
+
+// bacaGtfsImpor membentuk perjalanan dari trips.txt untuk setiap tanggal
+// service (calendar.txt + calendar_dates.txt) dalam rentang opsi.
+func bacaGtfsImpor(data []byte, opsi OpsiImpor, hasil *HasilImpor) (map[string]stasiunImpor, []perjalananImpor, error) {
+	if opsi.Harga <= 0 {
+		return nil, nil, errors.New("harga wajib diisi untuk impor GTFS")
+	}
+	if opsi.Kelas == "" {
+		opsi.Kelas = "ekonomi"
+	}
+	dari, err := time.ParseInLocation(formatTanggal, opsi.Dari, time.Local)
+	if err != nil {
+		return nil, nil, errors.New("dari harus berformat YYYY-MM-DD")
+	}
+	sampai, err := time.ParseInLocation(formatTanggal, opsi.Sampai, time.Local)
+	if err != nil {
+		return nil, nil, errors.New("sampai harus berformat YYYY-MM-DD")
+	}
+	if sampai.Before(dari) || sampai.Sub(dari) > maksHariImporGtfs*24*time.Hour {
+		return nil, nil, fmt.Errorf("rentang dari/sampai tidak valid (maksimal %d hari)", maksHariImporGtfs)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("file bukan zip GTFS: %w", err)
+	}
+	files := make(map[string]*tabel)
+	for _, nama := range []string{"stops.txt", "routes.txt", "trips.txt", "stop_times.txt", "calendar.txt", "calendar_dates.txt"} {
+		t, err := bukaFileZip(zr, nama)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", nama, err)
+		}
+		files[nama] = t
+	}
+	for _, nama := range []string{"stops.txt", "routes.txt", "trips.txt", "stop_times.txt"} {
+		if files[nama] == nil {
+			return nil, nil, fmt.Errorf("%s tidak ada di dalam zip", nama)
+		}
+	}
+	if files["calendar.txt"] == nil && files["calendar_dates.txt"] == nil {
+		return nil, nil, errors.New("calendar.txt atau calendar_dates.txt wajib ada")
+	}
+
+	// stops: stop_code dipakai sebagai Kode bila ada, selain itu stop_id
+	stops := files["stops.txt"]
+	if err := stops.wajib("stop_id", "stop_name"); err != nil {
+		return nil, nil, fmt.Errorf("stops.txt: %w", err)
+	}
+	stasiuns := make(map[string]stasiunImpor)
+	kodeStop := make(map[string]string)
+	for i, row := range stops.baris {
+		id := stops.ambil(row, "stop_id")
+		kode := stops.ambil(row, "stop_code")
+		if kode == "" {
+			kode = id
+		}
+		if kode == "" || len(kode) > 10 {
+			hasil.salah("stops.txt", i+2, "stop_code/stop_id wajib diisi, maksimal 10 karakter")
+			continue
+		}
+		kodeStop[id] = kode
+		stasiuns[kode] = stasiunImpor{
+			Kode:      kode,
+			Nama:      stops.ambil(row, "stop_name"),
+			Kota:      stops.ambil(row, "stop_desc"),
+			Latitude:  parseKoordinat(stops.ambil(row, "stop_lat")),
+			Longitude: parseKoordinat(stops.ambil(row, "stop_lon")),
+		}
+	}
+
+	routes := files["routes.txt"]
+	if err := routes.wajib("route_id"); err != nil {
+		return nil, nil, fmt.Errorf("routes.txt: %w", err)
+	}
+	namaRoute := make(map[string]string)
+	for i, row := range routes.baris {
+		nama := routes.ambil(row, "route_short_name")
+		if nama == "" {
+			nama = routes.ambil(row, "route_long_name")
+		}
+		if nama == "" {
+			hasil.salah("routes.txt", i+2, "route_short_name atau route_long_name wajib diisi")
+			continue
+		}
+		namaRoute[routes.ambil(row, "route_id")] = nama
+	}
+
+	tanggalService, err := tanggalServiceGtfs(files["calendar.txt"], files["calendar_dates.txt"], dari, sampai, hasil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st := files["stop_times.txt"]
+	if err := st.wajib("trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"); err != nil {
+		return nil, nil, fmt.Errorf("stop_times.txt: %w", err)
+	}
+	type barisStop struct {
+		urutan int
+		stop   stopImpor
+	}
+	stopTrip := make(map[string][]barisStop)
+	for i, row := range st.baris {
+		no := i + 2
+		kode, ok := kodeStop[st.ambil(row, "stop_id")]
+		if !ok {
+			hasil.salah("stop_times.txt", no, "stop_id %s tidak ada di stops.txt", st.ambil(row, "stop_id"))
+			continue
+		}
+		urutan, err := strconv.Atoi(st.ambil(row, "stop_sequence"))
+		if err != nil {
+			hasil.salah("stop_times.txt", no, "stop_sequence harus angka")
+			continue
+		}
+		tiba, err := parseWaktuOpsional(st.ambil(row, "arrival_time"))
+		if err != nil {
+			hasil.salah("stop_times.txt", no, "arrival_time: %v", err)
+			continue
+		}
+		berangkat, err := parseWaktuOpsional(st.ambil(row, "departure_time"))
+		if err != nil {
+			hasil.salah("stop_times.txt", no, "departure_time: %v", err)
+			continue
+		}
+		tripID := st.ambil(row, "trip_id")
+		stopTrip[tripID] = append(stopTrip[tripID], barisStop{urutan: urutan, stop: stopImpor{
+			Kode: kode, Tiba: tiba, Berangkat: berangkat, HargaKumulatif: -1,
+		}})
+	}
+
+	trips := files["trips.txt"]
+	if err := trips.wajib("route_id", "service_id", "trip_id"); err != nil {
+		return nil, nil, fmt.Errorf("trips.txt: %w", err)
+	}
+	var out []perjalananImpor
+	for i, row := range trips.baris {
+		no := i + 2
+		kereta, ok := namaRoute[trips.ambil(row, "route_id")]
+		if !ok {
+			hasil.salah("trips.txt", no, "route_id %s tidak ada di routes.txt", trips.ambil(row, "route_id"))
+			continue
+		}
+		list := stopTrip[trips.ambil(row, "trip_id")]
+		sort.SliceStable(list, func(a, b int) bool { return list[a].urutan < list[b].urutan })
+		stopsTrip := make([]stopImpor, 0, len(list))
+		for _, b := range list {
+			stopsTrip = append(stopsTrip, b.stop)
+		}
+		for _, tgl := range tanggalService[trips.ambil(row, "service_id")] {
+			out = append(out, perjalananImpor{
+				File:    "trips.txt",
+				Baris:   no,
+				Kereta:  kereta,
+				Tanggal: tgl,
+				Kelas:   opsi.Kelas,
+				Harga:   opsi.Harga,
+				Stops:   stopsTrip,
+			})
+		}
+	}
+	return stasiuns, out, nil
+}
+
+func tanggalServiceGtfs(cal, calDates *tabel, dari, sampai time.Time, hasil *HasilImpor) (map[string][]time.Time, error) {
+	aktif := make(map[string]map[string]time.Time)
+	tambah := func(service string, t time.Time) {
+		if aktif[service] == nil {
+			aktif[service] = make(map[string]time.Time)
+		}
+		aktif[service][t.Format(formatTanggal)] = t
+	}
+
+	if cal != nil {
+		hariKolom := []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+		if err := cal.wajib(append([]string{"service_id", "start_date", "end_date"}, hariKolom...)...); err != nil {
+			return nil, fmt.Errorf("calendar.txt: %w", err)
+		}
+		for i, row := range cal.baris {
+			mulai, err1 := time.ParseInLocation("20060102", cal.ambil(row, "start_date"), time.Local)
+			akhir, err2 := time.ParseInLocation("20060102", cal.ambil(row, "end_date"), time.Local)
+			if err1 != nil || err2 != nil {
+				hasil.salah("calendar.txt", i+2, "start_date/end_date harus berformat YYYYMMDD")
+				continue
+			}
+			if mulai.Before(dari) {
+				mulai = dari
+			}
+			if akhir.After(sampai) {
+				akhir = sampai
+			}
+			for d := mulai; !d.After(akhir); d = d.AddDate(0, 0, 1) {
+				if cal.ambil(row, hariKolom[d.Weekday()]) == "1" {
+					tambah(cal.ambil(row, "service_id"), d)
+				}
+			}
+		}
+	}
+
+	if calDates != nil {
+		if err := calDates.wajib("service_id", "date", "exception_type"); err != nil {
+			return nil, fmt.Errorf("calendar_dates.txt: %w", err)
+		}
+		for i, row := range calDates.baris {
+			d, err := time.ParseInLocation("20060102", calDates.ambil(row, "date"), time.Local)
+			if err != nil {
+				hasil.salah("calendar_dates.txt", i+2, "date harus berformat YYYYMMDD")
+				continue
+			}
+			if d.Before(dari) || d.After(sampai) {
+				continue
+			}
+			service := calDates.ambil(row, "service_id")
+			switch calDates.ambil(row, "exception_type") {
+			case "1":
+				tambah(service, d)
+			case "2":
+				delete(aktif[service], d.Format(formatTanggal))
+			default:
+				hasil.salah("calendar_dates.txt", i+2, "exception_type harus 1 atau 2")
+			}
+		}
+	}
+
+	out := make(map[string][]time.Time, len(aktif))
+	for service, m := range aktif {
+		for _, t := range m {
+			out[service] = append(out[service], t)
+		}
+		sort.Slice(out[service], func(a, b int) bool { return out[service][a].Before(out[service][b]) })
+	}
+	return out, nil
+}
+
+// simpan menjalankan seluruh upsert dalam satu transaksi. Transaksi selalu
+// di-rollback pada dry-run atau bila ada kesalahan, sehingga dry-run tetap
+// memeriksa data terhadap isi database.
+func (s *ImporJadwalService) simpan(stasiuns map[string]stasiunImpor, perjalanan []perjalananImpor, opsi OpsiImpor, hasil *HasilImpor) (*HasilImpor, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var ada []models.Stasiun
+		if err := tx.Find(&ada).Error; err != nil {
+			return err
+		}
+		idStasiun := make(map[string]uint, len(ada))
+		for _, st := range ada {
+			idStasiun[st.Kode] = st.ID
+		}
+
+		kodeList := make([]string, 0, len(stasiuns))
+		for kode := range stasiuns {
+			kodeList = append(kodeList, kode)
+		}
+		sort.Strings(kodeList)
+		for _, kode := range kodeList {
+			in := stasiuns[kode]
+			if id, ok := idStasiun[kode]; ok {
+				upd := map[string]interface{}{"updated_at": now}
+				if in.Nama != "" {
+					upd["nama"] = in.Nama
+				}
+				if in.Kota != "" {
+					upd["kota"] = in.Kota
+				}
+				if in.Latitude != nil && in.Longitude != nil {
+					upd["latitude"] = *in.Latitude
+					upd["longitude"] = *in.Longitude
+				}
+				if err := tx.Model(&models.Stasiun{}).Where("id = ?", id).Updates(upd).Error; err != nil {
+					return err
+				}
+				hasil.StasiunDiperbarui++
+				continue
+			}
+			baru := models.Stasiun{
+				Kode: in.Kode, Nama: in.Nama, Kota: in.Kota,
+				Latitude: in.Latitude, Longitude: in.Longitude,
+				CreatedAt: now, UpdatedAt: now,
+			}
+			if err := tx.Create(&baru).Error; err != nil {
+				return err
+			}
+			idStasiun[kode] = baru.ID
+			hasil.StasiunBaru++
+		}
+
+		var keretas []models.Kereta
+		if err := tx.Preload("Gerbongs").Find(&keretas).Error; err != nil {
+			return err
+		}
+		idKereta := make(map[string]uint, len(keretas))
+		kelasKereta := make(map[uint]map[string]bool)
+		for _, k := range keretas {
+			idKereta[k.Nama] = k.ID
+			kelasKereta[k.ID] = make(map[string]bool)
+			for _, g := range k.Gerbongs {
+				kelasKereta[k.ID][g.Kelas] = true
+			}
+		}
+
+		for _, p := range perjalanan {
+			if len(p.Stops) < 2 {
+				hasil.salah(p.File, p.Baris, "perjalanan minimal punya 2 stop")
+				continue
+			}
+			j, msg := jadwalDariImpor(p, idStasiun)
+			if msg != "" {
+				hasil.salah(p.File, p.Baris, "%s", msg)
+				continue
+			}
+
+			// inventori kursi jadwal dibentuk dari gerbong kereta, jadi
+			// kereta dan gerbong kelasnya harus sudah didaftarkan
+			keretaID, ok := idKereta[p.Kereta]
+			if !ok {
+				hasil.salah(p.File, p.Baris, "kereta %s belum terdaftar", p.Kereta)
+				continue
+			}
+			if !kelasKereta[keretaID][p.Kelas] {
+				hasil.salah(p.File, p.Baris, "kereta %s belum punya gerbong kelas %s", p.Kereta, p.Kelas)
+				continue
+			}
+			j.KeretaID = keretaID
+
+			var jumlah int64
+			if err := tx.Model(&models.Jadwal{}).
+				Where("kereta_id = ? AND asal_id = ? AND tanggal = ? AND kelas = ? AND waktu_berangkat = ?",
+					j.KeretaID, j.Stops[0].StasiunID, j.Tanggal, j.Kelas, j.WaktuBerangkat).
+				Count(&jumlah).Error; err != nil {
+				return err
+			}
+			if jumlah > 0 {
+				hasil.JadwalDilewati++
+				continue
+			}
+
+			if err := s.jadwalRepo.BuatTx(tx, j); err != nil {
+				return err
+			}
+			hasil.JadwalBaru++
+		}
+
+		if opsi.DryRun || len(hasil.Kesalahan) > 0 {
+			return errBatalkanImpor
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatalkanImpor) {
+		return nil, err
+	}
+	hasil.Disimpan = err == nil
+	return hasil, nil
+}
+
+func jadwalDariImpor(p perjalananImpor, idStasiun map[string]uint) (*models.Jadwal, string) {
+	j := &models.Jadwal{
+		Tanggal:   p.Tanggal.Format(formatTanggal),
+		Kelas:     p.Kelas,
+		Harga:     p.Harga,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	akhir := len(p.Stops) - 1
+	var sebelum time.Duration = -1
+	for i, in := range p.Stops {
+		id, ok := idStasiun[in.Kode]
+		if !ok {
+			return nil, fmt.Sprintf("stasiun %s belum ada dan tidak disertakan di file", in.Kode)
+		}
+		tiba, berangkat := in.Tiba, in.Berangkat
+		if tiba == nil {
+			tiba = berangkat
+		}
+		if berangkat == nil {
+			berangkat = tiba
+		}
+		if tiba == nil {
+			return nil, fmt.Sprintf("stop %s tidak punya waktu tiba/berangkat", in.Kode)
+		}
+		if *tiba < sebelum || *berangkat < *tiba {
+			return nil, fmt.Sprintf("waktu pada stop %s mundur", in.Kode)
+		}
+		sebelum = *berangkat
+
+		stop := models.JadwalStop{Urutan: i, StasiunID: id}
+		if i > 0 {
+			w := p.Tanggal.Add(*tiba)
+			stop.WaktuTiba = &w
+		}
+		if i < akhir {
+			w := p.Tanggal.Add(*berangkat)
+			stop.WaktuBerangkat = &w
+		}
+		switch {
+		case i == akhir:
+			stop.HargaKumulatif = p.Harga
+		case in.HargaKumulatif >= 0:
+			stop.HargaKumulatif = in.HargaKumulatif
+		}
+		j.Stops = append(j.Stops, stop)
+	}
+	j.WaktuBerangkat = *j.Stops[0].WaktuBerangkat
+	j.WaktuTiba = *j.Stops[akhir].WaktuTiba
+
+	// harga_kumulatif yang kosong (selalu untuk GTFS) dibagi menurut lama
+	// perjalanan sejak stasiun awal
+	total := int64(j.WaktuTiba.Sub(j.WaktuBerangkat) / time.Second)
+	for i := 1; i < akhir; i++ {
+		if p.Stops[i].HargaKumulatif < 0 && total > 0 {
+			lama := int64(j.Stops[i].WaktuTiba.Sub(j.WaktuBerangkat) / time.Second)
+			j.Stops[i].HargaKumulatif = p.Harga * lama / total
+		}
+	}
+	if err := j.ValidasiStops(); err != nil {
+		return nil, err.Error()
+	}
+	return j, ""
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+const headerCsvImpor = "perjalanan,kereta,tanggal,kelas,harga,urutan,kode_stasiun,nama_stasiun,kota,tiba,berangkat,harga_kumulatif\n"
+
+// csvImporValid: satu perjalanan GMR-BD-CN; harga_kumulatif BD kosong dan
+// dihitung dari lama perjalanan (2 dari 4 jam).
+const csvImporValid = headerCsvImpor +
+	"A1,Argo Uji,2026-03-01,eksekutif,150000,1,GMR,Gambir,Jakarta,,08:00,\n" +
+	"A1,Argo Uji,2026-03-01,eksekutif,150000,2,BD,Bandung,Bandung,10:00,10:05,\n" +
+	"A1,Argo Uji,2026-03-01,eksekutif,150000,3,CN,Cirebon,Cirebon,12:00,,\n"
+
+// keretaImporUji mendaftarkan kereta "Argo Uji" dengan satu gerbong
+// eksekutif berisi kapasitas kursi.
+func keretaImporUji(t *testing.T, conn *gorm.DB, kapasitas int) {
+	t.Helper()
+	kereta := models.Kereta{Nama: "Argo Uji"}
+	if err := conn.Create(&kereta).Error; err != nil {
+		t.Fatal(err)
+	}
+	gerbong := models.Gerbong{KeretaID: kereta.ID, NomorGerbong: 1, Kelas: "eksekutif", KapasitasKursi: kapasitas}
+	if err := conn.Create(&gerbong).Error; err != nil {
+		t.Fatal(err)
+	}
+	kursis := models.TataLetakStandar.Susun(gerbong.ID, kapasitas)
+	if err := conn.Create(&kursis).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func jumlahBaris(t *testing.T, conn *gorm.DB, model interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := conn.Model(model).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestImporCsvDryRun(t *testing.T) {
+	conn := dbUji(t)
+	keretaImporUji(t, conn, 4)
+	svc := NewImporJadwalService(conn, repositories.NewJadwalRepo(conn))
+
+	hasil, err := svc.ImporCsv(strings.NewReader(csvImporValid), OpsiImpor{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hasil.Kesalahan) > 0 {
+		t.Fatalf("kesalahan = %v, want kosong", hasil.Kesalahan)
+	}
+	if !hasil.DryRun || hasil.Disimpan || hasil.JadwalBaru != 1 || hasil.StasiunBaru != 3 {
+		t.Errorf("hasil = %+v, want dry-run 1 jadwal dan 3 stasiun baru tanpa disimpan", hasil)
+	}
+	if n := jumlahBaris(t, conn, &models.Jadwal{}); n != 0 {
+		t.Errorf("dry-run menyimpan %d jadwal", n)
+	}
+	if n := jumlahBaris(t, conn, &models.Stasiun{}); n != 0 {
+		t.Errorf("dry-run menyimpan %d stasiun", n)
+	}
+}
+
+func TestImporCsvKesalahanBaris(t *testing.T) {
+	tests := []struct {
+		nama  string
+		baris string
+		pesan string
+	}{
+		{
+			nama:  "kereta belum terdaftar",
+			baris: "B1,Kereta Hantu,2026-03-01,eksekutif,150000,1,GMR,,,,08:00,\nB1,Kereta Hantu,2026-03-01,eksekutif,150000,2,CN,,,12:00,,\n",
+			pesan: "kereta Kereta Hantu belum terdaftar",
+		},
+		{
+			nama:  "kereta tanpa gerbong kelas tersebut",
+			baris: "B1,Argo Uji,2026-03-01,ekonomi,90000,1,GMR,,,,08:00,\nB1,Argo Uji,2026-03-01,ekonomi,90000,2,CN,,,12:00,,\n",
+			pesan: "belum punya gerbong kelas ekonomi",
+		},
+		{
+			nama:  "harga kumulatif melebihi harga stop berikutnya",
+			baris: "B1,Argo Uji,2026-03-01,eksekutif,150000,1,GMR,,,,08:00,\nB1,Argo Uji,2026-03-01,eksekutif,150000,2,BD,,,10:00,10:05,160000\nB1,Argo Uji,2026-03-01,eksekutif,150000,3,CN,,,12:00,,\n",
+			pesan: "stops[2].harga_kumulatif",
+		},
+		{
+			nama:  "waktu mundur",
+			baris: "B1,Argo Uji,2026-03-01,eksekutif,150000,1,GMR,,,,08:00,\nB1,Argo Uji,2026-03-01,eksekutif,150000,2,CN,,,07:00,,\n",
+			pesan: "mundur",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			conn := dbUji(t)
+			keretaImporUji(t, conn, 4)
+			svc := NewImporJadwalService(conn, repositories.NewJadwalRepo(conn))
+
+			hasil, err := svc.ImporCsv(strings.NewReader(csvImporValid+tt.baris), OpsiImpor{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hasil.Kesalahan) != 1 {
+				t.Fatalf("kesalahan = %v, want satu", hasil.Kesalahan)
+			}
+			// baris 2-4 adalah perjalanan valid, perjalanan yang salah mulai baris 5
+			if k := hasil.Kesalahan[0]; k.File != "csv" || k.Baris != 5 || !strings.Contains(k.Pesan, tt.pesan) {
+				t.Errorf("kesalahan = %+v, want csv baris 5 berisi %q", k, tt.pesan)
+			}
+			if hasil.Disimpan {
+				t.Error("impor dengan kesalahan tetap disimpan")
+			}
+			if n := jumlahBaris(t, conn, &models.Jadwal{}); n != 0 {
+				t.Errorf("impor dengan kesalahan menyimpan %d jadwal", n)
+			}
+		})
+	}
+}
+
+func TestImporCsvSimpan(t *testing.T) {
+	conn := dbUji(t)
+	keretaImporUji(t, conn, 4)
+	svc := NewImporJadwalService(conn, repositories.NewJadwalRepo(conn))
+
+	hasil, err := svc.ImporCsv(strings.NewReader(csvImporValid), OpsiImpor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasil.Disimpan || hasil.JadwalBaru != 1 || len(hasil.Kesalahan) > 0 {
+		t.Fatalf("hasil = %+v, want 1 jadwal disimpan", hasil)
+	}
+
+	var jadwal models.Jadwal
+	if err := conn.Preload("Stops", func(db *gorm.DB) *gorm.DB {
+		return db.Order("urutan asc")
+	}).First(&jadwal).Error; err != nil {
+		t.Fatal(err)
+	}
+	var harga []int64
+	for _, s := range jadwal.Stops {
+		harga = append(harga, s.HargaKumulatif)
+	}
+	if len(harga) != 3 || harga[0] != 0 || harga[1] != 75000 || harga[2] != 150000 {
+		t.Errorf("harga kumulatif = %v, want [0 75000 150000]", harga)
+	}
+	if n := jumlahBaris(t, conn, &models.KetersediaanKursi{}); n != 4*2 {
+		t.Errorf("inventori = %d baris, want 8 (4 kursi x 2 segmen)", n)
+	}
+
+	// impor ulang tidak menggandakan jadwal
+	hasil, err = svc.ImporCsv(strings.NewReader(csvImporValid), OpsiImpor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasil.JadwalBaru != 0 || hasil.JadwalDilewati != 1 {
+		t.Errorf("impor ulang: baru %d dilewati %d, want 0 dan 1", hasil.JadwalBaru, hasil.JadwalDilewati)
+	}
+}
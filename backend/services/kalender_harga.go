@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+)
+
+const maksHariKalender = 90
+
+// KalenderHarga mengembalikan harga dewasa terendah dan sisa kursi per hari
+// per kelas untuk satu pasangan stasiun. Data diambil dengan satu query
+// agregat lalu harga dinamis dihitung di memori.
+func (s *TarifService) KalenderHarga(asalID, tujuanID uint, dari time.Time, hari int, kelas string) ([]models.HargaHarian, error) {
+	if asalID == 0 || tujuanID == 0 || asalID == tujuanID {
+		return nil, errors.New("asal dan tujuan wajib diisi dan tidak boleh sama")
+	}
+	if hari <= 0 || hari > maksHariKalender {
+		return nil, errors.New("jumlah hari harus 1 sampai 90")
+	}
+
+	sampai := dari.AddDate(0, 0, hari-1)
+	rows, err := s.jadwalRepo.RingkasanRute(asalID, tujuanID, dari.Format("2006-01-02"), sampai.Format("2006-01-02"), kelas)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	faktorDinamis, err := s.dinamis.FaktorDariMuatan(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.HargaHarian
+	index := make(map[[2]string]int)
+
+	for _, r := range rows {
+		sisa := r.KursiSegmen - r.KursiTerpakai
+		if sisa <= 0 || r.WaktuBerangkat.Before(now) {
+			continue
+		}
+
+		j := models.Jadwal{Harga: r.Harga}
+		harga := j.HargaAntara(&models.JadwalStop{HargaKumulatif: r.HargaNaik}, &models.JadwalStop{HargaKumulatif: r.HargaTurun})
+
+		muatan := 0
+		if r.TotalBaris > 0 {
+			muatan = int(r.BarisTerisi * 100 / r.TotalBaris)
+		}
+		harga = harga * int64(faktorDinamis(r.Kelas, r.WaktuBerangkat, muatan)) / 100
+		harga = s.hargaPerTipe(harga*int64(s.faktor(r.Kelas))/100, models.TipeDewasa)
+
+		key := [2]string{r.Tanggal, r.Kelas}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(out)
+			out = append(out, models.HargaHarian{
+				Tanggal:       r.Tanggal,
+				Kelas:         r.Kelas,
+				HargaTerendah: harga,
+				SisaKursi:     sisa,
+				JumlahJadwal:  1,
+			})
+			continue
+		}
+		if harga < out[i].HargaTerendah {
+			out[i].HargaTerendah = harga
+		}
+		out[i].SisaKursi += sisa
+		out[i].JumlahJadwal++
+	}
+
+	return out, nil
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	// kidHS256 dipakai untuk secret simetris dari config. Token tanpa header
+	// kid juga diverifikasi dengan secret ini, tetapi hanya selama belum ada
+	// kunci asimetris aktif.
+	kidHS256 = "hs256"
+)
+
+type kunciTerbaca struct {
+	kid    string
+	alg    string
+	method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+// PengelolaKunci menyimpan semua kunci penandatangan JWT. Kunci asimetris
+// disimpan di database supaya semua instance API memakai set kunci yang sama;
+// instance lain mengambil kunci baru lewat StartMuatUlangKunci. Private key
+// disimpan terenkripsi dengan JWT_KUNCI_ENKRIPSI.
+type PengelolaKunci struct {
+	repo     repositories.KunciJWTRepo
+	penyandi *PenyandiAmplop
+	hs256    *kunciTerbaca
+
+	mu    sync.RWMutex
+	kunci map[string]*kunciTerbaca
+	aktif string
+}
+
+// NewPengelolaKunci memuat kunci dari database. Selama belum ada kunci
+// asimetris aktif, token ditandatangani dengan secret HS256. Bila alg
+// asimetris dan kunci aktif belum memakai alg tersebut, kunci baru dibuat
+// otomatis. spesEnkripsi berformat "kid:base64,..." seperti IDENTITAS_KUNCI
+// dan wajib diisi untuk alg asimetris.
+func NewPengelolaKunci(repo repositories.KunciJWTRepo, secret, alg, spesEnkripsi string) (*PengelolaKunci, error) {
+	if alg != AlgHS256 && alg != AlgRS256 && alg != AlgEdDSA {
+		return nil, fmt.Errorf("JWT_ALG %q tidak didukung (HS256, RS256, EdDSA)", alg)
+	}
+	if secret == "" {
+		return nil, errors.New("JWT_SECRET wajib diisi")
+	}
+
+	p := &PengelolaKunci{
+		repo:  repo,
+		hs256: &kunciTerbaca{kid: kidHS256, alg: AlgHS256, method: jwt.SigningMethodHS256, sign: []byte(secret), verify: []byte(secret)},
+	}
+	if spesEnkripsi != "" {
+		penyedia, err := NewPenyediaKunciEnv("JWT_KUNCI_ENKRIPSI", spesEnkripsi)
+		if err != nil {
+			return nil, err
+		}
+		p.penyandi = NewPenyandiAmplop(penyedia, nil)
+	} else if alg != AlgHS256 {
+		return nil, fmt.Errorf("JWT_KUNCI_ENKRIPSI wajib diisi untuk JWT_ALG %s", alg)
+	}
+
+	if err := p.sandiKunciLama(); err != nil {
+		return nil, err
+	}
+	if err := p.Muat(); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	aktif := p.kunci[p.aktif]
+	p.mu.RUnlock()
+	if alg != AlgHS256 && aktif.alg != alg {
+		if _, err := p.Rotasi(alg); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Muat membaca ulang kunci yang belum pensiun dari database.
+func (p *PengelolaKunci) Muat() error {
+	list, err := p.repo.ListBerlaku()
+	if err != nil {
+		return err
+	}
+
+	baru := map[string]*kunciTerbaca{}
+	aktif := kidHS256
+	for _, k := range list {
+		kt, err := p.bacaKunci(k)
+		if err != nil {
+			log.Printf("[kunci] kunci %s dilewati: %v", k.Kid, err)
+			continue
+		}
+		baru[k.Kid] = kt
+		if k.Aktif {
+			aktif = k.Kid
+		}
+	}
+	// secret HS256 tidak diterima lagi begitu kunci asimetris aktif, supaya
+	// JWT_SECRET yang bocor tidak bisa dipakai memalsukan token
+	if aktif == kidHS256 {
+		baru[kidHS256] = p.hs256
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.kunci = baru
+	p.aktif = aktif
+	return nil
+}
+
+// sandiKunciLama mengenkripsi private key yang masih tersimpan sebagai PEM
+// biasa.
+func (p *PengelolaKunci) sandiKunciLama() error {
+	if p.penyandi == nil {
+		return nil
+	}
+	list, err := p.repo.ListBerlaku()
+	if err != nil {
+		return err
+	}
+	for _, k := range list {
+		if p.penyandi.Tersandi(k.PrivatePEM) {
+			continue
+		}
+		sandi, err := p.penyandi.Enkripsi(k.PrivatePEM)
+		if err != nil {
+			return err
+		}
+		if err := p.repo.SimpanPEM(k.Kid, sandi); err != nil {
+			return err
+		}
+		log.Printf("[kunci] private key %s dienkripsi", k.Kid)
+	}
+	return nil
+}
+
+func (p *PengelolaKunci) bacaKunci(k models.KunciJWT) (*kunciTerbaca, error) {
+	privPEM := k.PrivatePEM
+	if p.penyandi != nil && p.penyandi.Tersandi(privPEM) {
+		var err error
+		if privPEM, err = p.penyandi.Dekripsi(privPEM); err != nil {
+			return nil, err
+		}
+	} else if p.penyandi == nil && strings.HasPrefix(privPEM, awalanSandi) {
+		return nil, errors.New("private key terenkripsi, JWT_KUNCI_ENKRIPSI belum diisi")
+	}
+
+	block, _ := pem.Decode([]byte(privPEM))
+	if block == nil {
+		return nil, errors.New("PEM tidak valid")
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := priv.(type) {
+	case *rsa.PrivateKey:
+		return &kunciTerbaca{kid: k.Kid, alg: AlgRS256, method: jwt.SigningMethodRS256, sign: key, verify: &key.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &kunciTerbaca{kid: k.Kid, alg: AlgEdDSA, method: jwt.SigningMethodEdDSA, sign: key, verify: key.Public()}, nil
+	default:
+		return nil, fmt.Errorf("tipe kunci %T tidak didukung", priv)
+	}
+}
+
+// Rotasi membuat kunci baru dan menjadikannya kunci aktif. Kunci lama tetap
+// dipakai untuk verifikasi sampai dipensiunkan, jadi token yang sudah terbit
+// tetap berlaku.
+func (p *PengelolaKunci) Rotasi(alg string) (string, error) {
+	var priv crypto.PrivateKey
+	switch alg {
+	case AlgRS256:
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return "", err
+		}
+		priv = k
+	case AlgEdDSA:
+		_, k, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		priv = k
+	default:
+		return "", fmt.Errorf("rotasi hanya untuk RS256 atau EdDSA")
+	}
+	if p.penyandi == nil {
+		return "", errors.New("JWT_KUNCI_ENKRIPSI wajib diisi sebelum membuat kunci asimetris")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", err
+	}
+	acak := make([]byte, 4)
+	if _, err := rand.Read(acak); err != nil {
+		return "", err
+	}
+	sandi, err := p.penyandi.Enkripsi(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if err != nil {
+		return "", err
+	}
+	k := &models.KunciJWT{
+		Kid:        time.Now().Format("20060102") + "-" + hex.EncodeToString(acak),
+		Alg:        alg,
+		PrivatePEM: sandi,
+	}
+	if err := p.repo.BuatAktif(k); err != nil {
+		return "", err
+	}
+
+	if err := p.Muat(); err != nil {
+		return "", err
+	}
+	log.Printf("[kunci] kunci JWT baru aktif: %s (%s)", k.Kid, alg)
+	return k.Kid, nil
+}
+
+// Pensiunkan berhenti menerima token yang ditandatangani kunci tersebut.
+// Lakukan setelah umur access token terlama terlewati sejak rotasi.
+func (p *PengelolaKunci) Pensiunkan(kid string) error {
+	if kid == kidHS256 {
+		return errors.New("secret HS256 otomatis berhenti diterima setelah kunci asimetris aktif")
+	}
+	if err := p.repo.Pensiunkan(kid); err != nil {
+		return err
+	}
+	return p.Muat()
+}
+
+// Tandatangani menandatangani claims dengan kunci aktif dan menaruh kid di
+// header token.
+func (p *PengelolaKunci) Tandatangani(claims jwt.Claims) (string, error) {
+	p.mu.RLock()
+	k := p.kunci[p.aktif]
+	p.mu.RUnlock()
+	if k == nil {
+		return "", errors.New("tidak ada kunci JWT aktif")
+	}
+
+	t := jwt.NewWithClaims(k.method, claims)
+	t.Header["kid"] = k.kid
+	return t.SignedString(k.sign)
+}
+
+// Keyfunc dipakai jwt.Parse untuk memilih kunci verifikasi berdasarkan kid.
+func (p *PengelolaKunci) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = kidHS256
+	}
+
+	p.mu.RLock()
+	k := p.kunci[kid]
+	p.mu.RUnlock()
+	if k == nil {
+		return nil, fmt.Errorf("kid tidak dikenal: %s", kid)
+	}
+	if t.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	return k.verify, nil
+}
+
+// JWKS mengembalikan kunci publik yang masih berlaku (tanpa secret HS256)
+// dalam format JSON Web Key Set.
+func (p *PengelolaKunci) JWKS() map[string]interface{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys := make([]map[string]string, 0, len(p.kunci))
+	for _, k := range p.kunci {
+		switch pub := k.verify.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": AlgRS256,
+				"kid": k.kid,
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"use": "sig",
+				"alg": AlgEdDSA,
+				"kid": k.kid,
+				"x":   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
+// StartMuatUlangKunci memuat ulang kunci berkala agar rotasi dari instance
+// lain ikut terbaca.
+func StartMuatUlangKunci(ctx context.Context, p *PengelolaKunci, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.Muat(); err != nil {
+					log.Printf("[kunci] gagal memuat ulang kunci JWT: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+type InfoKunci struct {
+	Kid   string `json:"kid"`
+	Alg   string `json:"alg"`
+	Aktif bool   `json:"aktif"`
+}
+
+// Daftar mengembalikan kunci yang sedang dipakai untuk verifikasi.
+func (p *PengelolaKunci) Daftar() []InfoKunci {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	out := make([]InfoKunci, 0, len(p.kunci))
+	for _, k := range p.kunci {
+		out = append(out, InfoKunci{Kid: k.kid, Alg: k.alg, Aktif: k.kid == p.aktif})
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Kid < out[b].Kid })
+	return out
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+)
+
+const (
+	PosisiJendela = "jendela"
+	PosisiLorong  = "lorong"
+)
+
+// PreferensiKursi dipakai saat kursi dipilih otomatis. Semua preferensi
+// bersifat "sebisa mungkin" kecuali SatuGerbong yang wajib.
+type PreferensiKursi struct {
+	Posisi      string `json:"posisi"`       // jendela, lorong, atau kosong
+	Arah        string `json:"arah"`         // maju, mundur, atau kosong
+	Bersama     bool   `json:"bersama"`      // rombongan duduk berdekatan
+	SatuGerbong bool   `json:"satu_gerbong"` // semua penumpang di gerbong yang sama
+}
+
+var ErrKursiTidakCukup = errors.New("kursi tersedia tidak cukup untuk semua penumpang")
+
+func (p *PreferensiKursi) validasi() error {
+	p.Posisi = strings.ToLower(strings.TrimSpace(p.Posisi))
+	p.Arah = strings.ToLower(strings.TrimSpace(p.Arah))
+	if p.Posisi != "" && p.Posisi != PosisiJendela && p.Posisi != PosisiLorong {
+		return fmt.Errorf("preferensi posisi harus jendela atau lorong")
+	}
+	if p.Arah != "" && p.Arah != models.ArahMaju && p.Arah != models.ArahMundur {
+		return fmt.Errorf("preferensi arah harus maju atau mundur")
+	}
+	return nil
+}
+
+type kandidatKursi struct {
+	ID           uint
+	GerbongID    uint
+	NomorGerbong int
+	Baris        int
+	Kolom        int
+	Jendela      bool
+	Lorong       bool
+	Khusus       bool // kursi prioritas atau tempat kursi roda
+	Arah         string
+}
+
+func (k *kandidatKursi) penalti(p PreferensiKursi) int {
+	n := 0
+	if p.Posisi == PosisiJendela && !k.Jendela || p.Posisi == PosisiLorong && !k.Lorong {
+		n += 3
+	}
+	if p.Arah != "" && p.Arah != k.Arah {
+		n += 3
+	}
+	// kursi prioritas disisakan untuk yang membutuhkan
+	if k.Khusus {
+		n += 4
+	}
+	return n
+}
+
+// biayaRombongan menilai sekelompok kursi berurutan (baris lalu kolom):
+// makin sedikit baris yang dipakai dan makin rapat kursinya, makin murah.
+func biayaRombongan(grup []kandidatKursi, p PreferensiKursi) int {
+	biaya := 0
+	minBaris, maksBaris := grup[0].Baris, grup[0].Baris
+	for i := range grup {
+		biaya += grup[i].penalti(p)
+		if grup[i].Baris < minBaris {
+			minBaris = grup[i].Baris
+		}
+		if grup[i].Baris > maksBaris {
+			maksBaris = grup[i].Baris
+		}
+		if i == 0 {
+			continue
+		}
+		a, b := grup[i-1], grup[i]
+		if a.Baris != b.Baris {
+			continue
+		}
+		if b.Kolom-a.Kolom > 1 {
+			biaya += 5
+		}
+		// dua kursi lorong bersebelahan berarti dipisahkan lorong
+		if a.Lorong && b.Lorong {
+			biaya += 2
+		}
+	}
+	return biaya + 10*(maksBaris-minBaris)
+}
+
+// pilihKursi memilih n kursi dari kandidat yang semuanya tersedia.
+func pilihKursi(kandidat []kandidatKursi, n int, p PreferensiKursi) ([]uint, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	if len(kandidat) < n {
+		return nil, ErrKursiTidakCukup
+	}
+
+	sort.SliceStable(kandidat, func(a, b int) bool {
+		x, y := kandidat[a], kandidat[b]
+		if x.NomorGerbong != y.NomorGerbong {
+			return x.NomorGerbong < y.NomorGerbong
+		}
+		if x.Baris != y.Baris {
+			return x.Baris < y.Baris
+		}
+		return x.Kolom < y.Kolom
+	})
+
+	var gerbongs [][]kandidatKursi
+	for i := range kandidat {
+		if i == 0 || kandidat[i].GerbongID != kandidat[i-1].GerbongID {
+			gerbongs = append(gerbongs, nil)
+		}
+		gerbongs[len(gerbongs)-1] = append(gerbongs[len(gerbongs)-1], kandidat[i])
+	}
+
+	var terbaik []kandidatKursi
+	biayaTerbaik := -1
+	pertimbangkan := func(grup []kandidatKursi, biaya int) {
+		if biayaTerbaik < 0 || biaya < biayaTerbaik {
+			terbaik = append([]kandidatKursi(nil), grup...)
+			biayaTerbaik = biaya
+		}
+	}
+
+	if p.Bersama && n > 1 {
+		for _, g := range gerbongs {
+			for i := 0; i+n <= len(g); i++ {
+				pertimbangkan(g[i:i+n], biayaRombongan(g[i:i+n], p))
+			}
+		}
+	}
+
+	if terbaik == nil && p.SatuGerbong {
+		for _, g := range gerbongs {
+			if len(g) < n {
+				continue
+			}
+			pilihan := urutkanPenalti(g, p)[:n]
+			biaya := 0
+			for i := range pilihan {
+				biaya += pilihan[i].penalti(p)
+			}
+			pertimbangkan(pilihan, biaya)
+		}
+		if terbaik == nil {
+			return nil, fmt.Errorf("%w: tidak ada gerbong dengan %d kursi kosong", ErrKursiTidakCukup, n)
+		}
+	}
+
+	if terbaik == nil {
+		terbaik = urutkanPenalti(kandidat, p)[:n]
+	}
+
+	ids := make([]uint, len(terbaik))
+	for i := range terbaik {
+		ids[i] = terbaik[i].ID
+	}
+	return ids, nil
+}
+
+// urutkanPenalti mengurutkan salinan kursi dari yang paling cocok dengan
+// preferensi, dengan urutan gerbong/baris sebagai penentu seri.
+func urutkanPenalti(list []kandidatKursi, p PreferensiKursi) []kandidatKursi {
+	out := append([]kandidatKursi(nil), list...)
+	sort.SliceStable(out, func(a, b int) bool {
+		return out[a].penalti(p) < out[b].penalti(p)
+	})
+	return out
+}
+
+// pilihKursiOtomatis mengunci semua baris ketersediaan kursi kelas jadwal
+// pada segmen yang dilewati, lalu memilih n kursi yang kosong. Kunci ditahan
+// sampai transaksi booking selesai, jadi dua booking otomatis pada jadwal
+// yang sama tidak memilih kursi yang sama.
+func pilihKursiOtomatis(tx *gorm.DB, jadwal *models.Jadwal, naik, turun *models.JadwalStop, n int, p PreferensiKursi) ([]uint, error) {
+	var kursis []models.Kursi
+	if err := tx.Joins("Gerbong").
+		Where("Gerbong.kereta_id = ? AND Gerbong.kelas = ?", jadwal.KeretaID, jadwal.Kelas).
+		Order("kursis.id asc").
+		Find(&kursis).Error; err != nil {
+		return nil, err
+	}
+	if len(kursis) == 0 {
+		return nil, ErrKursiTidakCukup
+	}
+
+	ids := make([]uint, len(kursis))
+	for i := range kursis {
+		ids[i] = kursis[i].ID
+	}
+	var inv []models.KetersediaanKursi
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("train_schedule_id = ? AND seat_id IN ? AND segmen >= ? AND segmen < ?", jadwal.ID, ids, naik.Urutan, turun.Urutan).
+		Order("seat_id asc, segmen asc").
+		Find(&inv).Error; err != nil {
+		return nil, err
+	}
+	terisi := make(map[uint]bool)
+	for _, r := range inv {
+		if r.Status != "available" {
+			terisi[r.SeatID] = true
+		}
+	}
+
+	kandidat := make([]kandidatKursi, 0, len(kursis))
+	for _, k := range kursis {
+		if terisi[k.ID] {
+			continue
+		}
+		k.LengkapiAtribut()
+		kolom := 0
+		if k.Kolom != "" {
+			kolom = int(k.Kolom[0])
+		}
+		kandidat = append(kandidat, kandidatKursi{
+			ID:           k.ID,
+			GerbongID:    k.GerbongID,
+			NomorGerbong: k.Gerbong.NomorGerbong,
+			Baris:        k.Baris,
+			Kolom:        kolom,
+			Jendela:      k.Jendela,
+			Lorong:       k.Lorong,
+			Khusus:       k.Prioritas || k.KursiRoda,
+			Arah:         k.Arah,
+		})
+	}
+	return pilihKursi(kandidat, n, p)
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+)
+
+// kandidatUji membuat kandidat dari nomor kursi pada gerbong 2-2 dengan
+// baris ganjil menghadap mundur dan baris 1 sebagai kursi prioritas.
+// nama memetakan ID kandidat ke "g<nomor gerbong>-<nomor kursi>".
+func kandidatUji(nama map[uint]string, nomorGerbong int, nomor ...string) []kandidatKursi {
+	tataLetak := models.TataLetakGerbong{Kolom: "AB|CD", BarisMundur: "ganjil", KursiPrioritas: "1"}
+	var out []kandidatKursi
+	for _, n := range nomor {
+		k := models.Kursi{NomorKursi: n}
+		k.Baris, k.Kolom = k.Posisi()
+		tataLetak.Terapkan(&k)
+		id := uint(len(nama) + 1)
+		nama[id] = fmt.Sprintf("g%d-%s", nomorGerbong, n)
+		out = append(out, kandidatKursi{
+			ID:           id,
+			GerbongID:    uint(nomorGerbong),
+			NomorGerbong: nomorGerbong,
+			Baris:        k.Baris,
+			Kolom:        int(k.Kolom[0]),
+			Jendela:      k.Jendela,
+			Lorong:       k.Lorong,
+			Khusus:       k.Prioritas || k.KursiRoda,
+			Arah:         k.Arah,
+		})
+	}
+	return out
+}
+
+func TestPilihKursi(t *testing.T) {
+	tests := []struct {
+		nama       string
+		kandidat   func(nama map[uint]string) []kandidatKursi
+		n          int
+		preferensi PreferensiKursi
+		want       []string
+	}{
+		{
+			nama: "rombongan memilih kursi bersebelahan daripada terpisah",
+			kandidat: func(nama map[uint]string) []kandidatKursi {
+				return kandidatUji(nama, 1, "2A", "2D", "3A", "4A", "4B")
+			},
+			n:          2,
+			preferensi: PreferensiKursi{Bersama: true},
+			want:       []string{"g1-4A", "g1-4B"},
+		},
+		{
+			nama: "rombongan menghindari kursi yang dipisahkan lorong",
+			kandidat: func(nama map[uint]string) []kandidatKursi {
+				return kandidatUji(nama, 1, "2B", "2C", "4C", "4D")
+			},
+			n:          2,
+			preferensi: PreferensiKursi{Bersama: true},
+			want:       []string{"g1-4C", "g1-4D"},
+		},
+		{
+			nama: "rombongan tetap di gerbong yang sama",
+			kandidat: func(nama map[uint]string) []kandidatKursi {
+				return append(kandidatUji(nama, 1, "16D"), kandidatUji(nama, 2, "2A", "2B")...)
+			},
+			n:          2,
+			preferensi: PreferensiKursi{Bersama: true},
+			want:       []string{"g2-2A", "g2-2B"},
+		},
+		{
+			nama: "preferensi jendela",
+			kandidat: func(nama map[uint]string) []kandidatKursi {
+				return kandidatUji(nama, 1, "2A", "2B", "2C", "2D")
+			},
+			n:          2,
+			preferensi: PreferensiKursi{Posisi: PosisiJendela},
+			want:       []string{"g1-2A", "g1-2D"},
+		},
+		{
+			nama: "preferensi lorong",
+			kandidat: func(nama map[uint]string) []kandidatKursi {
+				return kandidatUji(nama, 1, "2A", "2B", "2C", "2D")
+			},
+			n:          2,
+			preferensi: PreferensiKursi{Posisi: PosisiLorong},
+			want:       []string{"g1-2B", "g1-2C"},
+		},
+		{
+			nama: "preferensi arah maju melewati baris mundur",
+			kandidat: func(nama map[uint]string) []kandidatKursi {
+				return kandidatUji(nama, 1, "3A", "3B", "4A", "4B")
+			},
+			n:          2,
+			preferensi: PreferensiKursi{Arah: models.ArahMaju},
+			want:       []string{"g1-4A", "g1-4B"},
+		},
+		{
+			nama: "jendela dan arah sama-sama dipertimbangkan",
+			kandidat: func(nama map[uint]string) []kandidatKursi {
+				return kandidatUji(nama, 1, "3A", "4B", "4D")
+			},
+			n:          1,
+			preferensi: PreferensiKursi{Posisi: PosisiJendela, Arah: models.ArahMaju},
+			want:       []string{"g1-4D"},
+		},
+		{
+			nama: "kursi prioritas disisakan",
+			kandidat: func(nama map[uint]string) []kandidatKursi {
+				return kandidatUji(nama, 1, "1A", "1B", "2C")
+			},
+			n:    1,
+			want: []string{"g1-2C"},
+		},
+		{
+			nama: "satu gerbong melewati gerbong yang kurang",
+			kandidat: func(nama map[uint]string) []kandidatKursi {
+				return append(kandidatUji(nama, 1, "2A"), kandidatUji(nama, 2, "2A", "6C")...)
+			},
+			n:          2,
+			preferensi: PreferensiKursi{SatuGerbong: true},
+			want:       []string{"g2-2A", "g2-6C"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			nama := map[uint]string{}
+			ids, err := pilihKursi(tt.kandidat(nama), tt.n, tt.preferensi)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, id := range ids {
+				got = append(got, nama[id])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pilihKursi = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPilihKursiTidakCukup(t *testing.T) {
+	nama := map[uint]string{}
+	if _, err := pilihKursi(kandidatUji(nama, 1, "2A"), 2, PreferensiKursi{}); !errors.Is(err, ErrKursiTidakCukup) {
+		t.Errorf("kandidat kurang: err = %v, want ErrKursiTidakCukup", err)
+	}
+	kandidat := append(kandidatUji(nama, 1, "2A"), kandidatUji(nama, 2, "2A")...)
+	if _, err := pilihKursi(kandidat, 2, PreferensiKursi{SatuGerbong: true}); !errors.Is(err, ErrKursiTidakCukup) {
+		t.Errorf("satu gerbong: err = %v, want ErrKursiTidakCukup", err)
+	}
+}
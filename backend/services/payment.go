@@ -36,7 +36,7 @@ func NewPaymentService(cfg *config.Config, repo repositories.PaymentRepo, bookin
 	return &PaymentService{cfg: cfg, repo: repo, bookingSvc: bookingSvc, snapClient: client}
 }
 
-func (s *PaymentService) CreatePayment(ctx context.Context, bookingID uint, amount int64) (map[string]interface{}, error) {
+func (s *PaymentService) CreatePayment(ctx context.Context, bookingID uint, amount int64, items []models.BookingItem) (map[string]interface{}, error) {
 	orderID := fmt.Sprintf("booking-%d-%d", bookingID, time.Now().Unix())
 
 	p := &models.Payment{
@@ -56,14 +56,7 @@ func (s *PaymentService) CreatePayment(ctx context.Context, bookingID uint, amou
 			OrderID:  orderID,
 			GrossAmt: int64(amount),
 		},
-		Items: &[]midtrans.ItemDetails{
-			{
-				ID:    "booking-" + strconv.Itoa(int(bookingID)),
-				Price: int64(amount),
-				Qty:   1,
-				Name:  "Booking tiket",
-			},
-		},
+		Items:          buildItemDetails(bookingID, amount, items),
 		CustomerDetail: &midtrans.CustomerDetails{},
 		CreditCard:     &snap.CreditCardDetails{Secure: true},
 	}
@@ -81,6 +74,36 @@ func (s *PaymentService) CreatePayment(ctx context.Context, bookingID uint, amou
 	return out, nil
 }
 
+// buildItemDetails memetakan rincian harga booking ke item_details Midtrans.
+// Midtrans menolak transaksi jika jumlah item tidak sama dengan gross amount,
+// jadi booking lama tanpa rincian (atau yang rinciannya tidak cocok) dikirim
+// sebagai satu item.
+func buildItemDetails(bookingID uint, amount int64, items []models.BookingItem) *[]midtrans.ItemDetails {
+	var sum int64
+	out := make([]midtrans.ItemDetails, 0, len(items))
+	for _, it := range items {
+		sum += it.HargaSatuan * int64(it.Jumlah)
+		out = append(out, midtrans.ItemDetails{
+			ID:    it.Kode,
+			Name:  it.Nama,
+			Price: it.HargaSatuan,
+			Qty:   int32(it.Jumlah),
+		})
+	}
+
+	if len(out) == 0 || sum != amount {
+		out = []midtrans.ItemDetails{
+			{
+				ID:    "booking-" + strconv.Itoa(int(bookingID)),
+				Price: int64(amount),
+				Qty:   1,
+				Name:  "Booking tiket",
+			},
+		}
+	}
+	return &out
+}
+
 func (s *PaymentService) VerifyMidtransSignature(orderID, statusCode, grossAmount, signatureKey string) bool {
 	payload := orderID + statusCode + grossAmount + s.cfg.MidtransServerKey
 	hasher := sha512.New()
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+// ErrLoginGagal sengaja sama untuk email tidak dikenal dan password salah
+// agar tidak bisa dipakai menebak email terdaftar.
+var ErrLoginGagal = errors.New("email atau password salah")
+
+// TerlaluBanyakPercobaanError dikembalikan saat akun atau IP sedang ditahan.
+type TerlaluBanyakPercobaanError struct {
+	CobaLagi time.Duration
+}
+
+func (e *TerlaluBanyakPercobaanError) Error() string {
+	return fmt.Sprintf("terlalu banyak percobaan login, coba lagi dalam %d detik", int(e.CobaLagi.Seconds()+0.5))
+}
+
+type kunciKlien struct{}
+
+// InfoKlien adalah asal request login, dibawa lewat context.
+type InfoKlien struct {
+	IP        string
+	UserAgent string
+}
+
+func DenganInfoKlien(ctx context.Context, info InfoKlien) context.Context {
+	return context.WithValue(ctx, kunciKlien{}, info)
+}
+
+func infoKlienDari(ctx context.Context) InfoKlien {
+	info, _ := ctx.Value(kunciKlien{}).(InfoKlien)
+	return info
+}
+
+const (
+	// jeda mulai berlaku setelah gagalTanpaJeda kali gagal, lalu berlipat dua
+	// setiap kegagalan berikutnya sampai jedaMaks
+	gagalTanpaJeda = 3
+	jedaAwal       = 1 * time.Second
+	jedaMaks       = 60 * time.Second
+)
+
+// PelindungLogin membatasi percobaan login per akun (email) dan per IP
+// berdasarkan riwayat di tabel percobaan_logins.
+type PelindungLogin struct {
+	repo       repositories.PercobaanLoginRepo
+	maksAkun   int
+	maksIP     int
+	lamaBlokir time.Duration
+}
+
+func NewPelindungLogin(repo repositories.PercobaanLoginRepo, maksAkun, maksIP, blokirMenit int) *PelindungLogin {
+	return &PelindungLogin{
+		repo:       repo,
+		maksAkun:   maksAkun,
+		maksIP:     maksIP,
+		lamaBlokir: time.Duration(blokirMenit) * time.Minute,
+	}
+}
+
+func jedaUntuk(gagal int64) time.Duration {
+	if gagal < gagalTanpaJeda {
+		return 0
+	}
+	jeda := jedaAwal
+	for i := int64(gagalTanpaJeda); i < gagal && jeda < jedaMaks; i++ {
+		jeda *= 2
+	}
+	if jeda > jedaMaks {
+		jeda = jedaMaks
+	}
+	return jeda
+}
+
+// Periksa mengembalikan TerlaluBanyakPercobaanError bila email atau IP masih
+// dalam masa jeda/blokir.
+func (p *PelindungLogin) Periksa(email, ip string, now time.Time) error {
+	sejak := now.Add(-p.lamaBlokir)
+
+	gagal, terakhir, err := p.repo.GagalTerakhir("email", email, sejak)
+	if err != nil {
+		return err
+	}
+	if terakhir != nil {
+		tunggu := jedaUntuk(gagal)
+		if gagal >= int64(p.maksAkun) {
+			tunggu = p.lamaBlokir
+		}
+		if sisa := terakhir.Add(tunggu).Sub(now); sisa > 0 {
+			return &TerlaluBanyakPercobaanError{CobaLagi: sisa}
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+	gagal, terakhir, err = p.repo.GagalTerakhir("ip", ip, sejak)
+	if err != nil {
+		return err
+	}
+	if terakhir != nil && gagal >= int64(p.maksIP) {
+		if sisa := terakhir.Add(p.lamaBlokir).Sub(now); sisa > 0 {
+			return &TerlaluBanyakPercobaanError{CobaLagi: sisa}
+		}
+	}
+	return nil
+}
+
+func (p *PelindungLogin) Catat(ctx context.Context, email string, userID *uint, alasan string) {
+	info := infoKlienDari(ctx)
+	ua := info.UserAgent
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+	err := p.repo.Catat(&models.PercobaanLogin{
+		Email:     email,
+		UserID:    userID,
+		IP:        info.IP,
+		UserAgent: ua,
+		Berhasil:  alasan == models.AlasanLoginBerhasil,
+		Alasan:    alasan,
+	})
+	if err != nil {
+		log.Printf("[auth] gagal mencatat percobaan login %s: %v", email, err)
+	}
+}
+
+func normalisasiEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
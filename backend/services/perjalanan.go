@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+const (
+	maksHasilItinerary = 50
+	maksTungguTransit  = 12 * time.Hour
+)
+
+type PerjalananService struct {
+	db              *gorm.DB
+	jadwalRepo      repositories.JadwalRepo
+	tarif           *TarifService
+	defaultTransfer time.Duration
+}
+
+func NewPerjalananService(db *gorm.DB, jr repositories.JadwalRepo, ts *TarifService, defaultTransferMenit int) *PerjalananService {
+	return &PerjalananService{
+		db:              db,
+		jadwalRepo:      jr,
+		tarif:           ts,
+		defaultTransfer: time.Duration(defaultTransferMenit) * time.Minute,
+	}
+}
+
+type titikBerangkat struct {
+	jadwal *models.Jadwal
+	idx    int
+}
+
+// Cari menyusun itinerary dari asal ke tujuan dengan maksimal maksTransit kali
+// pindah kereta. Kaki pertama harus berangkat pada tanggal yang diminta,
+// kaki lanjutan boleh berangkat sampai hari berikutnya.
+func (s *PerjalananService) Cari(ctx context.Context, asalID, tujuanID uint, tanggal, kelas string, maksTransit int, urut string) ([]models.Itinerary, error) {
+	if asalID == 0 || tujuanID == 0 || asalID == tujuanID {
+		return nil, errors.New("asal dan tujuan wajib diisi dan tidak boleh sama")
+	}
+	if maksTransit < 0 || maksTransit > 2 {
+		return nil, errors.New("maks_transit harus 0 sampai 2")
+	}
+
+	hari, err := time.Parse("2006-01-02", tanggal)
+	if err != nil {
+		return nil, errors.New("format tanggal harus YYYY-MM-DD")
+	}
+	sampai := hari.AddDate(0, 0, 1).Format("2006-01-02")
+
+	db := s.db.WithContext(ctx)
+	list, err := s.jadwalRepo.ListByRentangTanggalTx(db, tanggal, sampai, kelas)
+	if err != nil {
+		return nil, err
+	}
+
+	ptrs := make([]*models.Jadwal, 0, len(list))
+	for i := range list {
+		ptrs = append(ptrs, &list[i])
+	}
+	harga, err := s.tarif.FaktorHarga(db, ptrs)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[uint][]titikBerangkat)
+	for i := range list {
+		j := &list[i]
+		for k := 0; k < len(j.Stops)-1; k++ {
+			sid := j.Stops[k].StasiunID
+			index[sid] = append(index[sid], titikBerangkat{jadwal: j, idx: k})
+		}
+	}
+	for sid := range index {
+		titik := index[sid]
+		sort.Slice(titik, func(a, b int) bool {
+			return titik[a].jadwal.BerangkatDari(&titik[a].jadwal.Stops[titik[a].idx]).
+				Before(titik[b].jadwal.BerangkatDari(&titik[b].jadwal.Stops[titik[b].idx]))
+		})
+	}
+
+	var hasil []models.Itinerary
+	dikunjungi := map[uint]bool{asalID: true}
+	dipakai := make(map[uint]bool)
+
+	var telusuri func(stasiunID uint, siapSejak *time.Time, legs []models.ItineraryLeg)
+	telusuri = func(stasiunID uint, siapSejak *time.Time, legs []models.ItineraryLeg) {
+		for _, t := range index[stasiunID] {
+			j := t.jadwal
+			if dipakai[j.ID] {
+				continue
+			}
+			naik := &j.Stops[t.idx]
+			berangkat := j.BerangkatDari(naik)
+			if siapSejak == nil {
+				if j.Tanggal != tanggal {
+					continue
+				}
+			} else {
+				if berangkat.Before(*siapSejak) {
+					continue
+				}
+				if berangkat.Sub(*siapSejak) > maksTungguTransit {
+					break
+				}
+			}
+
+			dipakai[j.ID] = true
+			for k := t.idx + 1; k < len(j.Stops); k++ {
+				turun := &j.Stops[k]
+				if dikunjungi[turun.StasiunID] {
+					continue
+				}
+
+				leg := models.ItineraryLeg{
+					JadwalID:       j.ID,
+					KeretaID:       j.KeretaID,
+					NamaKereta:     j.Kereta.Nama,
+					Kelas:          j.Kelas,
+					NaikStasiunID:  naik.StasiunID,
+					Naik:           naik.Stasiun,
+					TurunStasiunID: turun.StasiunID,
+					Turun:          turun.Stasiun,
+					WaktuBerangkat: berangkat,
+					WaktuTiba:      j.TibaDi(turun),
+					Harga:          harga.Harga(j, j.HargaAntara(naik, turun)),
+				}
+				next := append(append([]models.ItineraryLeg{}, legs...), leg)
+
+				// Tujuan tidak pernah jadi titik transit: berhenti di sini
+				// supaya kaki ini tidak melewati tujuan lalu kembali lagi.
+				if turun.StasiunID == tujuanID {
+					hasil = append(hasil, susunItinerary(next))
+					break
+				}
+				if len(next) > maksTransit {
+					continue
+				}
+
+				siap := leg.WaktuTiba.Add(s.waktuTransfer(&turun.Stasiun))
+				dikunjungi[turun.StasiunID] = true
+				telusuri(turun.StasiunID, &siap, next)
+				delete(dikunjungi, turun.StasiunID)
+			}
+			delete(dipakai, j.ID)
+		}
+	}
+	telusuri(asalID, nil, nil)
+
+	urutkanItinerary(hasil, urut)
+	if len(hasil) > maksHasilItinerary {
+		hasil = hasil[:maksHasilItinerary]
+	}
+	return hasil, nil
+}
+
+func (s *PerjalananService) waktuTransfer(st *models.Stasiun) time.Duration {
+	if st.MinTransferMenit > 0 {
+		return time.Duration(st.MinTransferMenit) * time.Minute
+	}
+	return s.defaultTransfer
+}
+
+func susunItinerary(legs []models.ItineraryLeg) models.Itinerary {
+	it := models.Itinerary{
+		Legs:           legs,
+		JumlahTransit:  len(legs) - 1,
+		WaktuBerangkat: legs[0].WaktuBerangkat,
+		WaktuTiba:      legs[len(legs)-1].WaktuTiba,
+	}
+	for _, l := range legs {
+		it.TotalHarga += l.Harga
+	}
+	it.DurasiMenit = int(it.WaktuTiba.Sub(it.WaktuBerangkat).Minutes())
+	return it
+}
+
+// urutkanItinerary mengurutkan berdasarkan kriteria utama ("tiba", "durasi",
+// atau "harga"), lalu kriteria lain sebagai pemecah seri.
+func urutkanItinerary(list []models.Itinerary, urut string) {
+	tiba := func(a, b *models.Itinerary) int {
+		switch {
+		case a.WaktuTiba.Before(b.WaktuTiba):
+			return -1
+		case a.WaktuTiba.After(b.WaktuTiba):
+			return 1
+		}
+		return 0
+	}
+	durasi := func(a, b *models.Itinerary) int { return a.DurasiMenit - b.DurasiMenit }
+	harga := func(a, b *models.Itinerary) int {
+		switch {
+		case a.TotalHarga < b.TotalHarga:
+			return -1
+		case a.TotalHarga > b.TotalHarga:
+			return 1
+		}
+		return 0
+	}
+	transit := func(a, b *models.Itinerary) int { return a.JumlahTransit - b.JumlahTransit }
+
+	var kriteria []func(a, b *models.Itinerary) int
+	switch urut {
+	case "durasi":
+		kriteria = []func(a, b *models.Itinerary) int{durasi, tiba, harga, transit}
+	case "harga":
+		kriteria = []func(a, b *models.Itinerary) int{harga, tiba, durasi, transit}
+	default:
+		kriteria = []func(a, b *models.Itinerary) int{tiba, durasi, harga, transit}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		for _, k := range kriteria {
+			if c := k(&list[i], &list[j]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
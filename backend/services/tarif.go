@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
+)
+
+type LayananInput struct {
+	Kode   string `json:"kode"`
+	Jumlah int    `json:"jumlah"`
+}
+
+// TarifService menghitung harga booking di sisi server. Harga dari client
+// tidak pernah dipakai langsung, hanya dicocokkan dengan hasil Hitung.
+type TarifService struct {
+	db          *gorm.DB
+	layananRepo repositories.LayananRepo
+	jadwalRepo  repositories.JadwalRepo
+	voucherSvc  *VoucherService
+	dinamis     *HargaDinamisService
+	faktorKelas map[string]int // persen dari harga jadwal, default 100
+	diskonTipe  map[string]int // persen potongan per tipe penumpang
+}
+
+func NewTarifService(db *gorm.DB, lr repositories.LayananRepo, jr repositories.JadwalRepo, vs *VoucherService, hd *HargaDinamisService, faktorKelas, diskonTipe string) *TarifService {
+	return &TarifService{
+		db:          db,
+		layananRepo: lr,
+		jadwalRepo:  jr,
+		voucherSvc:  vs,
+		dinamis:     hd,
+		faktorKelas: parsePetaInt(faktorKelas),
+		diskonTipe:  parsePetaInt(diskonTipe),
+	}
+}
+
+// parsePetaInt membaca format "kunci:angka,...", misalnya persen
+// "eksekutif:100,bisnis:90" atau menit "web:15,loket:5".
+func parsePetaInt(raw string) map[string]int {
+	out := make(map[string]int)
+	for _, part := range strings.Split(raw, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || v < 0 {
+			continue
+		}
+		out[strings.ToLower(strings.TrimSpace(kv[0]))] = v
+	}
+	return out
+}
+
+// Quote menghitung harga untuk ditampilkan ke client. Voucher hanya
+// dipratinjau, kuotanya baru ditahan saat booking dibuat.
+func (s *TarifService) Quote(ctx context.Context, userID *uint, req PermintaanBooking) (*models.Quote, error) {
+	quote, konteks, err := s.hitung(s.db.WithContext(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+	if req.KodeVoucher != "" {
+		konteks.UserID = userID
+		item, err := s.voucherSvc.Pratinjau(ctx, req.KodeVoucher, *konteks)
+		if err != nil {
+			return nil, err
+		}
+		quote.Tambah(*item)
+	}
+	return quote, nil
+}
+
+func (s *TarifService) Hitung(tx *gorm.DB, req PermintaanBooking) (*models.Quote, error) {
+	quote, _, err := s.hitung(tx, req)
+	return quote, err
+}
+
+// hitung menyusun quote dari harga segmen tiap leg, harga dinamis, faktor kelas, tipe
+// penumpang, dan layanan tambahan. Dipanggil di dalam transaksi booking agar
+// harga yang disimpan sama dengan jadwal yang dikunci. Tipe penumpang pada
+// req.Penumpangs dinormalisasi di tempat berdasarkan keberangkatan leg pertama.
+// Selain quote, dikembalikan juga konteks rute/kelas untuk syarat voucher.
+func (s *TarifService) hitung(tx *gorm.DB, req PermintaanBooking) (*models.Quote, *KonteksVoucher, error) {
+	if len(req.Legs) == 0 {
+		return nil, nil, fmt.Errorf("booking minimal memiliki satu jadwal")
+	}
+	if len(req.Penumpangs) == 0 {
+		return nil, nil, fmt.Errorf("penumpang wajib diisi")
+	}
+
+	quote := &models.Quote{}
+	konteks := &KonteksVoucher{}
+
+	for i, leg := range req.Legs {
+		var jadwal models.Jadwal
+		if err := tx.Preload("Kereta").
+			Preload("Stops", func(db *gorm.DB) *gorm.DB {
+				return db.Order("urutan asc")
+			}).
+			Preload("Stops.Stasiun").
+			First(&jadwal, leg.ScheduleID).Error; err != nil {
+			return nil, nil, fmt.Errorf("jadwal %d tidak ditemukan", leg.ScheduleID)
+		}
+
+		naik, turun, err := jadwal.Segmen(leg.NaikStasiunID, leg.TurunStasiunID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("leg %d: %w", i+1, err)
+		}
+
+		if i == 0 {
+			if err := models.NormalisasiPenumpang(req.Penumpangs, jadwal.BerangkatDari(naik)); err != nil {
+				return nil, nil, err
+			}
+			konteks.AsalID = naik.StasiunID
+			konteks.TujuanID = turun.StasiunID
+		} else if turun.StasiunID != konteks.AsalID {
+			// untuk pulang-pergi tujuan tetap tujuan leg pertama
+			konteks.TujuanID = turun.StasiunID
+		}
+		konteks.Kelas = append(konteks.Kelas, jadwal.Kelas)
+
+		fh, err := s.FaktorHarga(tx, []*models.Jadwal{&jadwal})
+		if err != nil {
+			return nil, nil, err
+		}
+		hargaLeg := fh.Harga(&jadwal, jadwal.HargaAntara(naik, turun))
+
+		jumlahPerTipe := make(map[string]int)
+		var urutanTipe []string
+		for _, p := range req.Penumpangs {
+			tipe := p.Tipe
+			if _, ok := jumlahPerTipe[tipe]; !ok {
+				urutanTipe = append(urutanTipe, tipe)
+			}
+			jumlahPerTipe[tipe]++
+		}
+
+		for _, tipe := range urutanTipe {
+			quote.Tambah(models.BookingItem{
+				Kode:        fmt.Sprintf("TIKET-%d-%d-%s", i+1, jadwal.ID, strings.ToUpper(tipe)),
+				Nama:        namaItemTiket(&jadwal, naik, turun, tipe),
+				Jumlah:      jumlahPerTipe[tipe],
+				HargaSatuan: s.hargaPerTipe(hargaLeg, tipe),
+			})
+		}
+	}
+
+	if err := s.tambahLayanan(tx, quote, req); err != nil {
+		return nil, nil, err
+	}
+
+	konteks.Subtotal = quote.Total
+	return quote, konteks, nil
+}
+
+func (s *TarifService) tambahLayanan(tx *gorm.DB, quote *models.Quote, req PermintaanBooking) error {
+	if len(req.Layanan) == 0 {
+		return nil
+	}
+
+	kodes := make([]string, 0, len(req.Layanan))
+	for _, l := range req.Layanan {
+		kodes = append(kodes, l.Kode)
+	}
+	katalog, err := s.layananRepo.ListAktifByKodeTx(tx, kodes)
+	if err != nil {
+		return err
+	}
+	byKode := make(map[string]models.LayananTambahan)
+	for _, k := range katalog {
+		byKode[k.Kode] = k
+	}
+
+	for _, l := range req.Layanan {
+		item, ok := byKode[l.Kode]
+		if !ok {
+			return fmt.Errorf("layanan %s tidak tersedia", l.Kode)
+		}
+		jumlah := l.Jumlah
+		if jumlah == 0 && item.PerPenumpang {
+			jumlah = len(req.Penumpangs)
+		}
+		if jumlah <= 0 {
+			return fmt.Errorf("jumlah layanan %s tidak valid", l.Kode)
+		}
+		quote.Tambah(models.BookingItem{
+			Kode:        "LAYANAN-" + item.Kode,
+			Nama:        item.Nama,
+			Jumlah:      jumlah,
+			HargaSatuan: item.Harga,
+		})
+	}
+	return nil
+}
+
+// FaktorHarga menyimpan faktor harga dinamis sekumpulan jadwal supaya harga
+// yang ditampilkan di pencarian sama dengan harga quote dan booking.
+type FaktorHarga struct {
+	tarif   *TarifService
+	dinamis map[uint]int
+}
+
+func (s *TarifService) FaktorHarga(tx *gorm.DB, jadwals []*models.Jadwal) (*FaktorHarga, error) {
+	dinamis, err := s.dinamis.Faktor(tx, jadwals, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &FaktorHarga{tarif: s, dinamis: dinamis}, nil
+}
+
+// Harga menerapkan harga dinamis lalu faktor kelas ke harga dasar (harga
+// dewasa, sebelum diskon tipe penumpang).
+func (f *FaktorHarga) Harga(j *models.Jadwal, dasar int64) int64 {
+	harga := dasar * int64(f.dinamis[j.ID]) / 100
+	return harga * int64(f.tarif.faktor(j.Kelas)) / 100
+}
+
+// TerapkanHargaTampil mengisi HargaSaatIni dan HargaSegmen pada hasil
+// pencarian.
+func (s *TarifService) TerapkanHargaTampil(ctx context.Context, list []models.Jadwal) error {
+	ptrs := make([]*models.Jadwal, 0, len(list))
+	for i := range list {
+		ptrs = append(ptrs, &list[i])
+	}
+	fh, err := s.FaktorHarga(s.db.WithContext(ctx), ptrs)
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		list[i].HargaSaatIni = fh.Harga(&list[i], list[i].Harga)
+		if list[i].HargaSegmen > 0 {
+			list[i].HargaSegmen = fh.Harga(&list[i], list[i].HargaSegmen)
+		}
+	}
+	return nil
+}
+
+func (s *TarifService) faktor(kelas string) int {
+	if f, ok := s.faktorKelas[strings.ToLower(kelas)]; ok {
+		return f
+	}
+	return 100
+}
+
+func (s *TarifService) hargaPerTipe(harga int64, tipe string) int64 {
+	diskon := s.diskonTipe[tipe]
+	if diskon > 100 {
+		diskon = 100
+	}
+	return harga * int64(100-diskon) / 100
+}
+
+func namaItemTiket(j *models.Jadwal, naik, turun *models.JadwalStop, tipe string) string {
+	nama := fmt.Sprintf("%s %s-%s %s (%s)", j.Kereta.Nama, naik.Stasiun.Kode, turun.Stasiun.Kode, j.Kelas, tipe)
+	// batas panjang nama item Midtrans
+	if r := []rune(nama); len(r) > 50 {
+		nama = string(r[:50])
+	}
+	return nama
+}
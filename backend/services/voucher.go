@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/fitranmei/Mooove-/backend/models"
+)
+
+// KonteksVoucher berisi data booking yang dibutuhkan untuk memeriksa syarat
+// voucher.
+type KonteksVoucher struct {
+	UserID   *uint
+	AsalID   uint
+	TujuanID uint
+	Kelas    []string
+	Subtotal int64
+}
+
+type VoucherService struct {
+	db *gorm.DB
+}
+
+func NewVoucherService(db *gorm.DB) *VoucherService {
+	return &VoucherService{db: db}
+}
+
+func (s *VoucherService) cari(tx *gorm.DB, kode string, kunci bool) (*models.Voucher, error) {
+	var v models.Voucher
+	q := tx
+	if kunci {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := q.Where("kode = ? AND aktif = ?", strings.ToUpper(strings.TrimSpace(kode)), true).First(&v).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("voucher %s tidak ditemukan", kode)
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// periksaSyarat memeriksa masa berlaku, minimal belanja, rute, dan kelas.
+func periksaSyarat(v *models.Voucher, k KonteksVoucher, now time.Time) error {
+	if v.BerlakuMulai != nil && now.Before(*v.BerlakuMulai) {
+		return errors.New("voucher belum berlaku")
+	}
+	if v.BerlakuSampai != nil && now.After(*v.BerlakuSampai) {
+		return errors.New("voucher sudah kedaluwarsa")
+	}
+	if k.Subtotal < v.MinBelanja {
+		return fmt.Errorf("minimal belanja untuk voucher ini Rp%d", v.MinBelanja)
+	}
+	if v.AsalID != nil && *v.AsalID != k.AsalID {
+		return errors.New("voucher tidak berlaku untuk stasiun asal ini")
+	}
+	if v.TujuanID != nil && *v.TujuanID != k.TujuanID {
+		return errors.New("voucher tidak berlaku untuk stasiun tujuan ini")
+	}
+	if v.Kelas != "" {
+		for _, kelas := range k.Kelas {
+			if !strings.EqualFold(kelas, v.Kelas) {
+				return fmt.Errorf("voucher hanya berlaku untuk kelas %s", v.Kelas)
+			}
+		}
+	}
+	return nil
+}
+
+// pemakaianAktif menghitung redemption yang masih menahan kuota, yaitu yang
+// bookingnya masih pending atau sudah dibayar.
+func pemakaianAktif(tx *gorm.DB, voucherID uint, userID *uint) (int64, error) {
+	var n int64
+	q := tx.Model(&models.VoucherRedemption{}).
+		Joins("JOIN bookings ON bookings.id = voucher_redemptions.booking_id").
+		Where("voucher_redemptions.voucher_id = ?", voucherID).
+		Where("voucher_redemptions.status IN ?", []string{"reserved", "redeemed"}).
+		Where("bookings.status IN ?", []string{"pending", "paid"})
+	if userID != nil {
+		q = q.Where("voucher_redemptions.user_id = ?", *userID)
+	}
+	if err := q.Count(&n).Error; err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Pratinjau menghitung potongan tanpa menahan kuota, dipakai untuk quote.
+func (s *VoucherService) Pratinjau(ctx context.Context, kode string, k KonteksVoucher) (*models.BookingItem, error) {
+	tx := s.db.WithContext(ctx)
+	v, err := s.cari(tx, kode, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := periksaSyarat(v, k, time.Now()); err != nil {
+		return nil, err
+	}
+	return itemVoucher(v, k.Subtotal), nil
+}
+
+// Reservasi mengunci baris voucher, memeriksa kuota global dan per user, lalu
+// mencatat redemption berstatus reserved untuk booking. Harus dipanggil di
+// dalam transaksi yang sama dengan penguncian kursi.
+func (s *VoucherService) Reservasi(tx *gorm.DB, kode string, bookingID uint, k KonteksVoucher) (*models.BookingItem, error) {
+	v, err := s.cari(tx, kode, true)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := periksaSyarat(v, k, now); err != nil {
+		return nil, err
+	}
+
+	if v.KuotaGlobal > 0 {
+		n, err := pemakaianAktif(tx, v.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		if n >= int64(v.KuotaGlobal) {
+			return nil, errors.New("kuota voucher sudah habis")
+		}
+	}
+	if v.KuotaPerUser > 0 {
+		if k.UserID == nil {
+			return nil, errors.New("voucher ini hanya untuk pengguna yang login")
+		}
+		n, err := pemakaianAktif(tx, v.ID, k.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if n >= int64(v.KuotaPerUser) {
+			return nil, errors.New("batas pemakaian voucher untuk akun ini sudah tercapai")
+		}
+	}
+
+	item := itemVoucher(v, k.Subtotal)
+	red := models.VoucherRedemption{
+		VoucherID: v.ID,
+		UserID:    k.UserID,
+		BookingID: bookingID,
+		Potongan:  -item.Subtotal,
+		Status:    "reserved",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := tx.Create(&red).Error; err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func itemVoucher(v *models.Voucher, subtotal int64) *models.BookingItem {
+	potongan := v.Potongan(subtotal)
+	return &models.BookingItem{
+		Kode:        "VOUCHER-" + v.Kode,
+		Nama:        "Voucher " + v.Kode,
+		Jumlah:      1,
+		HargaSatuan: -potongan,
+		Subtotal:    -potongan,
+	}
+}
+
+// SetStatusRedemption memindahkan redemption booking ke status baru (redeemed
+// atau released).
+func SetStatusRedemption(tx *gorm.DB, bookingID uint, status string) error {
+	return tx.Model(&models.VoucherRedemption{}).
+		Where("booking_id = ? AND status = ?", bookingID, "reserved").
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": time.Now(),
+		}).Error
+}
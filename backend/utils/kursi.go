@@ -1,40 +1,28 @@
 package utils
 
 import (
-	"fmt"
-
 	"github.com/fitranmei/Mooove-/backend/models"
+	"github.com/fitranmei/Mooove-/backend/repositories"
 	"gorm.io/gorm"
 )
 
 func GenerateKursiUntukGerbong(db *gorm.DB, gerbong *models.Gerbong) error {
-	capacity := gerbong.KapasitasKursi
-	if capacity <= 0 {
-		capacity = 64
-	}
-
-	cols := []rune{'A', 'B', 'C', 'D'}
-	rows := capacity / len(cols)
-	if capacity%len(cols) != 0 {
-		rows++
+	tataLetak, err := repositories.AmbilTataLetak(db, gerbong)
+	if err != nil {
+		return err
 	}
-
-	var kursiList []models.Kursi
-	counter := 0
-
-	for r := 1; r <= rows; r++ {
-		for _, col := range cols {
-			counter++
-			if counter > capacity {
-				break
-			}
-			nomor := fmt.Sprintf("%d%c", r, col)
-			kursiList = append(kursiList, models.Kursi{
-				GerbongID:  gerbong.ID,
-				NomorKursi: nomor,
-			})
+	// Simpan tata letak yang dipakai supaya gerbong tidak ikut berubah saat
+	// template kelasnya diganti dan tidak perlu dicari ulang setiap request.
+	if gerbong.TataLetakID == nil && tataLetak.ID != 0 {
+		if err := db.Model(gerbong).Update("tata_letak_id", tataLetak.ID).Error; err != nil {
+			return err
 		}
+		gerbong.TataLetakID = &tataLetak.ID
 	}
 
+	kursiList := GenerateListKursiMenggunakanKapasitas(gerbong.ID, gerbong.KapasitasKursi, tataLetak)
+	if len(kursiList) == 0 {
+		return nil
+	}
 	return db.Create(&kursiList).Error
 }
@@ -75,6 +75,7 @@ export default function MyTicket({ navigation }) {
                 bookingCode: 'BOOK-' + booking.ID,
                 bookingId: booking.ID,
                 reservedUntil: (() => {
+                    if (booking.expires_at) return booking.expires_at;
                     if (booking.reserved_until) return booking.reserved_until;
                     if (booking.ReservedUntil) return booking.ReservedUntil;
                     
@@ -97,7 +97,7 @@ export default function PaymentConfirmation({ navigation, route }) {
 
         setBookingId(booking.booking_id);
 
-        let reservedUntil = booking.reserved_until || booking.ReservedUntil;
+        let reservedUntil = booking.expires_at || booking.reserved_until || booking.ReservedUntil;
         if (!reservedUntil && booking.reserved_seats && booking.reserved_seats.length > 0) {
             reservedUntil = booking.reserved_seats[0].reserved_until;
         }
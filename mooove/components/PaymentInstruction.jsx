@@ -80,7 +80,7 @@ export default function PaymentInstruction({ navigation, route }) {
                     const details = await getBookingDetails(bookingId);
                     
                     if (details) {
-                        let freshReservedUntil = details.reserved_until || details.ReservedUntil;
+                        let freshReservedUntil = details.expires_at || details.reserved_until || details.ReservedUntil;
 
                         if (!freshReservedUntil) {
                             const seats = details.Kursis || details.Seats || details.ketersediaan_kursis || details.SeatAvailabilities || [];
@@ -32,7 +32,9 @@ export default function SeatSelection({ navigation, route }) {
     const [selectedCarriage, setSelectedCarriage] = useState(null);
     const [allData, setAllData] = useState(null); // Store full response
 
-    const columns = ['A', 'B', 'C', 'D'];
+    // Column groups follow the carriage layout, '|' marks the aisle (e.g. "AB|CDE")
+    const [columnGroups, setColumnGroups] = useState([['A', 'B'], ['C', 'D']]);
+    const columns = columnGroups.flat();
 
     useEffect(() => {
         const fetchData = async () => {
@@ -65,6 +67,10 @@ export default function SeatSelection({ navigation, route }) {
         if (!currentCarriage) return;
 
         const carriageSeats = currentCarriage.kursi || [];
+        const layout = (currentCarriage.tata_letak && currentCarriage.tata_letak.kolom) || 'AB|CD';
+        const groups = layout.split('|').map(g => g.split(''));
+        const layoutColumns = groups.flat();
+        setColumnGroups(groups);
         
         let maxRow = 0;
         carriageSeats.forEach(s => {
@@ -79,9 +85,9 @@ export default function SeatSelection({ navigation, route }) {
         const grid = [];
 
         for (let r = 1; r <= maxRow; r++) {
-            const rowSeats = [0, 0, 0, 0]; // A, B, C, D (0: available, 1: occupied)
-            
-            columns.forEach((col, colIndex) => {
+            const rowSeats = layoutColumns.map(() => 0); // 0: available, 1: occupied
+
+            layoutColumns.forEach((col, colIndex) => {
                 const seatNum = `${r}${col}`;
                 const seatData = carriageSeats.find(s => s.nomor_kursi === seatNum);
                 
@@ -216,29 +222,32 @@ export default function SeatSelection({ navigation, route }) {
                 <View style={styles.seatLayoutContainer}>
                     <View style={styles.columnHeaders}>
                         <View style={{ width: 30 }} /> 
-                        <View style={styles.columnGroup}>
-                            <AppText style={styles.colHeader}>A</AppText>
-                            <AppText style={styles.colHeader}>B</AppText>
-                        </View>
-                        <View style={{ width: 40 }} /> 
-                        <View style={styles.columnGroup}>
-                            <AppText style={styles.colHeader}>C</AppText>
-                            <AppText style={styles.colHeader}>D</AppText>
-                        </View>
+                        {columnGroups.map((group, groupIndex) => (
+                            <React.Fragment key={groupIndex}>
+                                {groupIndex > 0 && <View style={{ width: 40 }} />}
+                                <View style={styles.columnGroup}>
+                                    {group.map(col => (
+                                        <AppText key={col} style={styles.colHeader}>{col}</AppText>
+                                    ))}
+                                </View>
+                            </React.Fragment>
+                        ))}
                     </View>
 
                     {seats.map((row, rowIndex) => (
                         <View key={rowIndex} style={styles.seatRow}>
                             <AppText style={styles.rowLabel}>{row.row}</AppText>
-                            <View style={styles.seatGroup}>
-                                {renderSeat(row.seats[0], rowIndex, 0)}
-                                {renderSeat(row.seats[1], rowIndex, 1)}
-                            </View>
-                            <View style={{ width: 40 }} /> 
-                            <View style={styles.seatGroup}>
-                                {renderSeat(row.seats[2], rowIndex, 2)}
-                                {renderSeat(row.seats[3], rowIndex, 3)}
-                            </View>
+                            {columnGroups.map((group, groupIndex) => {
+                                const offset = columnGroups.slice(0, groupIndex).reduce((n, g) => n + g.length, 0);
+                                return (
+                                    <React.Fragment key={groupIndex}>
+                                        {groupIndex > 0 && <View style={{ width: 40 }} />}
+                                        <View style={styles.seatGroup}>
+                                            {group.map((_, i) => renderSeat(row.seats[offset + i], rowIndex, offset + i))}
+                                        </View>
+                                    </React.Fragment>
+                                );
+                            })}
                         </View>
                     ))}
                 </View>
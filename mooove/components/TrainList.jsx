@@ -99,7 +99,7 @@ export default function TrainList({ navigation, route }) {
                     
                     grouped[key].classes.push({
                         type: item.kelas ? item.kelas.toUpperCase() : '',
-                        price: item.harga_dasar,
+                        price: item.harga_segmen || item.harga_saat_ini || item.harga_dasar,
                         scheduleId: item.id
                     });
                 });
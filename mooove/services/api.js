@@ -31,6 +31,41 @@ api.interceptors.request.use(async (request) => {
     return request;
 });
 
+// access token berumur pendek: saat 401, tukar refresh token sekali lalu
+// ulangi request
+let refreshing = null;
+
+api.interceptors.response.use(
+    (response) => response,
+    async (error) => {
+        const original = error.config;
+        if (!error.response || error.response.status !== 401 || original._retry || original.url === '/auth/refresh') {
+            return Promise.reject(error);
+        }
+        original._retry = true;
+
+        const refreshToken = await AsyncStorage.getItem('refreshToken');
+        if (!refreshToken) {
+            return Promise.reject(error);
+        }
+
+        try {
+            if (!refreshing) {
+                refreshing = api.post('/auth/refresh', { refresh_token: refreshToken })
+                    .finally(() => { refreshing = null; });
+            }
+            const { data } = await refreshing;
+            await AsyncStorage.setItem('userToken', data.token);
+            await AsyncStorage.setItem('refreshToken', data.refresh_token);
+            original.headers.Authorization = `Bearer ${data.token}`;
+            return api(original);
+        } catch (refreshError) {
+            await AsyncStorage.multiRemove(['userToken', 'refreshToken', 'userData']);
+            return Promise.reject(error);
+        }
+    }
+);
+
 export const getStations = async () => {
     try {
         const response = await api.get('/stasiun');
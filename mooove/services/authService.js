@@ -23,6 +23,7 @@ export const login = async (email, password) => {
         
         if (response.data.token) {
             await AsyncStorage.setItem('userToken', response.data.token);
+            await AsyncStorage.setItem('refreshToken', response.data.refresh_token);
             await AsyncStorage.setItem('userData', JSON.stringify(response.data.user));
         }
         
@@ -33,7 +34,16 @@ export const login = async (email, password) => {
 };
 
 export const logout = async () => {
+    const refreshToken = await AsyncStorage.getItem('refreshToken');
+    if (refreshToken) {
+        try {
+            await api.post('/auth/logout', { refresh_token: refreshToken });
+        } catch (error) {
+            // sesi lokal tetap dihapus walau server tidak terjangkau
+        }
+    }
     await AsyncStorage.removeItem('userToken');
+    await AsyncStorage.removeItem('refreshToken');
     await AsyncStorage.removeItem('userData');
 };
 